@@ -36,18 +36,51 @@ aws dynamodb put-item \
         "parameterCount": {"N": "1"},
         "description": {"S": "Su codigo de verificacion para $empresa es: $codigo"},
         "active": {"BOOL": true},
+        "GSI2PK": {"S": "TEMPLATE_TYPE#sms"},
+        "GSI2SK": {"S": "ACTIVE#true#sms_verification_code"},
+        "createdAt": {"S": "'$(date -u +%Y-%m-%dT%H:%M:%SZ)'"}
+    }' > /dev/null
+
+echo -e "${GREEN}✓${NC}"
+
+# Template 2: Recordatorio de cita con confirmación (SMS)
+echo -n "Creando template: sms_recordatorio_cita... "
+aws dynamodb put-item \
+    --table-name $TABLE_NAME \
+    --endpoint-url $ENDPOINT \
+    --region us-east-1 \
+    --item '{
+        "PK": {"S": "TEMPLATE#sms_recordatorio_cita"},
+        "SK": {"S": "METADATA"},
+        "templateId": {"S": "sms_recordatorio_cita"},
+        "name": {"S": "Recordatorio de Cita SMS"},
+        "type": {"S": "sms"},
+        "provider": {"S": "twilio"},
+        "externalId": {"S": ""},
+        "parameters": {"L": [
+            {"S": "name"},
+            {"S": "service"},
+            {"S": "date"}
+        ]},
+        "parameterCount": {"N": "3"},
+        "description": {"S": "$empresa: Hola $name, tu $service es el $date. Responde 1 para confirmar o 2 para cancelar."},
+        "active": {"BOOL": true},
+        "GSI2PK": {"S": "TEMPLATE_TYPE#sms"},
+        "GSI2SK": {"S": "ACTIVE#true#sms_recordatorio_cita"},
         "createdAt": {"S": "'$(date -u +%Y-%m-%dT%H:%M:%SZ)'"}
     }' > /dev/null
 
 echo -e "${GREEN}✓${NC}"
 
 echo ""
-echo -e "${GREEN}✓ Template de SMS creado exitosamente${NC}"
+echo -e "${GREEN}✓ Templates de SMS creados exitosamente${NC}"
 echo ""
-echo "Template disponible:"
+echo "Templates disponibles:"
 echo "  - sms_verification_code"
 echo "    • codigo: Código numérico de 4-6 dígitos"
 echo "    • empresa: Se toma automáticamente del negocio"
+echo "  - sms_recordatorio_cita (recordatorios de /v1/appointments)"
+echo "    • name, service, date: Se completan desde la cita"
 echo ""
 echo "Formato del mensaje:"
 echo "  Su codigo de verificacion para [EMPRESA] es: [CODIGO]"
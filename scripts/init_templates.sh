@@ -39,6 +39,10 @@ aws dynamodb put-item \
         "parameterCount": {"N": "4"},
         "description": {"S": "Header: {{1}}=empresa | Body: 👋Hola {{2}}=nombre, recordatorio: tu {{3}}=servicio está programado para {{4}}=fecha según nuestro registro."},
         "active": {"BOOL": true},
+        "GSI1PK": {"S": "TEMPLATE_TYPE#whatsapp"},
+        "GSI1SK": {"S": "EXTERNAL#HX334eac2cb8f3264c1cd104107bd39584"},
+        "GSI2PK": {"S": "TEMPLATE_TYPE#whatsapp"},
+        "GSI2SK": {"S": "ACTIVE#true#recordatorio_general"},
         "createdAt": {"S": "'$(date -u +%Y-%m-%dT%H:%M:%SZ)'"}
     }' > /dev/null
 
@@ -67,6 +71,10 @@ aws dynamodb put-item \
         "parameterCount": {"N": "4"},
         "description": {"S": "Header: {{1}}=empresa | Body: 👋 ¡Hola {{2}}=nombre!, tenemos una actualización sobre tu {{3}}=servicio. 📍 Estado actual: {{4}}=estado. Te mantendremos informado(a)."},
         "active": {"BOOL": true},
+        "GSI1PK": {"S": "TEMPLATE_TYPE#whatsapp"},
+        "GSI1SK": {"S": "EXTERNAL#HX879326777b5252e74303734ca3b8066d"},
+        "GSI2PK": {"S": "TEMPLATE_TYPE#whatsapp"},
+        "GSI2SK": {"S": "ACTIVE#true#actualizacion_estado"},
         "createdAt": {"S": "'$(date -u +%Y-%m-%dT%H:%M:%SZ)'"}
     }' > /dev/null
 
@@ -95,6 +103,10 @@ aws dynamodb put-item \
         "parameterCount": {"N": "4"},
         "description": {"S": "Header: {{1}}=empresa | Body: 👋 Hola {{2}}=nombre, tu {{3}}=servicio ha sido confirmado(a). 📌 Detalle: {{4}}=detalle. Si necesitas más información, contáctanos."},
         "active": {"BOOL": true},
+        "GSI1PK": {"S": "TEMPLATE_TYPE#whatsapp"},
+        "GSI1SK": {"S": "EXTERNAL#HXa771b1c388451770e47d0291001c54b5"},
+        "GSI2PK": {"S": "TEMPLATE_TYPE#whatsapp"},
+        "GSI2SK": {"S": "ACTIVE#true#confirmacion_general"},
         "createdAt": {"S": "'$(date -u +%Y-%m-%dT%H:%M:%SZ)'"}
     }' > /dev/null
 
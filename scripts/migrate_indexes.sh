@@ -0,0 +1,88 @@
+#!/bin/bash
+
+# Script para agregar los índices GSI1/GSI2 a una tabla existente
+# y completar las claves de índice en los templates ya creados
+
+set -e
+
+TABLE_NAME="NotificationService"
+ENDPOINT="http://localhost:8000"
+
+# Colores
+GREEN='\033[0;32m'
+YELLOW='\033[1;33m'
+NC='\033[0m'
+
+echo "🔧 Migrando índices de $TABLE_NAME"
+echo "=================================="
+echo ""
+
+# Crear un índice si aún no existe en la tabla
+create_index() {
+    local index_name=$1
+
+    if aws dynamodb describe-table \
+        --table-name $TABLE_NAME \
+        --endpoint-url $ENDPOINT \
+        --region us-east-1 \
+        --query "Table.GlobalSecondaryIndexes[?IndexName=='$index_name'].IndexName" \
+        --output text | grep -q "$index_name"; then
+        echo -e "Índice $index_name... ${YELLOW}Ya existe${NC}"
+        return
+    fi
+
+    echo -n "Creando índice $index_name... "
+    aws dynamodb update-table \
+        --table-name $TABLE_NAME \
+        --endpoint-url $ENDPOINT \
+        --region us-east-1 \
+        --attribute-definitions \
+            AttributeName=${index_name}PK,AttributeType=S \
+            AttributeName=${index_name}SK,AttributeType=S \
+        --global-secondary-index-updates \
+            '[{"Create":{"IndexName":"'$index_name'","KeySchema":[{"AttributeName":"'$index_name'PK","KeyType":"HASH"},{"AttributeName":"'$index_name'SK","KeyType":"RANGE"}],"Projection":{"ProjectionType":"ALL"}}}]' > /dev/null
+
+    aws dynamodb wait table-exists --table-name $TABLE_NAME --endpoint-url $ENDPOINT --region us-east-1
+    echo -e "${GREEN}✓${NC}"
+}
+
+create_index GSI1
+create_index GSI2
+
+# Completar claves de índice en templates existentes
+echo ""
+echo "Actualizando claves de índice en templates..."
+
+aws dynamodb scan \
+    --table-name $TABLE_NAME \
+    --endpoint-url $ENDPOINT \
+    --region us-east-1 \
+    --filter-expression "begins_with(PK, :pk) AND SK = :sk" \
+    --expression-attribute-values '{":pk": {"S": "TEMPLATE#"}, ":sk": {"S": "METADATA"}}' \
+    --query "Items[].[templateId.S, #t.S, active.BOOL, externalId.S]" \
+    --expression-attribute-names '{"#t": "type"}' \
+    --output text | while read -r template_id template_type active external_id; do
+
+    active=$(echo "$active" | tr '[:upper:]' '[:lower:]')
+
+    if [ -n "$external_id" ] && [ "$external_id" != "None" ]; then
+        update_expr="SET GSI1PK = :type, GSI1SK = :ext, GSI2PK = :type, GSI2SK = :active"
+        values='{":type": {"S": "TEMPLATE_TYPE#'$template_type'"}, ":ext": {"S": "EXTERNAL#'$external_id'"}, ":active": {"S": "ACTIVE#'$active'#'$template_id'"}}'
+    else
+        update_expr="SET GSI2PK = :type, GSI2SK = :active"
+        values='{":type": {"S": "TEMPLATE_TYPE#'$template_type'"}, ":active": {"S": "ACTIVE#'$active'#'$template_id'"}}'
+    fi
+
+    echo -n "  $template_id... "
+    aws dynamodb update-item \
+        --table-name $TABLE_NAME \
+        --endpoint-url $ENDPOINT \
+        --region us-east-1 \
+        --key '{"PK": {"S": "TEMPLATE#'$template_id'"}, "SK": {"S": "METADATA"}}' \
+        --update-expression "$update_expr" \
+        --expression-attribute-values "$values" > /dev/null
+    echo -e "${GREEN}✓${NC}"
+done
+
+echo ""
+echo -e "${GREEN}✓ Migración completada${NC}"
@@ -50,9 +50,16 @@ else
         --attribute-definitions \
             AttributeName=PK,AttributeType=S \
             AttributeName=SK,AttributeType=S \
+            AttributeName=GSI1PK,AttributeType=S \
+            AttributeName=GSI1SK,AttributeType=S \
+            AttributeName=GSI2PK,AttributeType=S \
+            AttributeName=GSI2SK,AttributeType=S \
         --key-schema \
             AttributeName=PK,KeyType=HASH \
             AttributeName=SK,KeyType=RANGE \
+        --global-secondary-indexes \
+            '[{"IndexName":"GSI1","KeySchema":[{"AttributeName":"GSI1PK","KeyType":"HASH"},{"AttributeName":"GSI1SK","KeyType":"RANGE"}],"Projection":{"ProjectionType":"ALL"}},
+              {"IndexName":"GSI2","KeySchema":[{"AttributeName":"GSI2PK","KeyType":"HASH"},{"AttributeName":"GSI2SK","KeyType":"RANGE"}],"Projection":{"ProjectionType":"ALL"}}]' \
         --billing-mode PAY_PER_REQUEST \
         --endpoint-url $ENDPOINT \
         --region us-east-1 > /dev/null
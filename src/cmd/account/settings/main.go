@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func AccountSettingsHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	var req services.UpdateAccountSettingsRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	info, err := services.UpdateAccountSettingsService(apiKey, req)
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "invalid email provider", "invalid domain", "invalid webhook url", "invalid country", "invalid country policy", "invalid channel",
+			"invalid delivery window", "no settings to update":
+			statusCode = 400
+		case "sender not verified":
+			statusCode = 403
+		case "service unavailable":
+			statusCode = 503
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, info), nil
+}
+
+func main() {
+	lambda.Start(AccountSettingsHandler)
+}
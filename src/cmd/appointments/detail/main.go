@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// AppointmentHandler atiende GET (consultar), PATCH (mover o editar) y DELETE (cancelar) de una cita
+func AppointmentHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	appointmentID := request.PathParameters["id"]
+	if appointmentID == "" {
+		return response.ErrorResponse(400, "appointment id is required"), nil
+	}
+
+	switch request.HTTPMethod {
+	case "GET":
+		result, err := services.GetAppointmentService(apiKey, appointmentID)
+		if err != nil {
+			return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+		}
+		return response.SuccessResponse(200, result), nil
+
+	case "DELETE":
+		result, err := services.CancelAppointmentService(apiKey, appointmentID)
+		if err != nil {
+			return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+		}
+		return response.SuccessResponse(200, result), nil
+	}
+
+	var req services.AppointmentRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	result, err := services.UpdateAppointmentService(apiKey, appointmentID, req)
+	if err != nil {
+		return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, result), nil
+}
+
+// errorStatus traduce los errores de la cita y de la programación de sus recordatorios (SMS, WhatsApp)
+func errorStatus(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "notification limit reached":
+		return 429
+	case "invalid channel", "to or contact_id is required", "contact_id cannot be combined with to",
+		"service_name is required", "invalid start_at", "invalid timezone",
+		"invalid reminders", "too many reminders", "reminder too far in advance",
+		"invalid phone number format", "invalid template", "invalid template type", "invalid template parameters",
+		"missing required parameters", "message too long", "template requires media", "contact has no address for channel":
+		return 400
+	case "appointment not found", "template not found", "template not available", "contact not found":
+		return 404
+	case "contact consent not granted":
+		return 403
+	case "appointment cancelled", "appointment modified":
+		return 409
+	case "phone number cannot receive sms":
+		return 422
+	case "service unavailable", "service temporarily unavailable":
+		return 503
+	}
+	switch {
+	case strings.HasPrefix(errMsg, "recipient suppressed"):
+		return 422
+	case strings.HasPrefix(errMsg, "destination country not allowed:"):
+		return 403
+	}
+	return 500
+}
+
+func main() {
+	lambda.Start(AppointmentHandler)
+}
@@ -12,19 +12,21 @@ import (
 )
 
 type RegisterRequest struct {
-	Name   string `json:"name" validate:"required"`
-	Email  string `json:"email" validate:"required,email"`
-	Phone  string `json:"phone" validate:"required"`
-	PlanID string `json:"plan_id"`
+	Name    string `json:"name" validate:"required"`
+	Email   string `json:"email" validate:"required,email"`
+	Phone   string `json:"phone" validate:"required"`
+	Country string `json:"country"` // ISO alfa-2, para teléfonos en formato nacional
+	PlanID  string `json:"plan_id"`
 }
 
 type RegisterResponse struct {
-	IDBusiness string `json:"id_business"`
-	Name       string `json:"name"`
-	Email      string `json:"email"`
-	Phone      string `json:"phone"`
-	PlanID     string `json:"plan_id"`
-	APIKey     string `json:"api_key"`
+	IDBusiness     string `json:"id_business"`
+	Name           string `json:"name"`
+	Email          string `json:"email"`
+	Phone          string `json:"phone"`
+	DefaultCountry string `json:"default_country,omitempty"`
+	PlanID         string `json:"plan_id"`
+	APIKey         string `json:"api_key"`
 }
 
 func RegisterBusinessHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -42,20 +44,25 @@ func RegisterBusinessHandler(request events.APIGatewayProxyRequest) (events.APIG
 		req.Name,
 		req.Email,
 		req.Phone,
+		req.Country,
 		req.PlanID,
 	)
 
 	if err != nil {
+		if err.Error() == "invalid phone number format" || err.Error() == "invalid country" {
+			return response.ErrorResponse(400, err.Error()), nil
+		}
 		return response.ErrorResponse(500, err.Error()), nil
 	}
 
 	resp := RegisterResponse{
-		IDBusiness: result.BusinessID,
-		Name:       req.Name,
-		Email:      req.Email,
-		Phone:      req.Phone,
-		PlanID:     req.PlanID,
-		APIKey:     result.APIKey,
+		IDBusiness:     result.BusinessID,
+		Name:           req.Name,
+		Email:          req.Email,
+		Phone:          result.Phone,
+		DefaultCountry: result.DefaultCountry,
+		PlanID:         req.PlanID,
+		APIKey:         result.APIKey,
 	}
 
 	return response.SuccessResponse(200, resp), nil
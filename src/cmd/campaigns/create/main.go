@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/models"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/go-playground/validator/v10"
+)
+
+type CreateCampaignRequest struct {
+	Name          string            `json:"name" validate:"required"`
+	Type          string            `json:"type" validate:"required,oneof=whatsapp sms"`
+	TemplateID    string            `json:"template_id" validate:"required"`
+	CSV           string            `json:"csv" validate:"required,base64"`
+	PhoneColumn   string            `json:"phone_column"`
+	Columns       map[string]string `json:"columns"`
+	Parameters    map[string]string `json:"parameters"`
+	ScheduledAt   string            `json:"scheduled_at"`
+	RatePerMinute int               `json:"rate_per_minute" validate:"omitempty,min=1,max=500"`
+
+	Category       string                 `json:"category" validate:"omitempty,oneof=marketing transactional otp"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+}
+
+// CreateCampaignHandler crea una campaña desde un CSV; el envío lo hace el worker de campañas
+func CreateCampaignHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	var req CreateCampaignRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	result, err := services.CreateCampaignService(apiKey, services.CreateCampaignRequest{
+		Name:          req.Name,
+		Type:          req.Type,
+		TemplateID:    req.TemplateID,
+		CSV:           req.CSV,
+		PhoneColumn:   req.PhoneColumn,
+		Columns:       req.Columns,
+		Parameters:    req.Parameters,
+		ScheduledAt:   req.ScheduledAt,
+		RatePerMinute: req.RatePerMinute,
+
+		Category:       req.Category,
+		DeliveryWindow: req.DeliveryWindow,
+	})
+	if err != nil {
+		return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(201, result), nil
+}
+
+func errorStatus(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "name is required", "invalid campaign type", "template_id is required", "invalid rate_per_minute",
+		"invalid scheduled_at", "invalid csv", "phone column not found", "invalid column mapping",
+		"too many recipients", "no valid recipients", "invalid template", "invalid template type",
+		"template requires media", "invalid category", "invalid delivery window":
+		return 400
+	case "template not available":
+		return 404
+	case "csv too large":
+		return 413
+	case "service unavailable":
+		return 503
+	}
+	if strings.HasPrefix(errMsg, "column not found:") {
+		return 400
+	}
+	return 500
+}
+
+func main() {
+	lambda.Start(CreateCampaignHandler)
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// GetCampaignHandler retorna el estado y el progreso de una campaña
+func GetCampaignHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	campaignID := request.PathParameters["id"]
+	if campaignID == "" {
+		return response.ErrorResponse(400, "campaign id is required"), nil
+	}
+
+	campaign, err := services.GetCampaignService(apiKey, campaignID)
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "campaign not found":
+			statusCode = 404
+		case "service unavailable":
+			statusCode = 503
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, campaign), nil
+}
+
+func main() {
+	lambda.Start(GetCampaignHandler)
+}
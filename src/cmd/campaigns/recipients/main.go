@@ -0,0 +1,61 @@
+package main
+
+import (
+	"strconv"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// ListCampaignRecipientsHandler lista el resultado de cada destinatario de una campaña
+func ListCampaignRecipientsHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	campaignID := request.PathParameters["id"]
+	if campaignID == "" {
+		return response.ErrorResponse(400, "campaign id is required"), nil
+	}
+
+	limit := 50
+	if value := request.QueryStringParameters["limit"]; value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > 100 {
+			return response.ErrorResponse(400, "limit must be between 1 and 100"), nil
+		}
+		limit = parsed
+	}
+
+	result, err := services.ListCampaignRecipientsService(apiKey, campaignID, request.QueryStringParameters["status"], int32(limit), request.QueryStringParameters["cursor"])
+	if err != nil {
+		return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, result), nil
+}
+
+func errorStatus(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "invalid status", "invalid cursor":
+		return 400
+	case "campaign not found":
+		return 404
+	case "service unavailable":
+		return 503
+	}
+	return 500
+}
+
+func main() {
+	lambda.Start(ListCampaignRecipientsHandler)
+}
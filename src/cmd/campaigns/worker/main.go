@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"notify-backend/internal/services"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Tiempo mínimo restante para tomar otra campaña (una tanda de 500 destinatarios)
+const campaignReserve = 45 * time.Second
+
+// CampaignWorkerHandler se ejecuta cada minuto y envía la siguiente tanda de cada campaña pendiente
+func CampaignWorkerHandler(ctx context.Context, event events.CloudWatchEvent) error {
+	return services.ProcessCampaignsService(ctx, campaignReserve)
+}
+
+func main() {
+	lambda.Start(CampaignWorkerHandler)
+}
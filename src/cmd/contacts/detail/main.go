@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// ContactHandler atiende GET (consultar), PATCH (actualizar) y DELETE (eliminar) de un contacto
+func ContactHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	contactID := request.PathParameters["id"]
+	if contactID == "" {
+		return response.ErrorResponse(400, "contact id is required"), nil
+	}
+
+	switch request.HTTPMethod {
+	case "GET":
+		result, err := services.GetContactService(apiKey, contactID)
+		if err != nil {
+			return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+		}
+		return response.SuccessResponse(200, result), nil
+
+	case "DELETE":
+		if err := services.DeleteContactService(apiKey, contactID); err != nil {
+			return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+		}
+		return response.SuccessResponse(200, map[string]bool{"deleted": true}), nil
+	}
+
+	var req services.ContactRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	result, err := services.UpdateContactService(apiKey, contactID, req)
+	if err != nil {
+		return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, result), nil
+}
+
+func errorStatus(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "invalid phone number format", "invalid locale", "invalid timezone", "invalid attributes",
+		"too many attributes", "invalid channel", "contact address is required":
+		return 400
+	case "contact not found":
+		return 404
+	case "service unavailable":
+		return 503
+	}
+	if strings.HasPrefix(errMsg, "invalid email address") {
+		return 400
+	}
+	return 500
+}
+
+func main() {
+	lambda.Start(ContactHandler)
+}
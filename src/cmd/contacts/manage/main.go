@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// ContactsHandler atiende GET (listar) y POST (crear) sobre los contactos del negocio
+func ContactsHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	if request.HTTPMethod == "GET" {
+		limit := 50
+		if value := request.QueryStringParameters["limit"]; value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > 100 {
+				return response.ErrorResponse(400, "limit must be between 1 and 100"), nil
+			}
+			limit = parsed
+		}
+
+		result, err := services.ListContactsService(apiKey, int32(limit), request.QueryStringParameters["cursor"])
+		if err != nil {
+			return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+		}
+		return response.SuccessResponse(200, result), nil
+	}
+
+	var req services.ContactRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	result, err := services.CreateContactService(apiKey, req)
+	if err != nil {
+		return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(201, result), nil
+}
+
+func errorStatus(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "invalid cursor", "invalid phone number format", "invalid locale", "invalid timezone", "invalid attributes",
+		"too many attributes", "invalid channel", "contact address is required":
+		return 400
+	case "service unavailable":
+		return 503
+	}
+	if strings.HasPrefix(errMsg, "invalid email address") {
+		return 400
+	}
+	return 500
+}
+
+func main() {
+	lambda.Start(ContactsHandler)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/go-playground/validator/v10"
+)
+
+type UploadFileRequest struct {
+	Filename    string `json:"filename" validate:"required"`
+	Content     string `json:"content" validate:"required,base64"`
+	ContentType string `json:"content_type"`
+}
+
+func UploadFileHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	var req UploadFileRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body: "+err.Error()), nil
+	}
+
+	serviceReq := services.UploadFileRequest{
+		Filename:    req.Filename,
+		Content:     req.Content,
+		ContentType: req.ContentType,
+	}
+
+	result, err := services.UploadFileService(apiKey, serviceReq)
+	if err != nil {
+		statusCode := 500
+		errMsg := err.Error()
+
+		if errMsg == "authentication failed" {
+			statusCode = 401
+		} else if errMsg == "filename is required" || errMsg == "invalid file content" || errMsg == "attachment type not allowed" {
+			statusCode = 400
+		} else if errMsg == "file too large" {
+			statusCode = 413
+		}
+
+		return response.ErrorResponse(statusCode, errMsg), nil
+	}
+
+	return response.SuccessResponse(201, result), nil
+}
+
+func main() {
+	lambda.Start(UploadFileHandler)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/xml"
+	"net/url"
+	"strings"
+
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// twimlResponse es la respuesta TwiML; sin Message Twilio no responde al remitente
+type twimlResponse struct {
+	XMLName xml.Name `xml:"Response"`
+	Message string   `xml:"Message,omitempty"`
+}
+
+// InboundTwilioHandler recibe los mensajes entrantes de SMS y WhatsApp (webhook de Twilio).
+// No usa API Key: la petición se autentica con la firma X-Twilio-Signature.
+func InboundTwilioHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	body := request.Body
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return twiml(400, ""), nil
+		}
+		body = string(decoded)
+	}
+
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		return twiml(400, ""), nil
+	}
+
+	params := make(map[string]string, len(form))
+	for key := range form {
+		params[key] = form.Get(key)
+	}
+
+	signature := ""
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, "X-Twilio-Signature") {
+			signature = value
+		}
+	}
+
+	// Twilio firma la URL pública configurada en el número, no la interna de API Gateway
+	requestURL, err := utils.PublicURL(services.InboundTwilioPath)
+	if err != nil {
+		return twiml(503, ""), nil
+	}
+
+	reply, err := services.HandleTwilioInboundService(requestURL, params, signature)
+	if err != nil {
+		if err.Error() == "invalid signature" {
+			return twiml(403, ""), nil
+		}
+		return twiml(503, ""), nil
+	}
+
+	return twiml(200, reply), nil
+}
+
+func twiml(statusCode int, message string) events.APIGatewayProxyResponse {
+	out, _ := xml.Marshal(twimlResponse{Message: message})
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "text/xml"},
+		Body:       xml.Header + string(out),
+	}
+}
+
+func main() {
+	lambda.Start(InboundTwilioHandler)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/models"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/go-playground/validator/v10"
+)
+
+type SendBatchRequest struct {
+	Type       string            `json:"type" validate:"required,oneof=whatsapp sms email"`
+	TemplateID string            `json:"template_id" validate:"required"`
+	Parameters map[string]string `json:"parameters"`
+	Recipients []BatchRecipient  `json:"recipients" validate:"required,min=1,dive"`
+
+	Category       string                 `json:"category" validate:"omitempty,oneof=marketing transactional otp"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+}
+
+type BatchRecipient struct {
+	To         string            `json:"to" validate:"required"`
+	Parameters map[string]string `json:"parameters"`
+}
+
+func SendBatchHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	var req SendBatchRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	serviceReq := services.SendBatchRequest{
+		Type:       req.Type,
+		TemplateID: req.TemplateID,
+		Parameters: req.Parameters,
+
+		Category:       req.Category,
+		DeliveryWindow: req.DeliveryWindow,
+	}
+	for _, recipient := range req.Recipients {
+		serviceReq.Recipients = append(serviceReq.Recipients, services.BatchRecipient{
+			To:         recipient.To,
+			Parameters: recipient.Parameters,
+		})
+	}
+
+	// Los errores de cada destinatario van en results; aquí solo llegan los del lote completo
+	result, err := services.SendBatchService(apiKey, serviceReq)
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "notification limit reached":
+			statusCode = 429
+		case "invalid notification type", "template_id is required", "recipients is required", "too many recipients",
+			"invalid template", "invalid template type", "template requires media",
+			"invalid category", "invalid delivery window":
+			statusCode = 400
+		case "template not available":
+			statusCode = 404
+		case "sender not verified":
+			statusCode = 403
+		case "service unavailable", "service temporarily unavailable", "email service not configured":
+			statusCode = 503
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, result), nil
+}
+
+func main() {
+	lambda.Start(SendBatchHandler)
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// CancelNotificationHandler cancela un envío programado y libera la cuota reservada
+func CancelNotificationHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	notificationID := request.PathParameters["id"]
+	if notificationID == "" {
+		return response.ErrorResponse(400, "notification id is required"), nil
+	}
+
+	notification, err := services.CancelScheduledNotificationService(apiKey, notificationID)
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "notification not found":
+			statusCode = 404
+		case "notification not scheduled":
+			statusCode = 409
+		case "service unavailable":
+			statusCode = 503
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, notification), nil
+}
+
+func main() {
+	lambda.Start(CancelNotificationHandler)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func GetNotificationChainHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	chainID := request.PathParameters["id"]
+	if chainID == "" {
+		return response.ErrorResponse(400, "chain id is required"), nil
+	}
+
+	chain, err := services.GetNotificationChainService(apiKey, chainID)
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "chain not found":
+			statusCode = 404
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, chain), nil
+}
+
+func main() {
+	lambda.Start(GetNotificationChainHandler)
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"strings"
 
 	"notify-backend/common/response"
 	"notify-backend/internal/services"
@@ -13,10 +14,27 @@ import (
 )
 
 type SendEmailRequest struct {
-	To      string `json:"to" validate:"required,email"`
-	Subject string `json:"subject" validate:"required"`
-	Body    string `json:"body" validate:"required"`
-	HTML    bool   `json:"html"`
+	To      services.EmailAddressList `json:"to" validate:"required_without=ContactID,omitempty,dive,email"`
+	Cc      services.EmailAddressList `json:"cc" validate:"omitempty,dive,email"`
+	Bcc     services.EmailAddressList `json:"bcc" validate:"omitempty,dive,email"`
+	ReplyTo string                    `json:"reply_to" validate:"omitempty,email"`
+	Headers map[string]string         `json:"headers"`
+	Subject string                    `json:"subject" validate:"required"`
+	Body    string                    `json:"body" validate:"required"`
+	HTML    bool                      `json:"html"`
+
+	From     string `json:"from" validate:"omitempty,email"`
+	FromName string `json:"from_name"`
+
+	TemplateID  string `json:"template_id"`
+	TrackOpens  bool   `json:"track_opens"`
+	TrackClicks bool   `json:"track_clicks"`
+
+	Attachments []services.EmailAttachment `json:"attachments"`
+
+	ContactID string `json:"contact_id"` // En lugar de to
+
+	SendAt string `json:"send_at"` // RFC3339: se programa en lugar de enviarse
 }
 
 func SendEmailHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -39,9 +57,26 @@ func SendEmailHandler(request events.APIGatewayProxyRequest) (events.APIGatewayP
 
 	serviceReq := services.SendEmailRequest{
 		To:      req.To,
+		Cc:      req.Cc,
+		Bcc:     req.Bcc,
+		ReplyTo: req.ReplyTo,
+		Headers: req.Headers,
 		Subject: req.Subject,
 		Body:    req.Body,
 		HTML:    req.HTML,
+
+		From:     req.From,
+		FromName: req.FromName,
+
+		TemplateID:  req.TemplateID,
+		TrackOpens:  req.TrackOpens,
+		TrackClicks: req.TrackClicks,
+
+		Attachments: req.Attachments,
+
+		ContactID: req.ContactID,
+
+		SendAt: req.SendAt,
 	}
 
 	result, err := services.SendEmailService(apiKey, serviceReq)
@@ -53,6 +88,32 @@ func SendEmailHandler(request events.APIGatewayProxyRequest) (events.APIGatewayP
 			statusCode = 401
 		} else if errMsg == "notification limit reached" {
 			statusCode = 429
+		} else if strings.HasPrefix(errMsg, "invalid email address") || strings.HasPrefix(errMsg, "invalid header") || strings.HasPrefix(errMsg, "header not allowed") || errMsg == "at least one recipient is required" || errMsg == "too many recipients" {
+			statusCode = 400
+		} else if errMsg == "invalid template id" || errMsg == "tracking requires an html body" {
+			statusCode = 400
+		} else if errMsg == "invalid attachment" || errMsg == "invalid inline image" || errMsg == "invalid content id" || errMsg == "attachment type not allowed" || strings.HasPrefix(errMsg, "inline image not found") {
+			statusCode = 400
+		} else if errMsg == "file not found" {
+			statusCode = 404
+		} else if errMsg == "attachments too large" {
+			statusCode = 413
+		} else if strings.HasPrefix(errMsg, "recipient suppressed") {
+			statusCode = 422
+		} else if errMsg == "sender not verified" {
+			statusCode = 403
+		} else if errMsg == "email rejected by provider" {
+			statusCode = 422
+		} else if errMsg == "email provider busy" || errMsg == "email service not configured" || errMsg == "tracking not configured" {
+			statusCode = 503
+		} else if errMsg == "contact_id cannot be combined with to" || errMsg == "contact has no address for channel" {
+			statusCode = 400
+		} else if errMsg == "contact not found" {
+			statusCode = 404
+		} else if errMsg == "contact consent not granted" {
+			statusCode = 403
+		} else if errMsg == "invalid send_at" || errMsg == "scheduled request too large" {
+			statusCode = 400
 		}
 
 		return response.ErrorResponse(statusCode, errMsg), nil
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func GetNotificationHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	notificationID := request.PathParameters["id"]
+	if notificationID == "" {
+		return response.ErrorResponse(400, "notification id is required"), nil
+	}
+
+	notification, err := services.GetNotificationService(apiKey, notificationID)
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "notification not found":
+			statusCode = 404
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, notification), nil
+}
+
+func main() {
+	lambda.Start(GetNotificationHandler)
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/go-playground/validator/v10"
+)
+
+type RescheduleRequest struct {
+	SendAt string `json:"send_at" validate:"required"`
+}
+
+// RescheduleNotificationHandler cambia el send_at de un envío programado
+func RescheduleNotificationHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	notificationID := request.PathParameters["id"]
+	if notificationID == "" {
+		return response.ErrorResponse(400, "notification id is required"), nil
+	}
+
+	var req RescheduleRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body: "+err.Error()), nil
+	}
+
+	notification, err := services.RescheduleNotificationService(apiKey, notificationID, services.ScheduledNotificationRequest{
+		SendAt: req.SendAt,
+	})
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "invalid send_at":
+			statusCode = 400
+		case "notification not found":
+			statusCode = 404
+		case "notification not scheduled":
+			statusCode = 409
+		case "service unavailable":
+			statusCode = 503
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, notification), nil
+}
+
+func main() {
+	lambda.Start(RescheduleNotificationHandler)
+}
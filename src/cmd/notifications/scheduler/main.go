@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"notify-backend/internal/services"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// Tiempo mínimo restante para tomar otro envío programado
+const scheduledReserve = 15 * time.Second
+
+// schedulerInterval es la frecuencia del ticker local (igual a la regla de EventBridge)
+const schedulerInterval = time.Minute
+
+// SchedulerHandler se ejecuta cada minuto y envía las notificaciones programadas cuyo send_at ya llegó
+func SchedulerHandler(ctx context.Context, event events.CloudWatchEvent) error {
+	return services.ProcessScheduledNotificationsService(ctx, scheduledReserve)
+}
+
+// runLocalScheduler reemplaza a EventBridge cuando el binario corre fuera de Lambda (self-hosted)
+func runLocalScheduler() {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), schedulerInterval)
+		if err := services.ProcessScheduledNotificationsService(ctx, scheduledReserve); err != nil {
+			fmt.Printf("Scheduler error: %v\n", err)
+		}
+		cancel()
+		<-ticker.C
+	}
+}
+
+func main() {
+	// El runtime de Lambda define AWS_LAMBDA_RUNTIME_API; sin él se usa el ticker local
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") == "" {
+		runLocalScheduler()
+		return
+	}
+
+	lambda.Start(SchedulerHandler)
+}
@@ -2,8 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"strings"
 
 	"notify-backend/common/response"
+	"notify-backend/internal/models"
 	"notify-backend/internal/services"
 	"notify-backend/internal/utils"
 
@@ -13,9 +15,76 @@ import (
 )
 
 type SendNotificationRequest struct {
-	To      string `json:"to" validate:"required"`
-	Message string `json:"message" validate:"required"`
-	Type    string `json:"type" validate:"required,oneof=whatsapp sms email"`
+	Type       string            `json:"type" validate:"required_without=Channels,omitempty,oneof=whatsapp sms email"`
+	To         string            `json:"to" validate:"required_without_all=Channels ContactID"`
+	ContactID  string            `json:"contact_id"`
+	TemplateID string            `json:"template_id"`
+	Parameters map[string]string `json:"parameters"`
+	Message    string            `json:"message"`
+	Subject    string            `json:"subject"`
+	SendAt     string            `json:"send_at"`
+
+	Category       string                 `json:"category" validate:"omitempty,oneof=marketing transactional otp"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+
+	Channels        []ChannelStep `json:"channels" validate:"omitempty,dive"`
+	FallbackTimeout int           `json:"fallback_timeout"`
+}
+
+// ChannelStep es un canal de la cadena de respaldo, con su propio destinatario o el contact_id de la cadena
+type ChannelStep struct {
+	Type       string            `json:"type" validate:"required,oneof=whatsapp sms email"`
+	To         string            `json:"to"`
+	ContactID  string            `json:"contact_id"`
+	TemplateID string            `json:"template_id"`
+	Parameters map[string]string `json:"parameters"`
+	Message    string            `json:"message"`
+	Subject    string            `json:"subject"`
+
+	Category       string                 `json:"category" validate:"omitempty,oneof=marketing transactional otp"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+}
+
+// statusCodeForError traduce los errores de los canales (SMS, WhatsApp, email) a códigos HTTP
+func statusCodeForError(errMsg string) int {
+	switch errMsg {
+	case "authentication failed", "invalid API key":
+		return 401
+	case "notification limit reached":
+		return 429
+	case "channels cannot be combined with type", "too many channels", "invalid fallback timeout", "invalid channel chain",
+		"invalid notification type",
+		"template_id or message is required", "template_id cannot be combined with message", "template_id is required for sms",
+		"invalid phone number format", "invalid template", "invalid template type", "missing required parameters",
+		"invalid verification code format", "message too long", "body or media_urls is required",
+		"template_id cannot be combined with body", "subject is required", "body is required", "invalid template id",
+		"at least one recipient is required", "template requires media",
+		"to or contact_id is required", "contact_id cannot be combined with to", "contact has no address for channel",
+		"invalid send_at", "send_at cannot be combined with channels", "scheduled request too large",
+		"invalid category", "invalid delivery window":
+		return 400
+	case "template not available", "contact not found":
+		return 404
+	case "sender not verified", "contact consent not granted":
+		return 403
+	case "phone number cannot receive sms", "whatsapp session window closed", "email rejected by provider":
+		return 422
+	case "service unavailable", "service temporarily unavailable", "email provider busy", "email service not configured":
+		return 503
+	}
+
+	switch {
+	case strings.HasPrefix(errMsg, "invalid email address"):
+		return 400
+	case strings.HasPrefix(errMsg, "recipient suppressed"):
+		return 422
+	case strings.HasPrefix(errMsg, "destination country not allowed:"):
+		return 403
+	case strings.HasPrefix(errMsg, "all channels failed"):
+		return 422
+	}
+
+	return 500
 }
 
 func SendNotificationHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -37,20 +106,38 @@ func SendNotificationHandler(request events.APIGatewayProxyRequest) (events.APIG
 	}
 
 	serviceReq := services.SendNotificationRequest{
-		To:      req.To,
-		Message: req.Message,
-		Type:    req.Type,
+		Type:       req.Type,
+		To:         req.To,
+		ContactID:  req.ContactID,
+		TemplateID: req.TemplateID,
+		Parameters: req.Parameters,
+		Message:    req.Message,
+		Subject:    req.Subject,
+		SendAt:     req.SendAt,
+
+		Category:       req.Category,
+		DeliveryWindow: req.DeliveryWindow,
+
+		FallbackTimeout: req.FallbackTimeout,
+	}
+	for _, step := range req.Channels {
+		serviceReq.Channels = append(serviceReq.Channels, services.SendNotificationRequest{
+			Type:       step.Type,
+			To:         step.To,
+			ContactID:  step.ContactID,
+			TemplateID: step.TemplateID,
+			Parameters: step.Parameters,
+			Message:    step.Message,
+			Subject:    step.Subject,
+
+			Category:       step.Category,
+			DeliveryWindow: step.DeliveryWindow,
+		})
 	}
 
 	result, err := services.SendNotificationService(apiKey, serviceReq)
 	if err != nil {
-		statusCode := 500
-		if err.Error() == "invalid API key" {
-			statusCode = 401
-		} else if err.Error() == "notification limit reached. Please upgrade your plan" {
-			statusCode = 429
-		}
-		return response.ErrorResponse(statusCode, err.Error()), nil
+		return response.ErrorResponse(statusCodeForError(err.Error()), err.Error()), nil
 	}
 
 	return response.SuccessResponse(200, result), nil
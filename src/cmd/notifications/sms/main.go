@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"notify-backend/common/response"
+	"notify-backend/internal/models"
 	"notify-backend/internal/services"
 	"notify-backend/internal/utils"
 
@@ -13,9 +14,16 @@ import (
 )
 
 type SendSMSRequest struct {
-	To         string            `json:"to" validate:"required"`
-	TemplateID string            `json:"template_id" validate:"required"`
-	Parameters map[string]string `json:"parameters"`
+	To            string            `json:"to" validate:"required_without=ContactID"`
+	ContactID     string            `json:"contact_id"`
+	TemplateID    string            `json:"template_id" validate:"required"`
+	Parameters    map[string]string `json:"parameters"`
+	MediaURLs     []string          `json:"media_urls"`
+	Transliterate bool              `json:"transliterate"`
+	SendAt        string            `json:"send_at"`
+
+	Category       string                 `json:"category" validate:"omitempty,oneof=marketing transactional otp"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
 }
 
 func SendSMSHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -37,9 +45,16 @@ func SendSMSHandler(request events.APIGatewayProxyRequest) (events.APIGatewayPro
 	}
 
 	serviceReq := services.SendSMSRequest{
-		To:         req.To,
-		TemplateID: req.TemplateID,
-		Parameters: req.Parameters,
+		To:            req.To,
+		ContactID:     req.ContactID,
+		TemplateID:    req.TemplateID,
+		Parameters:    req.Parameters,
+		MediaURLs:     req.MediaURLs,
+		Transliterate: req.Transliterate,
+		SendAt:        req.SendAt,
+
+		Category:       req.Category,
+		DeliveryWindow: req.DeliveryWindow,
 	}
 
 	result, err := services.SendSMSService(apiKey, serviceReq)
@@ -55,6 +70,23 @@ func SendSMSHandler(request events.APIGatewayProxyRequest) (events.APIGatewayPro
 			statusCode = 400
 		} else if errMsg == "template not available" {
 			statusCode = 404
+		} else if errMsg == "invalid media url" || errMsg == "too many media urls" || errMsg == "unsupported media type" || errMsg == "media too large" {
+			statusCode = 400
+		} else if errMsg == "media not reachable" || errMsg == "media size unknown" || errMsg == "phone number cannot receive sms" {
+			statusCode = 422
+		} else if len(errMsg) > 20 && errMsg[:20] == "recipient suppressed" {
+			statusCode = 422
+		} else if len(errMsg) > 32 && errMsg[:32] == "destination country not allowed:" {
+			statusCode = 403
+		} else if errMsg == "contact_id cannot be combined with to" || errMsg == "contact has no address for channel" {
+			statusCode = 400
+		} else if errMsg == "contact not found" {
+			statusCode = 404
+		} else if errMsg == "contact consent not granted" {
+			statusCode = 403
+		} else if errMsg == "invalid send_at" || errMsg == "scheduled request too large" ||
+			errMsg == "invalid category" || errMsg == "invalid delivery window" {
+			statusCode = 400
 		}
 
 		return response.ErrorResponse(statusCode, errMsg), nil
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 
 	"notify-backend/common/response"
+	"notify-backend/internal/models"
 	"notify-backend/internal/services"
 	"notify-backend/internal/utils"
 
@@ -13,9 +14,16 @@ import (
 )
 
 type SendWhatsAppRequest struct {
-	To         string            `json:"to" validate:"required"`
-	TemplateID string            `json:"template_id" validate:"required"`
+	To         string            `json:"to" validate:"required_without=ContactID"`
+	ContactID  string            `json:"contact_id"`
+	TemplateID string            `json:"template_id"`
 	Parameters map[string]string `json:"parameters"`
+	Body       string            `json:"body"`
+	MediaURLs  []string          `json:"media_urls"`
+	SendAt     string            `json:"send_at"`
+
+	Category       string                 `json:"category" validate:"omitempty,oneof=marketing transactional otp"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
 }
 
 func SendWhatsAppHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
@@ -38,8 +46,15 @@ func SendWhatsAppHandler(request events.APIGatewayProxyRequest) (events.APIGatew
 
 	serviceReq := services.SendWhatsAppRequest{
 		To:         req.To,
+		ContactID:  req.ContactID,
 		TemplateID: req.TemplateID,
 		Parameters: req.Parameters,
+		Body:       req.Body,
+		MediaURLs:  req.MediaURLs,
+		SendAt:     req.SendAt,
+
+		Category:       req.Category,
+		DeliveryWindow: req.DeliveryWindow,
 	}
 
 	result, err := services.SendWhatsAppService(apiKey, serviceReq)
@@ -57,6 +72,31 @@ func SendWhatsAppHandler(request events.APIGatewayProxyRequest) (events.APIGatew
 			statusCode = 400
 		} else if len(errMsg) > 25 && errMsg[:25] == "missing required parameters" {
 			statusCode = 400
+		} else if len(errMsg) > 20 && errMsg[:20] == "recipient suppressed" {
+			statusCode = 422
+		} else if errMsg == "whatsapp session window closed" {
+			statusCode = 422
+		} else if errMsg == "invalid phone number format" {
+			statusCode = 400
+		} else if errMsg == "body or media_urls is required" || errMsg == "template_id cannot be combined with body" ||
+			errMsg == "message too long" || errMsg == "too many media urls" || errMsg == "invalid media url" {
+			statusCode = 400
+		} else if errMsg == "unsupported media type" || errMsg == "media too large" || errMsg == "template requires media" ||
+			errMsg == "template does not accept media" || errMsg == "media does not match template header" {
+			statusCode = 400
+		} else if errMsg == "media not reachable" || errMsg == "media size unknown" {
+			statusCode = 422
+		} else if len(errMsg) > 32 && errMsg[:32] == "destination country not allowed:" {
+			statusCode = 403
+		} else if errMsg == "contact_id cannot be combined with to" || errMsg == "contact has no address for channel" {
+			statusCode = 400
+		} else if errMsg == "contact not found" {
+			statusCode = 404
+		} else if errMsg == "contact consent not granted" {
+			statusCode = 403
+		} else if errMsg == "invalid send_at" || errMsg == "scheduled request too large" ||
+			errMsg == "invalid category" || errMsg == "invalid delivery window" {
+			statusCode = 400
 		}
 
 		return response.ErrorResponse(statusCode, errMsg), nil
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// PhoneLookupHandler valida un teléfono y retorna su formato E.164, país y tipo de línea
+func PhoneLookupHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	number := request.QueryStringParameters["number"]
+	if number == "" {
+		return response.ErrorResponse(400, "number is required"), nil
+	}
+
+	result, err := services.PhoneLookupService(apiKey, number, request.QueryStringParameters["country"])
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "invalid phone number format", "invalid country":
+			statusCode = 400
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, result), nil
+}
+
+func main() {
+	lambda.Start(PhoneLookupHandler)
+}
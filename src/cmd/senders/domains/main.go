@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/go-playground/validator/v10"
+)
+
+type RegisterDomainRequest struct {
+	Domain      string `json:"domain" validate:"required,fqdn"`
+	FromAddress string `json:"from_address" validate:"required,email"`
+	FromName    string `json:"from_name"`
+}
+
+// SenderDomainsHandler atiende POST (registrar dominio) y GET (listar dominios)
+func SenderDomainsHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	if request.HTTPMethod == "GET" {
+		domains, err := services.ListSenderDomainsService(apiKey)
+		if err != nil {
+			return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+		}
+		return response.SuccessResponse(200, map[string]interface{}{"domains": domains}), nil
+	}
+
+	var req RegisterDomainRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body: "+err.Error()), nil
+	}
+
+	domain, err := services.RegisterSenderDomainService(apiKey, services.RegisterSenderDomainRequest{
+		Domain:      req.Domain,
+		FromAddress: req.FromAddress,
+		FromName:    req.FromName,
+	})
+	if err != nil {
+		return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(201, domain), nil
+}
+
+func errorStatus(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "invalid domain", "from address must belong to the domain", "invalid from name":
+		return 400
+	case "domain already registered":
+		return 409
+	case "service unavailable":
+		return 503
+	}
+	return 500
+}
+
+func main() {
+	lambda.Start(SenderDomainsHandler)
+}
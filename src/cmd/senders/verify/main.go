@@ -0,0 +1,48 @@
+package main
+
+import (
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func VerifyDomainHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	domain := request.PathParameters["domain"]
+	if domain == "" {
+		return response.ErrorResponse(400, "domain is required"), nil
+	}
+
+	result, err := services.VerifySenderDomainService(apiKey, domain)
+	if err != nil {
+		statusCode := 500
+		switch err.Error() {
+		case "authentication failed":
+			statusCode = 401
+		case "invalid domain":
+			statusCode = 400
+		case "domain not found":
+			statusCode = 404
+		case "domain verification failed":
+			statusCode = 422
+		case "service unavailable":
+			statusCode = 503
+		}
+		return response.ErrorResponse(statusCode, err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, result), nil
+}
+
+func main() {
+	lambda.Start(VerifyDomainHandler)
+}
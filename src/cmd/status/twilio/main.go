@@ -0,0 +1,63 @@
+package main
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// TwilioStatusHandler recibe los reportes de entrega de los mensajes de SMS y WhatsApp (StatusCallback de Twilio).
+// No usa API Key: la petición se autentica con la firma X-Twilio-Signature.
+func TwilioStatusHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	body := request.Body
+	if request.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(body)
+		if err != nil {
+			return events.APIGatewayProxyResponse{StatusCode: 400}, nil
+		}
+		body = string(decoded)
+	}
+
+	form, err := url.ParseQuery(body)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 400}, nil
+	}
+
+	params := make(map[string]string, len(form))
+	for key := range form {
+		params[key] = form.Get(key)
+	}
+
+	signature := ""
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, "X-Twilio-Signature") {
+			signature = value
+		}
+	}
+
+	// Twilio firma la URL pública indicada en StatusCallback, no la interna de API Gateway
+	requestURL, err := utils.PublicURL(services.TwilioStatusPath)
+	if err != nil {
+		return events.APIGatewayProxyResponse{StatusCode: 503}, nil
+	}
+
+	if err := services.HandleTwilioStatusService(requestURL, params, signature); err != nil {
+		if err.Error() == "invalid signature" {
+			return events.APIGatewayProxyResponse{StatusCode: 403}, nil
+		}
+		// Twilio reintenta el callback si respondemos error
+		return events.APIGatewayProxyResponse{StatusCode: 503}, nil
+	}
+
+	return events.APIGatewayProxyResponse{StatusCode: 204}, nil
+}
+
+func main() {
+	lambda.Start(TwilioStatusHandler)
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+func DeleteSuppressionHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	err := services.RemoveSuppressionService(apiKey, request.PathParameters["channel"], request.PathParameters["value"])
+	if err != nil {
+		statusCode := 500
+		errMsg := err.Error()
+
+		if errMsg == "authentication failed" {
+			statusCode = 401
+		} else if errMsg == "invalid channel" || errMsg == "invalid phone number format" || (len(errMsg) > 21 && errMsg[:21] == "invalid email address") {
+			statusCode = 400
+		} else if errMsg == "suppression not found" {
+			statusCode = 404
+		} else if errMsg == "service unavailable" {
+			statusCode = 503
+		}
+
+		return response.ErrorResponse(statusCode, errMsg), nil
+	}
+
+	return response.SuccessResponse(200, map[string]bool{"deleted": true}), nil
+}
+
+func main() {
+	lambda.Start(DeleteSuppressionHandler)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/go-playground/validator/v10"
+)
+
+type AddSuppressionRequest struct {
+	Channel string `json:"channel" validate:"required,oneof=email phone"`
+	Value   string `json:"value" validate:"required"`
+	Reason  string `json:"reason"`
+}
+
+// SuppressionsHandler atiende GET (listar) y POST (agregar) sobre la lista de supresión
+func SuppressionsHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	if request.HTTPMethod == "GET" {
+		limit := 50
+		if value := request.QueryStringParameters["limit"]; value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > 100 {
+				return response.ErrorResponse(400, "limit must be between 1 and 100"), nil
+			}
+			limit = parsed
+		}
+
+		result, err := services.ListSuppressionsService(apiKey, request.QueryStringParameters["channel"], int32(limit), request.QueryStringParameters["cursor"])
+		if err != nil {
+			return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+		}
+		return response.SuccessResponse(200, result), nil
+	}
+
+	var req AddSuppressionRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body: "+err.Error()), nil
+	}
+
+	result, err := services.AddSuppressionService(apiKey, services.AddSuppressionRequest{
+		Channel: req.Channel,
+		Value:   req.Value,
+		Reason:  req.Reason,
+	})
+	if err != nil {
+		return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(201, result), nil
+}
+
+func errorStatus(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "invalid channel", "invalid reason", "invalid cursor", "invalid phone number format":
+		return 400
+	case "service unavailable":
+		return 503
+	}
+	if len(errMsg) > 21 && errMsg[:21] == "invalid email address" {
+		return 400
+	}
+	return 500
+}
+
+func main() {
+	lambda.Start(SuppressionsHandler)
+}
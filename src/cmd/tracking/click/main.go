@@ -0,0 +1,29 @@
+package main
+
+import (
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// TrackClickHandler registra el clic y redirige al destino firmado en el token
+func TrackClickHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	target, err := services.RecordClickService(request.PathParameters["token"])
+	if err != nil {
+		return response.ErrorResponse(404, "link not found"), nil
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 302,
+		Headers: map[string]string{
+			"Location":      target,
+			"Cache-Control": "no-store",
+		},
+	}, nil
+}
+
+func main() {
+	lambda.Start(TrackClickHandler)
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"notify-backend/internal/services"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+// transparentGIF es un GIF de 1x1 transparente en base64
+const transparentGIF = "R0lGODlhAQABAIAAAAAAAP///yH5BAEAAAAALAAAAAABAAEAAAIBRAA7"
+
+// TrackOpenHandler registra la apertura y siempre responde el pixel,
+// para no mostrar una imagen rota al destinatario si el token no es válido
+func TrackOpenHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	if err := services.RecordOpenService(request.PathParameters["token"]); err != nil {
+		// Log interno, el pixel se responde igual
+		fmt.Printf("Open tracking: %v\n", err)
+	}
+
+	return events.APIGatewayProxyResponse{
+		StatusCode: 200,
+		Headers: map[string]string{
+			"Content-Type":  "image/gif",
+			"Cache-Control": "no-store, no-cache, must-revalidate, max-age=0",
+		},
+		Body:            transparentGIF,
+		IsBase64Encoded: true,
+	}, nil
+}
+
+func main() {
+	lambda.Start(TrackOpenHandler)
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"html"
+
+	"notify-backend/internal/services"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+const pageTemplate = `<!DOCTYPE html>
+<html lang="es"><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Cancelar suscripción</title></head>
+<body style="font-family:sans-serif;max-width:480px;margin:48px auto;padding:0 16px">%s</body></html>`
+
+// UnsubscribeHandler es la página pública de baja.
+// GET muestra la confirmación (los escáneres de enlaces no deben dar de baja a nadie);
+// POST da de baja, tanto desde el formulario como desde List-Unsubscribe-Post (RFC 8058).
+func UnsubscribeHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	token := request.PathParameters["token"]
+
+	recipient, err := services.ValidateUnsubscribeTokenService(token)
+	if err != nil {
+		return page(404, "<h1>Enlace no válido</h1><p>El enlace de baja no es válido o está incompleto.</p>"), nil
+	}
+
+	if request.HTTPMethod != "POST" {
+		return page(200, fmt.Sprintf(`<h1>Cancelar suscripción</h1>
+<p>¿Dejar de recibir mensajes en <strong>%s</strong>?</p>
+<form method="post"><button type="submit">Confirmar baja</button></form>`, html.EscapeString(recipient))), nil
+	}
+
+	if err := services.UnsubscribeService(token); err != nil {
+		return page(503, "<h1>No pudimos procesar tu solicitud</h1><p>Intenta de nuevo en unos minutos.</p>"), nil
+	}
+
+	return page(200, fmt.Sprintf("<h1>Listo</h1><p><strong>%s</strong> ya no recibirá más mensajes.</p>", html.EscapeString(recipient))), nil
+}
+
+func page(statusCode int, body string) events.APIGatewayProxyResponse {
+	return events.APIGatewayProxyResponse{
+		StatusCode: statusCode,
+		Headers:    map[string]string{"Content-Type": "text/html; charset=utf-8"},
+		Body:       fmt.Sprintf(pageTemplate, body),
+	}
+}
+
+func main() {
+	lambda.Start(UnsubscribeHandler)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/go-playground/validator/v10"
+)
+
+type VerifyCheckRequest struct {
+	To   string `json:"to" validate:"required"`
+	Code string `json:"code" validate:"required"`
+}
+
+// errorStatus traduce los errores de la validación del código a códigos HTTP
+func errorStatus(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "too many attempts":
+		return 429
+	case "to is required", "code is required", "invalid phone number format":
+		return 400
+	case "verification not found":
+		return 404
+	case "verification modified":
+		return 409
+	case "verification expired":
+		return 410
+	case "service unavailable":
+		return 503
+	}
+	if strings.HasPrefix(errMsg, "invalid email address") {
+		return 400
+	}
+	return 500
+}
+
+// VerifyCheckHandler valida el código de verificación recibido por el destinatario
+func VerifyCheckHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	var req VerifyCheckRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body: "+err.Error()), nil
+	}
+
+	result, err := services.CheckVerificationService(apiKey, services.CheckVerificationRequest{
+		To:   req.To,
+		Code: req.Code,
+	})
+	if err != nil {
+		return response.ErrorResponse(errorStatus(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, result), nil
+}
+
+func main() {
+	lambda.Start(VerifyCheckHandler)
+}
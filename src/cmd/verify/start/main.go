@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+
+	"notify-backend/common/response"
+	"notify-backend/internal/services"
+	"notify-backend/internal/utils"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/go-playground/validator/v10"
+)
+
+type VerifyStartRequest struct {
+	To         string            `json:"to" validate:"required"`
+	Channel    string            `json:"channel" validate:"omitempty,oneof=sms whatsapp email"`
+	TemplateID string            `json:"template_id"`
+	Parameters map[string]string `json:"parameters"`
+	CodeLength int               `json:"code_length" validate:"omitempty,min=4,max=6"`
+	TTL        int               `json:"ttl" validate:"omitempty,min=60,max=3600"`
+}
+
+// statusCodeForError traduce los errores de la verificación y del envío del código a códigos HTTP
+func statusCodeForError(errMsg string) int {
+	switch errMsg {
+	case "authentication failed":
+		return 401
+	case "verification locked", "resend too soon", "too many verification requests", "notification limit reached":
+		return 429
+	case "invalid channel", "to is required", "invalid code_length", "invalid ttl", "to does not match channel",
+		"invalid phone number format", "invalid template", "invalid template type", "missing required parameters",
+		"invalid verification code format", "message too long", "template requires media":
+		return 400
+	case "template not available":
+		return 404
+	case "sender not verified":
+		return 403
+	case "phone number cannot receive sms", "whatsapp session window closed", "email rejected by provider":
+		return 422
+	case "service unavailable", "service temporarily unavailable", "email provider busy", "email service not configured":
+		return 503
+	}
+
+	switch {
+	case strings.HasPrefix(errMsg, "invalid email address"):
+		return 400
+	case strings.HasPrefix(errMsg, "recipient suppressed"):
+		return 422
+	case strings.HasPrefix(errMsg, "destination country not allowed:"):
+		return 403
+	}
+
+	return 500
+}
+
+// VerifyStartHandler genera un código de verificación y lo envía por SMS, WhatsApp o email
+func VerifyStartHandler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+	// Obtener API Key del header
+	apiKey := utils.ExtractAPIKeyFromRequest(request)
+
+	if apiKey == "" {
+		return response.ErrorResponse(401, "API Key is required"), nil
+	}
+
+	var req VerifyStartRequest
+	if err := json.Unmarshal([]byte(request.Body), &req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body"), nil
+	}
+
+	validate := validator.New()
+	if err := validate.Struct(req); err != nil {
+		return response.ErrorResponse(400, "Invalid request body: "+err.Error()), nil
+	}
+
+	result, err := services.StartVerificationService(apiKey, services.StartVerificationRequest{
+		To:         req.To,
+		Channel:    req.Channel,
+		TemplateID: req.TemplateID,
+		Parameters: req.Parameters,
+		CodeLength: req.CodeLength,
+		TTL:        req.TTL,
+	})
+	if err != nil {
+		return response.ErrorResponse(statusCodeForError(err.Error()), err.Error()), nil
+	}
+
+	return response.SuccessResponse(200, result), nil
+}
+
+func main() {
+	lambda.Start(VerifyStartHandler)
+}
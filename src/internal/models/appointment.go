@@ -0,0 +1,70 @@
+package models
+
+// Estados de una cita
+const (
+	AppointmentStatusScheduled = "scheduled"
+	AppointmentStatusCancelled = "cancelled" // Sus recordatorios pendientes se cancelaron
+)
+
+// Confirmación de la cita por el destinatario (respuesta a un recordatorio)
+const (
+	AppointmentConfirmationPending   = "pending" // Se envió un recordatorio que pide confirmación
+	AppointmentConfirmationConfirmed = "confirmed"
+	AppointmentConfirmationCancelled = "cancelled" // El destinatario canceló: la cita queda cancelada
+)
+
+// Estados de un recordatorio de cita
+const (
+	AppointmentReminderScheduled = "scheduled" // Notificación programada; su resultado está en la notificación
+	AppointmentReminderSkipped   = "skipped"   // La hora del recordatorio ya había pasado
+	AppointmentReminderCancelled = "cancelled" // La cita se movió o se canceló antes del envío
+)
+
+// Appointment es una cita de un cliente con recordatorios programados antes de su inicio
+type Appointment struct {
+	PK            string            `dynamodbav:"PK"` // BUSINESS#{businessId}
+	SK            string            `dynamodbav:"SK"` // APPOINTMENT#{appointmentId}
+	AppointmentID string            `dynamodbav:"appointmentId"`
+	Status        string            `dynamodbav:"status"`
+	Channel       string            `dynamodbav:"channel"` // whatsapp, sms
+	To            string            `dynamodbav:"to,omitempty"`
+	ContactID     string            `dynamodbav:"contactId,omitempty"` // En lugar de to
+	TemplateID    string            `dynamodbav:"templateId"`
+	CustomerName  string            `dynamodbav:"customerName,omitempty"`
+	ServiceName   string            `dynamodbav:"serviceName"`
+	StartAt       string            `dynamodbav:"startAt"`  // RFC3339 en UTC
+	Timezone      string            `dynamodbav:"timezone"` // IANA: formato de la fecha en el mensaje
+	Parameters    map[string]string `dynamodbav:"parameters,omitempty"`
+
+	Reminders []AppointmentReminder `dynamodbav:"reminders"`
+
+	// Los recordatorios piden responder 1 (confirmar) o 2 (cancelar)
+	RequestConfirmation bool   `dynamodbav:"requestConfirmation"`
+	Confirmation        string `dynamodbav:"confirmation,omitempty"`
+	ConfirmationAt      string `dynamodbav:"confirmationAt,omitempty"`
+
+	CreatedAt   string `dynamodbav:"createdAt"`
+	UpdatedAt   string `dynamodbav:"updatedAt"`
+	CancelledAt string `dynamodbav:"cancelledAt,omitempty"`
+
+	Version int `dynamodbav:"version"` // Control de concurrencia (cambios del negocio y respuestas del destinatario)
+}
+
+// AppointmentReminder es un recordatorio de la cita, enviado como notificación programada
+type AppointmentReminder struct {
+	Before         string `dynamodbav:"before"` // Anticipación respecto al inicio (ej: 24h, 2h, 30m)
+	SendAt         string `dynamodbav:"sendAt"`
+	Status         string `dynamodbav:"status"`
+	NotificationID string `dynamodbav:"notificationId,omitempty"` // Notificación programada (send_at)
+}
+
+// AppointmentReplyLink asocia el teléfono de un destinatario con el último recordatorio que espera su respuesta.
+// Las respuestas por SMS no indican a qué mensaje responden; la última cita recordada del negocio la recibe.
+type AppointmentReplyLink struct {
+	PK             string `dynamodbav:"PK"` // APPOINTMENT_REPLY#{channel}#{phone}
+	SK             string `dynamodbav:"SK"` // BUSINESS#{businessId}
+	AppointmentID  string `dynamodbav:"appointmentId"`
+	NotificationID string `dynamodbav:"notificationId"` // Recordatorio enviado (SID de Twilio)
+	ExpiresAt      string `dynamodbav:"expiresAt"`      // Inicio de la cita: después ya no se aceptan respuestas
+	CreatedAt      string `dynamodbav:"createdAt"`
+}
@@ -10,4 +10,24 @@ type Business struct {
 	APIKey    string `dynamodbav:"apiKey"`
 	CreatedAt string `dynamodbav:"createdAt"`
 	UpdatedAt string `dynamodbav:"updatedAt,omitempty"`
+
+	// Configuración opcional del negocio (PATCH /v1/account/settings)
+	EmailProvider       string `dynamodbav:"emailProvider,omitempty"`       // smtp, ses, sendgrid (vacío = el de la plataforma)
+	DefaultSenderDomain string `dynamodbav:"defaultSenderDomain,omitempty"` // Dominio verificado usado como remitente por defecto
+	WebhookURL          string `dynamodbav:"webhookUrl,omitempty"`          // Recibe eventos (mensajes entrantes, ...)
+	WebhookSecret       string `dynamodbav:"webhookSecret,omitempty"`       // Firma HMAC de los eventos
+	DefaultCountry      string `dynamodbav:"defaultCountry,omitempty"`      // País (ISO alfa-2) de los teléfonos en formato nacional
+
+	// Países de destino permitidos/bloqueados por canal (sms, whatsapp)
+	CountryPolicies map[string]CountryPolicy `dynamodbav:"countryPolicies,omitempty"`
+
+	// Horario de envío de SMS y WhatsApp en la zona horaria del destinatario
+	DeliveryWindow *DeliveryWindow `dynamodbav:"deliveryWindow,omitempty"`
+}
+
+// DeliveryWindow es el horario permitido de envío (HH:MM, hora local del destinatario).
+// Si Start es posterior a End, el horario cruza la medianoche (ej: 22:00 a 06:00).
+type DeliveryWindow struct {
+	Start string `dynamodbav:"start" json:"start"` // Incluido
+	End   string `dynamodbav:"end" json:"end"`     // Excluido
 }
@@ -0,0 +1,78 @@
+package models
+
+// Estados de una campaña
+const (
+	CampaignStatusScheduled = "scheduled" // Esperando scheduledAt
+	CampaignStatusRunning   = "running"   // Enviando por tandas (ratePerMinute por minuto)
+	CampaignStatusCompleted = "completed" // Todos los destinatarios procesados
+	CampaignStatusFailed    = "failed"    // Detenida por un error del lote completo (ej: plantilla desactivada)
+)
+
+// Estados de un destinatario de campaña
+const (
+	CampaignRecipientPending = "pending"
+	CampaignRecipientSending = "sending" // En la tanda en curso; queda así si el worker se interrumpió
+	CampaignRecipientSent    = "sent"
+	CampaignRecipientFailed  = "failed"  // Rechazado al enviar (supresión, país, proveedor, ...)
+	CampaignRecipientInvalid = "invalid" // Fila del CSV descartada al crear la campaña
+)
+
+// CampaignRecipientScheduled es un destinatario aplazado por el horario de envío (notificationId es el envío programado)
+const CampaignRecipientScheduled = "scheduled"
+
+// CampaignQueueKey agrupa en GSI2 las campañas con envíos pendientes, ordenadas por próxima ejecución
+const CampaignQueueKey = "CAMPAIGN_QUEUE"
+
+// Campaign es un envío de una plantilla a los destinatarios de un CSV
+type Campaign struct {
+	PK            string            `dynamodbav:"PK"` // CAMPAIGN#{campaignId}
+	SK            string            `dynamodbav:"SK"` // METADATA
+	CampaignID    string            `dynamodbav:"campaignId"`
+	BusinessID    string            `dynamodbav:"businessId"`
+	Name          string            `dynamodbav:"name"`
+	Type          string            `dynamodbav:"type"` // whatsapp, sms
+	TemplateID    string            `dynamodbav:"templateId"`
+	Parameters    map[string]string `dynamodbav:"parameters,omitempty"` // Comunes a todos los destinatarios
+	Status        string            `dynamodbav:"status"`
+	RatePerMinute int               `dynamodbav:"ratePerMinute"`
+	ScheduledAt   string            `dynamodbav:"scheduledAt"`
+	NextRunAt     string            `dynamodbav:"nextRunAt,omitempty"`
+	Cursor        int               `dynamodbav:"cursor"`  // Índice del próximo destinatario a enviar (avanza antes de enviar)
+	Version       int               `dynamodbav:"version"` // Control de concurrencia del worker
+
+	Total   int `dynamodbav:"total"` // Destinatarios válidos
+	Invalid int `dynamodbav:"invalid"`
+	Sent    int `dynamodbav:"sent"`
+	Failed  int `dynamodbav:"failed"`
+
+	Scheduled int `dynamodbav:"scheduled,omitempty"` // Aplazados por el horario de envío
+	Sending   int `dynamodbav:"sending,omitempty"`   // Tanda en curso o interrumpida sin resultado
+
+	// Horario de envío de la campaña (vacío = el del negocio)
+	Category       string          `dynamodbav:"category,omitempty"`
+	DeliveryWindow *DeliveryWindow `dynamodbav:"deliveryWindow,omitempty"`
+
+	LastError   string `dynamodbav:"lastError,omitempty"`
+	CreatedAt   string `dynamodbav:"createdAt"`
+	UpdatedAt   string `dynamodbav:"updatedAt"`
+	StartedAt   string `dynamodbav:"startedAt,omitempty"`
+	CompletedAt string `dynamodbav:"completedAt,omitempty"`
+
+	// Solo mientras hay envíos pendientes
+	GSI2PK string `dynamodbav:"GSI2PK,omitempty"` // CAMPAIGN_QUEUE
+	GSI2SK string `dynamodbav:"GSI2SK,omitempty"` // {nextRunAt}#{campaignId}
+}
+
+// CampaignRecipient es una fila del CSV. Los válidos se numeran desde 0 en el orden de envío;
+// los inválidos se guardan aparte para el reporte.
+type CampaignRecipient struct {
+	PK             string            `dynamodbav:"PK"`  // CAMPAIGN#{campaignId}
+	SK             string            `dynamodbav:"SK"`  // RECIPIENT#{index} o INVALID#{row}
+	Row            int               `dynamodbav:"row"` // Fila del CSV (la cabecera es la fila 1)
+	To             string            `dynamodbav:"to"`  // E.164 si es válido, el valor del CSV si no
+	Parameters     map[string]string `dynamodbav:"parameters,omitempty"`
+	Status         string            `dynamodbav:"status"`
+	Error          string            `dynamodbav:"error,omitempty"`
+	NotificationID string            `dynamodbav:"notificationId,omitempty"`
+	ProcessedAt    string            `dynamodbav:"processedAt,omitempty"`
+}
@@ -0,0 +1,24 @@
+package models
+
+// Contact es un destinatario guardado por el negocio. Los envíos pueden usar contact_id en lugar de to.
+type Contact struct {
+	PK         string                    `dynamodbav:"PK"` // BUSINESS#{businessId}
+	SK         string                    `dynamodbav:"SK"` // CONTACT#{contactId}
+	ContactID  string                    `dynamodbav:"contactId"`
+	Name       string                    `dynamodbav:"name,omitempty"`
+	Phone      string                    `dynamodbav:"phone,omitempty"`      // E.164, para SMS (y WhatsApp si no hay whatsappId)
+	Email      string                    `dynamodbav:"email,omitempty"`      // Normalizado
+	WhatsAppID string                    `dynamodbav:"whatsappId,omitempty"` // E.164 de la cuenta de WhatsApp, si es otro número
+	Locale     string                    `dynamodbav:"locale,omitempty"`     // es, es-CO, en-US: elige la variante de la plantilla
+	Timezone   string                    `dynamodbav:"timezone,omitempty"`   // IANA (ej: America/Bogota)
+	Attributes map[string]string         `dynamodbav:"attributes,omitempty"` // Se envían como parámetros de la plantilla
+	Consent    map[string]ContactConsent `dynamodbav:"consent,omitempty"`    // Por canal: sms, whatsapp, email
+	CreatedAt  string                    `dynamodbav:"createdAt"`
+	UpdatedAt  string                    `dynamodbav:"updatedAt"`
+}
+
+// ContactConsent registra el consentimiento de un canal y cuándo cambió
+type ContactConsent struct {
+	Granted   bool   `dynamodbav:"granted" json:"granted"`
+	UpdatedAt string `dynamodbav:"updatedAt" json:"updated_at"`
+}
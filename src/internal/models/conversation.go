@@ -0,0 +1,29 @@
+package models
+
+// Conversation asocia un teléfono y canal con el negocio que le escribió por última vez.
+// Los números de Twilio son compartidos por la plataforma, así que los mensajes entrantes
+// se asignan al último negocio que envió al destinatario por ese canal.
+type Conversation struct {
+	PK             string `dynamodbav:"PK"`                       // CONVERSATION#{channel}#{phone}
+	SK             string `dynamodbav:"SK"`                       // METADATA
+	Channel        string `dynamodbav:"channel"`                  // sms, whatsapp
+	Phone          string `dynamodbav:"phone"`                    // Teléfono del destinatario en E.164
+	BusinessID     string `dynamodbav:"businessId"`               // Último negocio que envió al destinatario
+	LastOutboundAt string `dynamodbav:"lastOutboundAt,omitempty"` // Último envío del negocio
+	LastInboundAt  string `dynamodbav:"lastInboundAt,omitempty"`  // Último mensaje recibido del destinatario
+}
+
+// InboundMessage es un mensaje recibido de un destinatario (respuesta por SMS o WhatsApp)
+type InboundMessage struct {
+	PK         string   `dynamodbav:"PK"`                  // BUSINESS#{businessId}
+	SK         string   `dynamodbav:"SK"`                  // INBOUND#{receivedAt}#{messageSid} (más el marcador INBOUND_SID#{messageSid})
+	MessageSID string   `dynamodbav:"messageSid"`          // SID de Twilio
+	BusinessID string   `dynamodbav:"businessId"`          // Negocio dueño de la conversación
+	Channel    string   `dynamodbav:"channel"`             // sms, whatsapp
+	From       string   `dynamodbav:"from"`                // Teléfono del remitente en E.164
+	To         string   `dynamodbav:"to"`                  // Número de la plataforma que recibió el mensaje
+	Body       string   `dynamodbav:"body"`                // Texto recibido
+	MediaURLs  []string `dynamodbav:"mediaUrls,omitempty"` // Adjuntos (URLs de Twilio)
+	Keyword    string   `dynamodbav:"keyword,omitempty"`   // STOP, START o HELP si el mensaje era una palabra clave
+	ReceivedAt string   `dynamodbav:"receivedAt"`
+}
@@ -0,0 +1,14 @@
+package models
+
+// Canales con política de países de destino
+const (
+	CountryPolicyChannelSMS      = "sms"
+	CountryPolicyChannelWhatsApp = "whatsapp"
+)
+
+// CountryPolicy restringe los países de destino (ISO 3166-1 alfa-2) de un canal.
+// Con Allowed, solo se aceptan esos países; Denied siempre tiene prioridad.
+type CountryPolicy struct {
+	Allowed []string `dynamodbav:"allowed,omitempty" json:"allowed,omitempty"`
+	Denied  []string `dynamodbav:"denied,omitempty" json:"denied,omitempty"`
+}
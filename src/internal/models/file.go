@@ -0,0 +1,15 @@
+package models
+
+// File representa un archivo subido por un negocio para adjuntar en emails.
+// El contenido se guarda en chunks (FILE#{fileId} / CHUNK#{n}) por el límite de 400 KB por item de DynamoDB.
+type File struct {
+	PK          string `dynamodbav:"PK"`          // BUSINESS#{businessId}
+	SK          string `dynamodbav:"SK"`          // FILE#{fileId}
+	FileID      string `dynamodbav:"fileId"`      // ID único del archivo
+	BusinessID  string `dynamodbav:"businessId"`  // Negocio dueño del archivo
+	Filename    string `dynamodbav:"filename"`    // Nombre original
+	ContentType string `dynamodbav:"contentType"` // MIME type detectado
+	Size        int    `dynamodbav:"size"`        // Tamaño en bytes
+	ChunkCount  int    `dynamodbav:"chunkCount"`  // Número de chunks del contenido
+	CreatedAt   string `dynamodbav:"createdAt"`
+}
@@ -0,0 +1,84 @@
+package models
+
+// Estados de un envío programado (send_at) antes de enviarse; luego pasa a sent o failed
+const (
+	NotificationStatusScheduled   = "scheduled"   // En cola hasta sendAt, con la cuota ya reservada
+	NotificationStatusDispatching = "dispatching" // Tomado por el worker, en la cola hasta que vence la reserva
+	NotificationStatusCancelled   = "cancelled"   // Cancelado por el cliente; la cuota se liberó
+)
+
+// ScheduledQueueKey agrupa en GSI2 los envíos programados pendientes, ordenados por sendAt
+const ScheduledQueueKey = "SCHEDULED_QUEUE"
+
+// Notification es el registro de una notificación enviada.
+// Las métricas de apertura y clics se actualizan con contadores atómicos desde los endpoints de tracking.
+type Notification struct {
+	PK                string   `dynamodbav:"PK"`                          // NOTIFICATION#{notificationId}
+	SK                string   `dynamodbav:"SK"`                          // METADATA
+	NotificationID    string   `dynamodbav:"notificationId"`              // Message-ID (email) o SID del proveedor
+	BusinessID        string   `dynamodbav:"businessId"`                  // Negocio que envió la notificación
+	Channel           string   `dynamodbav:"channel"`                     // email, sms, whatsapp
+	Recipients        []string `dynamodbav:"recipients"`                  // Destinatarios (to + cc + bcc en email)
+	TemplateID        string   `dynamodbav:"templateId,omitempty"`        // Plantilla usada (agrupa métricas)
+	Status            string   `dynamodbav:"status"`                      // sent, failed, delivered, read, undelivered
+	Provider          string   `dynamodbav:"provider"`                    // smtp, ses, sendgrid, twilio
+	ProviderMessageID string   `dynamodbav:"providerMessageId,omitempty"` // ID asignado por el proveedor
+	TrackOpens        bool     `dynamodbav:"trackOpens"`
+	TrackClicks       bool     `dynamodbav:"trackClicks"`
+	CreatedAt         string   `dynamodbav:"createdAt"`
+
+	Media []NotificationMedia `dynamodbav:"media,omitempty"` // Adjuntos de SMS (MMS) y WhatsApp
+
+	// Estado de entrega reportado por el proveedor (callbacks de Twilio)
+	Error           string `dynamodbav:"error,omitempty"` // Motivo del fallo o de la no entrega
+	StatusUpdatedAt string `dynamodbav:"statusUpdatedAt,omitempty"`
+
+	ChainID string `dynamodbav:"chainId,omitempty"` // Cadena de canales de respaldo a la que pertenece el intento
+
+	AppointmentID string `dynamodbav:"appointmentId,omitempty"` // Cita de la que es recordatorio
+
+	// Envío programado: la petición del canal se guarda y el worker la envía en sendAt
+	SendAt                   string `dynamodbav:"sendAt,omitempty"`
+	ScheduledRequest         string `dynamodbav:"scheduledRequest,omitempty"`         // JSON de la petición del canal
+	ReservedUnits            int    `dynamodbav:"reservedUnits,omitempty"`            // Cuota reservada al programar
+	UsageSK                  string `dynamodbav:"usageSk,omitempty"`                  // Período en que se reservó la cuota
+	DispatchedNotificationID string `dynamodbav:"dispatchedNotificationId,omitempty"` // Notificación creada al enviar
+
+	// Métricas de tracking
+	Opens          int            `dynamodbav:"opens"`
+	Clicks         int            `dynamodbav:"clicks"`
+	LinkClicks     map[string]int `dynamodbav:"linkClicks"` // Clics por URL de destino
+	FirstOpenedAt  string         `dynamodbav:"firstOpenedAt,omitempty"`
+	LastOpenedAt   string         `dynamodbav:"lastOpenedAt,omitempty"`
+	FirstClickedAt string         `dynamodbav:"firstClickedAt,omitempty"`
+	LastClickedAt  string         `dynamodbav:"lastClickedAt,omitempty"`
+
+	// Listado por negocio en GSI1 (ver repository.GSI1IndexName)
+	GSI1PK string `dynamodbav:"GSI1PK"` // BUSINESS#{businessId}
+	GSI1SK string `dynamodbav:"GSI1SK"` // NOTIFICATION#{createdAt}#{notificationId}
+
+	// Solo mientras el envío programado está pendiente o tomado por un worker
+	GSI2PK string `dynamodbav:"GSI2PK,omitempty"` // SCHEDULED_QUEUE
+	GSI2SK string `dynamodbav:"GSI2SK,omitempty"` // {sendAt}#{notificationId} o, tomado, {fin de la reserva}#{notificationId}
+}
+
+// NotificationMedia es la referencia a un adjunto enviado por URL (Twilio MediaUrl)
+type NotificationMedia struct {
+	URL         string `dynamodbav:"url"`
+	ContentType string `dynamodbav:"contentType"`
+	Size        int64  `dynamodbav:"size,omitempty"` // Bytes según Content-Length (0 si el servidor no lo informa)
+}
+
+// TemplateTrackingStats son las métricas agregadas de una plantilla para un negocio
+type TemplateTrackingStats struct {
+	PK           string `dynamodbav:"PK"` // BUSINESS#{businessId}
+	SK           string `dynamodbav:"SK"` // TRACKING#TEMPLATE#{templateId}
+	TemplateID   string `dynamodbav:"templateId"`
+	Sent         int    `dynamodbav:"sent"`         // Emails enviados con pixel de apertura (track_opens)
+	ClickTracked int    `dynamodbav:"clickTracked"` // Emails enviados con enlaces de tracking (track_clicks)
+	Opens        int    `dynamodbav:"opens"`        // Aperturas totales
+	UniqueOpens  int    `dynamodbav:"uniqueOpens"`  // Notificaciones abiertas al menos una vez
+	Clicks       int    `dynamodbav:"clicks"`       // Clics totales
+	UniqueClicks int    `dynamodbav:"uniqueClicks"` // Notificaciones con al menos un clic
+	UpdatedAt    string `dynamodbav:"updatedAt"`
+}
@@ -0,0 +1,53 @@
+package models
+
+// Estados de una cadena de canales de respaldo
+const (
+	ChainStatusPending     = "pending"     // El último envío puede reportar no entrega antes del timeout
+	ChainStatusSent        = "sent"        // Un canal envió la notificación y no quedan pasos por intentar
+	ChainStatusDelivered   = "delivered"   // El proveedor confirmó la entrega
+	ChainStatusUndelivered = "undelivered" // El último canal enviado reportó no entrega
+	ChainStatusFailed      = "failed"      // Ningún canal pudo enviar
+)
+
+// NotificationChain es un envío con canales de respaldo (ej: WhatsApp, luego SMS, luego email).
+// Se pasa al siguiente canal si el envío falla o si el proveedor reporta no entrega antes de DeadlineAt.
+type NotificationChain struct {
+	PK             string                     `dynamodbav:"PK"` // CHAIN#{chainId}
+	SK             string                     `dynamodbav:"SK"` // METADATA
+	ChainID        string                     `dynamodbav:"chainId"`
+	BusinessID     string                     `dynamodbav:"businessId"`
+	Steps          []NotificationChainStep    `dynamodbav:"steps"`
+	Attempts       []NotificationChainAttempt `dynamodbav:"attempts"`
+	CurrentStep    int                        `dynamodbav:"currentStep"` // Índice del paso en curso
+	Status         string                     `dynamodbav:"status"`
+	TimeoutSeconds int                        `dynamodbav:"timeoutSeconds"`       // Espera del reporte de no entrega por paso
+	DeadlineAt     string                     `dynamodbav:"deadlineAt,omitempty"` // Límite del paso en curso (solo pending)
+	CreatedAt      string                     `dynamodbav:"createdAt"`
+	UpdatedAt      string                     `dynamodbav:"updatedAt"`
+}
+
+// NotificationChainStep es un canal de la cadena con su propio destinatario
+type NotificationChainStep struct {
+	Type       string            `dynamodbav:"type"` // whatsapp, sms, email
+	To         string            `dynamodbav:"to"`
+	ContactID  string            `dynamodbav:"contactId,omitempty"` // En lugar de to: se resuelve en cada intento
+	TemplateID string            `dynamodbav:"templateId,omitempty"`
+	Parameters map[string]string `dynamodbav:"parameters,omitempty"`
+	Message    string            `dynamodbav:"message,omitempty"`
+	Subject    string            `dynamodbav:"subject,omitempty"`
+
+	// Horario de envío (sms, whatsapp): fuera de él el paso falla y se intenta el siguiente canal
+	Category       string          `dynamodbav:"category,omitempty"`
+	DeliveryWindow *DeliveryWindow `dynamodbav:"deliveryWindow,omitempty"`
+}
+
+// NotificationChainAttempt es un intento de envío; cada intento tiene su registro en el log de notificaciones
+type NotificationChainAttempt struct {
+	Step           int    `dynamodbav:"step"`
+	Type           string `dynamodbav:"type"`
+	To             string `dynamodbav:"to"`
+	NotificationID string `dynamodbav:"notificationId"`
+	Status         string `dynamodbav:"status"` // sent, failed, delivered, read, undelivered
+	Error          string `dynamodbav:"error,omitempty"`
+	At             string `dynamodbav:"at"`
+}
@@ -10,4 +10,13 @@ type Plan struct {
 	Description       string  `dynamodbav:"description"`
 	Active            bool    `dynamodbav:"active"`
 	CreatedAt         string  `dynamodbav:"createdAt"`
+
+	// Límites opcionales por plan (0 = valor por defecto de la plataforma)
+	AttachmentMaxBytes int `dynamodbav:"attachmentMaxBytes,omitempty"` // Tamaño máximo total de adjuntos por email
+
+	// Facturación de SMS: si es true cada segmento consume una notificación (por defecto, una por mensaje)
+	SMSChargePerSegment bool `dynamodbav:"smsChargePerSegment,omitempty"`
+
+	// Países de destino permitidos/bloqueados por canal; se aplica a todos los negocios del plan
+	CountryPolicies map[string]CountryPolicy `dynamodbav:"countryPolicies,omitempty"`
 }
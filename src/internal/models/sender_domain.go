@@ -0,0 +1,21 @@
+package models
+
+// SenderDomain es un dominio de envío registrado por un negocio.
+// La propiedad se verifica con un registro TXT y los emails se firman con DKIM usando una llave propia del dominio.
+type SenderDomain struct {
+	PK                string `dynamodbav:"PK"`                   // BUSINESS#{businessId}
+	SK                string `dynamodbav:"SK"`                   // DOMAIN#{domain}
+	Domain            string `dynamodbav:"domain"`               // Dominio en minúsculas (ej. miempresa.com)
+	BusinessID        string `dynamodbav:"businessId"`           // Negocio dueño del dominio
+	FromAddress       string `dynamodbav:"fromAddress"`          // Remitente por defecto del dominio
+	FromName          string `dynamodbav:"fromName,omitempty"`   // Nombre visible (vacío = nombre del negocio)
+	VerificationToken string `dynamodbav:"verificationToken"`    // Valor esperado en el registro TXT
+	Verified          bool   `dynamodbav:"verified"`             // Token TXT encontrado
+	VerifiedAt        string `dynamodbav:"verifiedAt,omitempty"` // Primera verificación exitosa
+	DKIMSelector      string `dynamodbav:"dkimSelector"`         // Selector: {selector}._domainkey.{domain}
+	DKIMPrivateKey    string `dynamodbav:"dkimPrivateKey"`       // Llave RSA en PEM (nunca se expone en la API)
+	DKIMPublicKey     string `dynamodbav:"dkimPublicKey"`        // Llave pública en base64 (valor p= del registro DNS)
+	DKIMEnabled       bool   `dynamodbav:"dkimEnabled"`          // Registro DKIM publicado; los emails se firman
+	CreatedAt         string `dynamodbav:"createdAt"`
+	UpdatedAt         string `dynamodbav:"updatedAt,omitempty"`
+}
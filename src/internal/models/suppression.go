@@ -0,0 +1,19 @@
+package models
+
+// Canales de una supresión: el email cubre el canal email, el teléfono cubre SMS y WhatsApp
+const (
+	SuppressionChannelEmail = "email"
+	SuppressionChannelPhone = "phone"
+)
+
+// Suppression es un destinatario al que un negocio no puede enviar (opt-out, baja manual, etc.)
+type Suppression struct {
+	PK         string `dynamodbav:"PK"`         // BUSINESS#{businessId}
+	SK         string `dynamodbav:"SK"`         // SUPPRESSION#{channel}#{value}
+	BusinessID string `dynamodbav:"businessId"` // Negocio dueño de la lista
+	Channel    string `dynamodbav:"channel"`    // email, phone
+	Value      string `dynamodbav:"value"`      // Email en minúsculas o teléfono en E.164
+	Reason     string `dynamodbav:"reason"`     // unsubscribe, manual, stop_keyword, bounce, complaint
+	Source     string `dynamodbav:"source,omitempty"`
+	CreatedAt  string `dynamodbav:"createdAt"`
+}
@@ -1,19 +1,31 @@
 package models
 
 type Template struct {
-	PK             string   `dynamodbav:"PK"`             // TEMPLATE#{templateId}
-	SK             string   `dynamodbav:"SK"`             // METADATA
-	TemplateID     string   `dynamodbav:"templateId"`     // ID único de la plantilla
-	Name           string   `dynamodbav:"name"`           // Nombre descriptivo
-	Type           string   `dynamodbav:"type"`           // whatsapp, sms, email
-	Provider       string   `dynamodbav:"provider"`       // twilio, sendgrid, etc.
-	ExternalID     string   `dynamodbav:"externalId"`     // ID de la plantilla en el proveedor (ej: Twilio template SID)
-	Parameters     []string `dynamodbav:"parameters"`     // Lista de parámetros requeridos ["name", "code", "date"]
-	ParameterCount int      `dynamodbav:"parameterCount"` // Número de parámetros
-	Description    string   `dynamodbav:"description"`    // Descripción de la plantilla
-	Active         bool     `dynamodbav:"active"`         // Si está activa o no
+	PK             string   `dynamodbav:"PK"`                    // TEMPLATE#{templateId}
+	SK             string   `dynamodbav:"SK"`                    // METADATA
+	TemplateID     string   `dynamodbav:"templateId"`            // ID único de la plantilla
+	Name           string   `dynamodbav:"name"`                  // Nombre descriptivo
+	Type           string   `dynamodbav:"type"`                  // whatsapp, sms, email
+	Provider       string   `dynamodbav:"provider"`              // twilio, sendgrid, etc.
+	ExternalID     string   `dynamodbav:"externalId"`            // ID de la plantilla en el proveedor (ej: Twilio template SID)
+	Parameters     []string `dynamodbav:"parameters"`            // Lista de parámetros requeridos ["name", "code", "date"]
+	ParameterCount int      `dynamodbav:"parameterCount"`        // Número de parámetros
+	Description    string   `dynamodbav:"description"`           // Descripción de la plantilla
+	MediaHeader    string   `dynamodbav:"mediaHeader,omitempty"` // Header multimedia de WhatsApp: image, video, document (vacío si no tiene)
+	Subject        string   `dynamodbav:"subject,omitempty"`     // Asunto de las plantillas de email (admite $parametros)
+	HTML           bool     `dynamodbav:"html,omitempty"`        // El cuerpo (description) de la plantilla de email es HTML
+	Active         bool     `dynamodbav:"active"`                // Si está activa o no
 	CreatedAt      string   `dynamodbav:"createdAt"`
 	UpdatedAt      string   `dynamodbav:"updatedAt,omitempty"`
+
+	// Variantes por idioma: locale (es, en, en-US) -> templateId de la misma plantilla traducida
+	Variants map[string]string `dynamodbav:"variants,omitempty"`
+
+	// Claves de índices secundarios (ver repository.GSI1IndexName / GSI2IndexName)
+	GSI1PK string `dynamodbav:"GSI1PK,omitempty"` // TEMPLATE_TYPE#{type}
+	GSI1SK string `dynamodbav:"GSI1SK,omitempty"` // EXTERNAL#{externalId}
+	GSI2PK string `dynamodbav:"GSI2PK,omitempty"` // TEMPLATE_TYPE#{type}
+	GSI2SK string `dynamodbav:"GSI2SK,omitempty"` // ACTIVE#{true|false}#{templateId}
 }
 
 // TemplateValidation representa la validación de una plantilla
@@ -0,0 +1,37 @@
+package models
+
+// Estados de una verificación (OTP)
+const (
+	VerificationStatusPending  = "pending"
+	VerificationStatusApproved = "approved"
+	VerificationStatusLocked   = "locked" // Superó los intentos: bloqueada hasta lockedUntil
+	VerificationStatusFailed   = "failed" // No se pudo enviar el código
+)
+
+// Verification es el código de verificación vigente de un destinatario. Hay una por destinatario:
+// un nuevo envío reemplaza el código y conserva los intentos y el límite de reenvíos.
+type Verification struct {
+	PK             string `dynamodbav:"PK"` // BUSINESS#{businessId}
+	SK             string `dynamodbav:"SK"` // VERIFICATION#{to}
+	VerificationID string `dynamodbav:"verificationId"`
+	BusinessID     string `dynamodbav:"businessId"`
+	Channel        string `dynamodbav:"channel"` // sms, whatsapp, email
+	To             string `dynamodbav:"to"`      // E.164 o email normalizado
+	Status         string `dynamodbav:"status"`
+	CodeHash       string `dynamodbav:"codeHash"` // SHA-256 del código con codeSalt; el código no se guarda
+	CodeSalt       string `dynamodbav:"codeSalt"`
+	ExpiresAt      string `dynamodbav:"expiresAt"`
+	Attempts       int    `dynamodbav:"attempts"` // Códigos incorrectos
+	LockedUntil    string `dynamodbav:"lockedUntil,omitempty"`
+	NotificationID string `dynamodbav:"notificationId,omitempty"` // Último envío del código
+	ApprovedAt     string `dynamodbav:"approvedAt,omitempty"`
+	Version        int    `dynamodbav:"version"` // Control de concurrencia (intentos y reenvíos simultáneos)
+
+	// Límite de reenvíos por destinatario
+	SendCount       int    `dynamodbav:"sendCount"`       // Envíos desde sendWindowStart
+	SendWindowStart string `dynamodbav:"sendWindowStart"` // Inicio de la ventana de una hora
+	LastSentAt      string `dynamodbav:"lastSentAt"`
+
+	CreatedAt string `dynamodbav:"createdAt"`
+	UpdatedAt string `dynamodbav:"updatedAt"`
+}
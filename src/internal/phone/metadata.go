@@ -0,0 +1,87 @@
+package phone
+
+// Tipos de línea detectables por prefijo
+const (
+	LineTypeMobile    = "mobile"
+	LineTypeFixedLine = "fixed_line"
+	LineTypeUnknown   = "unknown" // El país no distingue móviles por prefijo (ej: +1, México)
+)
+
+// countryMetadata son las reglas de numeración de un país
+type countryMetadata struct {
+	Code          string   // ISO 3166-1 alfa-2
+	CallingCode   string   // Código de país sin "+"
+	TrunkPrefix   string   // Prefijo de marcación nacional que se elimina (ej: "0")
+	Lengths       []int    // Longitudes válidas del número nacional (sin código de país ni prefijo)
+	MobilePrefix  []string // Prefijos del número nacional que identifican móviles
+	FixedPrefix   []string // Prefijos del número nacional que identifican fijos
+	MobileLengths []int    // Longitudes válidas para móviles (vacío = las de Lengths)
+}
+
+// countries son los países con reglas de longitud y prefijos.
+// Los números de otros países se aceptan si cumplen el formato E.164 general.
+var countries = map[string]*countryMetadata{
+	// Norteamérica (NANP): el país se detecta por el código de área
+	"US": {Code: "US", CallingCode: "1", TrunkPrefix: "1", Lengths: []int{10}},
+	"CA": {Code: "CA", CallingCode: "1", TrunkPrefix: "1", Lengths: []int{10}},
+	"PR": {Code: "PR", CallingCode: "1", TrunkPrefix: "1", Lengths: []int{10}},
+	"DO": {Code: "DO", CallingCode: "1", TrunkPrefix: "1", Lengths: []int{10}},
+
+	// Latinoamérica
+	"MX": {Code: "MX", CallingCode: "52", Lengths: []int{10}},
+	"GT": {Code: "GT", CallingCode: "502", Lengths: []int{8}, MobilePrefix: []string{"3", "4", "5"}, FixedPrefix: []string{"2", "6", "7"}},
+	"SV": {Code: "SV", CallingCode: "503", Lengths: []int{8}, MobilePrefix: []string{"6", "7"}, FixedPrefix: []string{"2"}},
+	"HN": {Code: "HN", CallingCode: "504", Lengths: []int{8}, MobilePrefix: []string{"3", "7", "8", "9"}, FixedPrefix: []string{"2"}},
+	"NI": {Code: "NI", CallingCode: "505", Lengths: []int{8}, MobilePrefix: []string{"5", "7", "8"}, FixedPrefix: []string{"2"}},
+	"CR": {Code: "CR", CallingCode: "506", Lengths: []int{8}, MobilePrefix: []string{"6", "7", "8"}, FixedPrefix: []string{"2"}},
+	"PA": {Code: "PA", CallingCode: "507", Lengths: []int{7, 8}, MobilePrefix: []string{"6"}, FixedPrefix: []string{"2", "3", "4", "5", "7", "8", "9"}, MobileLengths: []int{8}},
+	"CO": {Code: "CO", CallingCode: "57", Lengths: []int{10}, MobilePrefix: []string{"3"}, FixedPrefix: []string{"60"}},
+	"VE": {Code: "VE", CallingCode: "58", TrunkPrefix: "0", Lengths: []int{10}, MobilePrefix: []string{"4"}, FixedPrefix: []string{"2"}},
+	"EC": {Code: "EC", CallingCode: "593", TrunkPrefix: "0", Lengths: []int{8, 9}, MobilePrefix: []string{"9"}, FixedPrefix: []string{"2", "3", "4", "5", "6", "7"}, MobileLengths: []int{9}},
+	"PE": {Code: "PE", CallingCode: "51", TrunkPrefix: "0", Lengths: []int{8, 9}, MobilePrefix: []string{"9"}, FixedPrefix: []string{"1", "4", "5", "6", "7", "8"}, MobileLengths: []int{9}},
+	"BO": {Code: "BO", CallingCode: "591", TrunkPrefix: "0", Lengths: []int{8}, MobilePrefix: []string{"6", "7"}, FixedPrefix: []string{"2", "3", "4"}},
+	"CL": {Code: "CL", CallingCode: "56", Lengths: []int{9}, MobilePrefix: []string{"9"}, FixedPrefix: []string{"2", "3", "4", "5", "6", "7"}},
+	"AR": {Code: "AR", CallingCode: "54", TrunkPrefix: "0", Lengths: []int{10, 11}, MobilePrefix: []string{"9"}, FixedPrefix: []string{"1", "2", "3"}, MobileLengths: []int{11}},
+	"PY": {Code: "PY", CallingCode: "595", TrunkPrefix: "0", Lengths: []int{7, 8, 9}, MobilePrefix: []string{"9"}, FixedPrefix: []string{"2", "3", "4", "5", "6", "7", "8"}, MobileLengths: []int{9}},
+	"UY": {Code: "UY", CallingCode: "598", TrunkPrefix: "0", Lengths: []int{8}, MobilePrefix: []string{"9"}, FixedPrefix: []string{"2", "4"}},
+	"BR": {Code: "BR", CallingCode: "55", TrunkPrefix: "0", Lengths: []int{10, 11}},
+
+	// Europa
+	"ES": {Code: "ES", CallingCode: "34", Lengths: []int{9}, MobilePrefix: []string{"6", "7"}, FixedPrefix: []string{"8", "9"}},
+	"PT": {Code: "PT", CallingCode: "351", Lengths: []int{9}, MobilePrefix: []string{"9"}, FixedPrefix: []string{"2"}},
+	"FR": {Code: "FR", CallingCode: "33", TrunkPrefix: "0", Lengths: []int{9}, MobilePrefix: []string{"6", "7"}, FixedPrefix: []string{"1", "2", "3", "4", "5", "9"}},
+	"GB": {Code: "GB", CallingCode: "44", TrunkPrefix: "0", Lengths: []int{9, 10}, MobilePrefix: []string{"7"}, FixedPrefix: []string{"1", "2", "3"}, MobileLengths: []int{10}},
+	"DE": {Code: "DE", CallingCode: "49", TrunkPrefix: "0", Lengths: []int{6, 7, 8, 9, 10, 11, 12, 13}, MobilePrefix: []string{"15", "16", "17"}, FixedPrefix: []string{"2", "3", "4", "5", "6", "7", "8", "9"}, MobileLengths: []int{10, 11}},
+	// En Italia el 0 de los fijos forma parte del número (no es prefijo nacional)
+	"IT": {Code: "IT", CallingCode: "39", Lengths: []int{6, 7, 8, 9, 10, 11}, MobilePrefix: []string{"3"}, FixedPrefix: []string{"0"}, MobileLengths: []int{9, 10}},
+}
+
+// callingCodeCountries agrupa los países por código de país (para detectar el país de un número E.164)
+var callingCodeCountries = buildCallingCodeIndex()
+
+// Códigos de área de NANP que no son de Estados Unidos
+var nanpAreaCodes = map[string]string{
+	"787": "PR", "939": "PR",
+	"809": "DO", "829": "DO", "849": "DO",
+	// Caribe y territorios (sin reglas propias: se validan como NANP)
+	"242": "BS", "246": "BB", "264": "AI", "268": "AG", "284": "VG", "340": "VI", "345": "KY",
+	"441": "BM", "473": "GD", "649": "TC", "658": "JM", "876": "JM", "664": "MS", "670": "MP",
+	"671": "GU", "684": "AS", "721": "SX", "758": "LC", "767": "DM", "784": "VC", "868": "TT",
+	"869": "KN",
+	"204": "CA", "226": "CA", "236": "CA", "249": "CA", "250": "CA", "263": "CA", "289": "CA",
+	"306": "CA", "343": "CA", "354": "CA", "365": "CA", "367": "CA", "368": "CA", "382": "CA",
+	"387": "CA", "403": "CA", "416": "CA", "418": "CA", "428": "CA", "431": "CA", "437": "CA",
+	"438": "CA", "450": "CA", "460": "CA", "468": "CA", "474": "CA", "506": "CA", "514": "CA",
+	"519": "CA", "548": "CA", "579": "CA", "581": "CA", "584": "CA", "587": "CA", "604": "CA",
+	"613": "CA", "639": "CA", "647": "CA", "672": "CA", "683": "CA", "705": "CA", "709": "CA",
+	"742": "CA", "753": "CA", "778": "CA", "780": "CA", "782": "CA", "807": "CA", "819": "CA",
+	"825": "CA", "867": "CA", "873": "CA", "879": "CA", "902": "CA", "905": "CA",
+}
+
+func buildCallingCodeIndex() map[string][]*countryMetadata {
+	index := map[string][]*countryMetadata{}
+	for _, country := range countries {
+		index[country.CallingCode] = append(index[country.CallingCode], country)
+	}
+	return index
+}
@@ -0,0 +1,214 @@
+// Package phone interpreta y normaliza teléfonos a E.164 con reglas por país
+// (código de país, longitudes y prefijos de móviles y fijos).
+package phone
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Límites de E.164: el número completo (código de país + número nacional) tiene como máximo 15 dígitos.
+// Para países sin reglas se mantiene el mínimo histórico de 10 dígitos.
+const (
+	maxE164Digits     = 15
+	minUnknownDigits  = 10
+	internationalPfx  = "00"
+	nanpCallingCode   = "1"
+	mexicoLegacyDigit = "1" // Móviles de México marcados como +52 1 XXXXXXXXXX antes de 2019
+)
+
+// Number es un teléfono interpretado
+type Number struct {
+	E164           string // +573001234567
+	Country        string // ISO 3166-1 alfa-2 (vacío si el código de país no está asignado a un país, ej: +882)
+	CallingCode    string // 57
+	NationalNumber string // 3001234567
+	LineType       string // mobile, fixed_line, unknown
+}
+
+// IsSupportedCountry indica si hay reglas de numeración para el país (ISO 3166-1 alfa-2)
+func IsSupportedCountry(code string) bool {
+	_, ok := countries[strings.ToUpper(code)]
+	return ok
+}
+
+// IsKnownCountry indica si el código ISO 3166-1 alfa-2 corresponde a un país con código de país asignado
+// (tenga o no reglas de numeración)
+func IsKnownCountry(code string) bool {
+	code = strings.ToUpper(code)
+	if IsSupportedCountry(code) || code == "KZ" {
+		return true
+	}
+	for _, country := range callingCodeRegions {
+		if country == code {
+			return true
+		}
+	}
+	for _, country := range nanpAreaCodes {
+		if country == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse interpreta un teléfono en formato internacional (+57..., 0057...) o nacional.
+// El formato nacional ("300 123 4567") requiere defaultCountry.
+func Parse(input, defaultCountry string) (*Number, error) {
+	raw := strings.NewReplacer(" ", "", "-", "", "(", "", ")", "", ".", "", "/", "", " ", "").Replace(strings.TrimSpace(input))
+
+	var digits string
+	switch {
+	case strings.HasPrefix(raw, "+"):
+		digits = raw[1:]
+	case strings.HasPrefix(raw, internationalPfx):
+		digits = raw[len(internationalPfx):]
+	default:
+		country, ok := countries[strings.ToUpper(defaultCountry)]
+		if !ok || !isDigits(raw) {
+			return nil, fmt.Errorf("invalid phone number format")
+		}
+		digits = country.CallingCode + stripTrunkPrefix(country, raw)
+	}
+
+	if !isDigits(digits) || digits[0] == '0' || len(digits) > maxE164Digits {
+		return nil, fmt.Errorf("invalid phone number format")
+	}
+
+	for size := 1; size <= 3 && size < len(digits); size++ {
+		callingCode := digits[:size]
+		if candidates, ok := callingCodeCountries[callingCode]; ok {
+			return parseNational(candidates, callingCode, digits[size:])
+		}
+	}
+
+	// Código de país sin reglas: solo se valida el formato E.164 general
+	if len(digits) < minUnknownDigits {
+		return nil, fmt.Errorf("invalid phone number format")
+	}
+	number := &Number{E164: "+" + digits, LineType: LineTypeUnknown}
+	for size := 1; size <= 3 && size < len(digits); size++ {
+		if country, ok := callingCodeRegions[digits[:size]]; ok {
+			number.Country = country
+			number.CallingCode = digits[:size]
+			number.NationalNumber = digits[size:]
+			break
+		}
+	}
+	if number.CallingCode == "7" && (number.NationalNumber[0] == '6' || number.NationalNumber[0] == '7') {
+		number.Country = "KZ" // Kazajistán comparte el +7 con Rusia
+	}
+	return number, nil
+}
+
+// parseNational valida el número nacional con las reglas del país
+func parseNational(candidates []*countryMetadata, callingCode, national string) (*Number, error) {
+	country := candidates[0]
+	if callingCode == nanpCallingCode {
+		country = nanpCountry(national)
+	}
+
+	// "+44 (0) 7911 123456": el prefijo nacional no forma parte del número internacional
+	national = stripTrunkPrefix(country, national)
+
+	if country.Code == "MX" && len(national) == 11 && strings.HasPrefix(national, mexicoLegacyDigit) {
+		national = national[1:]
+	}
+
+	if !slices.Contains(country.Lengths, len(national)) {
+		return nil, fmt.Errorf("invalid phone number format")
+	}
+
+	lineType, ok := detectLineType(country, national)
+	if !ok {
+		return nil, fmt.Errorf("invalid phone number format")
+	}
+
+	return &Number{
+		E164:           "+" + callingCode + national,
+		Country:        country.Code,
+		CallingCode:    callingCode,
+		NationalNumber: national,
+		LineType:       lineType,
+	}, nil
+}
+
+// detectLineType identifica móviles y fijos por prefijo.
+// Retorna false si el país define prefijos y el número no coincide con ninguno.
+func detectLineType(country *countryMetadata, national string) (string, bool) {
+	switch country.CallingCode {
+	case nanpCallingCode:
+		// Código de área y central empiezan en 2-9
+		if national[0] < '2' || national[3] < '2' {
+			return "", false
+		}
+		return LineTypeUnknown, true
+	case "55":
+		// Brasil: DDD de dos dígitos; los móviles tienen 9 dígitos y empiezan en 9
+		if len(national) == 11 && national[2] == '9' {
+			return LineTypeMobile, true
+		}
+		if len(national) == 10 {
+			return LineTypeFixedLine, true
+		}
+		return "", false
+	}
+
+	if len(country.MobilePrefix) == 0 && len(country.FixedPrefix) == 0 {
+		return LineTypeUnknown, true
+	}
+
+	if hasAnyPrefix(national, country.MobilePrefix) &&
+		(len(country.MobileLengths) == 0 || slices.Contains(country.MobileLengths, len(national))) {
+		return LineTypeMobile, true
+	}
+	if hasAnyPrefix(national, country.FixedPrefix) {
+		return LineTypeFixedLine, true
+	}
+
+	return "", false
+}
+
+// nanpCountry detecta el país de un número +1 por su código de área (Estados Unidos por defecto)
+func nanpCountry(national string) *countryMetadata {
+	national = stripTrunkPrefix(countries["US"], national)
+	if len(national) >= 3 {
+		if code, ok := nanpAreaCodes[national[:3]]; ok {
+			if country, ok := countries[code]; ok {
+				return country
+			}
+			return &countryMetadata{Code: code, CallingCode: nanpCallingCode, TrunkPrefix: "1", Lengths: []int{10}}
+		}
+	}
+	return countries["US"]
+}
+
+// stripTrunkPrefix elimina el prefijo nacional ("0", "1" en NANP); ningún número nacional de esos países empieza con él
+func stripTrunkPrefix(country *countryMetadata, national string) string {
+	if country.TrunkPrefix == "" || !strings.HasPrefix(national, country.TrunkPrefix) {
+		return national
+	}
+	return national[len(country.TrunkPrefix):]
+}
+
+func hasAnyPrefix(value string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDigits(value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
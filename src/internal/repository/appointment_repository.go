@@ -0,0 +1,218 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type AppointmentRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewAppointmentRepository(client *dynamodb.Client, tableName string) *AppointmentRepository {
+	return &AppointmentRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+func appointmentKey(businessID, appointmentID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+		"SK": &types.AttributeValueMemberS{Value: "APPOINTMENT#" + appointmentID},
+	}
+}
+
+// Create registra una cita nueva
+func (r *AppointmentRepository) Create(ctx context.Context, appointment *models.Appointment) error {
+	item, err := attributevalue.MarshalMap(appointment)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+
+	return err
+}
+
+// Update reemplaza una cita existente solo si nadie la modificó desde que se leyó (expectedVersion)
+func (r *AppointmentRepository) Update(ctx context.Context, appointment *models.Appointment, expectedVersion int) error {
+	appointment.Version = expectedVersion + 1
+
+	item, err := attributevalue.MarshalMap(appointment)
+	if err != nil {
+		return err
+	}
+
+	// Las citas anteriores al control de versiones no tienen el atributo
+	condition := "version = :version"
+	if expectedVersion == 0 {
+		condition = "attribute_exists(PK) AND (attribute_not_exists(version) OR version = :version)"
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String(condition),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", expectedVersion)},
+		},
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("appointment modified")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByID obtiene una cita del negocio
+func (r *AppointmentRepository) GetByID(ctx context.Context, businessID, appointmentID string) (*models.Appointment, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key:       appointmentKey(businessID, appointmentID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("appointment not found")
+	}
+
+	var appointment models.Appointment
+	if err := attributevalue.UnmarshalMap(out.Item, &appointment); err != nil {
+		return nil, err
+	}
+
+	return &appointment, nil
+}
+
+// ListPage lista las citas del negocio
+func (r *AppointmentRepository) ListPage(ctx context.Context, businessID string, limit int32, cursor string) ([]*models.Appointment, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			":sk": &types.AttributeValueMemberS{Value: "APPOINTMENT#"},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	appointments := make([]*models.Appointment, 0, len(out.Items))
+	for _, item := range out.Items {
+		var appointment models.Appointment
+		if err := attributevalue.UnmarshalMap(item, &appointment); err != nil {
+			return nil, "", err
+		}
+		appointments = append(appointments, &appointment)
+	}
+
+	nextCursor, err := encodeCursor(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return appointments, nextCursor, nil
+}
+
+// AwaitConfirmation marca la cita como pendiente de confirmación al enviarse un recordatorio.
+// Solo aplica a citas vigentes que piden confirmación; una respuesta anterior se conserva.
+func (r *AppointmentRepository) AwaitConfirmation(ctx context.Context, businessID, appointmentID string) (*models.Appointment, error) {
+	out, err := r.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:           aws.String(r.TableName),
+		Key:                 appointmentKey(businessID, appointmentID),
+		UpdateExpression:    aws.String("SET confirmation = if_not_exists(confirmation, :pending) ADD version :one"),
+		ConditionExpression: aws.String("requestConfirmation = :true AND #status = :scheduled"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending":   &types.AttributeValueMemberS{Value: models.AppointmentConfirmationPending},
+			":one":       &types.AttributeValueMemberN{Value: "1"},
+			":true":      &types.AttributeValueMemberBOOL{Value: true},
+			":scheduled": &types.AttributeValueMemberS{Value: models.AppointmentStatusScheduled},
+		},
+		ReturnValues: types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return nil, fmt.Errorf("appointment not awaiting confirmation")
+		}
+		return nil, err
+	}
+
+	var appointment models.Appointment
+	if err := attributevalue.UnmarshalMap(out.Attributes, &appointment); err != nil {
+		return nil, err
+	}
+
+	return &appointment, nil
+}
+
+// PutReplyLink registra (o reemplaza) el recordatorio que espera la respuesta del destinatario
+func (r *AppointmentRepository) PutReplyLink(ctx context.Context, link *models.AppointmentReplyLink) error {
+	item, err := attributevalue.MarshalMap(link)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.TableName),
+		Item:      item,
+	})
+
+	return err
+}
+
+// GetReplyLink obtiene el recordatorio que espera la respuesta de un teléfono para el negocio
+func (r *AppointmentRepository) GetReplyLink(ctx context.Context, channel, phone, businessID string) (*models.AppointmentReplyLink, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "APPOINTMENT_REPLY#" + channel + "#" + phone},
+			"SK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("reply link not found")
+	}
+
+	var link models.AppointmentReplyLink
+	if err := attributevalue.UnmarshalMap(out.Item, &link); err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
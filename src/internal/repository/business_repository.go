@@ -3,10 +3,12 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"notify-backend/internal/models"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
@@ -92,6 +94,10 @@ func (r *BusinessRepository) Create(ctx context.Context, b *models.Business) err
 		metaItem["updatedAt"] = &types.AttributeValueMemberS{Value: b.UpdatedAt}
 	}
 
+	if b.DefaultCountry != "" {
+		metaItem["defaultCountry"] = &types.AttributeValueMemberS{Value: b.DefaultCountry}
+	}
+
 	_, err := r.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
 		TransactItems: []types.TransactWriteItem{
 			{
@@ -185,9 +191,92 @@ func (r *BusinessRepository) GetByPK(ctx context.Context, pk string) (*models.Bu
 		business.UpdatedAt = updatedAt.(*types.AttributeValueMemberS).Value
 	}
 
+	if emailProvider, ok := out.Item["emailProvider"]; ok {
+		business.EmailProvider = emailProvider.(*types.AttributeValueMemberS).Value
+	}
+
+	if defaultSenderDomain, ok := out.Item["defaultSenderDomain"]; ok {
+		business.DefaultSenderDomain = defaultSenderDomain.(*types.AttributeValueMemberS).Value
+	}
+
+	if webhookURL, ok := out.Item["webhookUrl"]; ok {
+		business.WebhookURL = webhookURL.(*types.AttributeValueMemberS).Value
+	}
+
+	if webhookSecret, ok := out.Item["webhookSecret"]; ok {
+		business.WebhookSecret = webhookSecret.(*types.AttributeValueMemberS).Value
+	}
+
+	if defaultCountry, ok := out.Item["defaultCountry"]; ok {
+		business.DefaultCountry = defaultCountry.(*types.AttributeValueMemberS).Value
+	}
+
+	if countryPolicies, ok := out.Item["countryPolicies"]; ok {
+		if err := attributevalue.Unmarshal(countryPolicies, &business.CountryPolicies); err != nil {
+			return nil, fmt.Errorf("unmarshal country policies: %w", err)
+		}
+	}
+
 	return business, nil
 }
 
+// UpdateSettings actualiza atributos de configuración en la metadata del negocio.
+// Los valores nil o "" eliminan el atributo para volver al comportamiento por defecto.
+func (r *BusinessRepository) UpdateSettings(ctx context.Context, businessPK string, settings map[string]interface{}, updatedAt string) error {
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{
+		":updatedAt": &types.AttributeValueMemberS{Value: updatedAt},
+	}
+	setParts := []string{"updatedAt = :updatedAt"}
+	removeParts := []string{}
+
+	i := 0
+	for attribute, value := range settings {
+		i++
+		nameKey := fmt.Sprintf("#s%d", i)
+		names[nameKey] = attribute
+
+		if value == nil || value == "" {
+			removeParts = append(removeParts, nameKey)
+			continue
+		}
+
+		av, err := attributevalue.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("marshal setting %s: %w", attribute, err)
+		}
+		valueKey := fmt.Sprintf(":s%d", i)
+		values[valueKey] = av
+		setParts = append(setParts, nameKey+" = "+valueKey)
+	}
+
+	updateExpression := "SET " + strings.Join(setParts, ", ")
+	if len(removeParts) > 0 {
+		updateExpression += " REMOVE " + strings.Join(removeParts, ", ")
+	}
+
+	input := &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: businessPK},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+		UpdateExpression:          aws.String(updateExpression),
+		ExpressionAttributeValues: values,
+		ConditionExpression:       aws.String("attribute_exists(PK)"),
+	}
+	if len(names) > 0 {
+		input.ExpressionAttributeNames = names
+	}
+
+	_, err := r.Client.UpdateItem(ctx, input)
+	if err != nil {
+		return fmt.Errorf("update settings: %w", err)
+	}
+
+	return nil
+}
+
 func (r *BusinessRepository) UpdateAPIKey(ctx context.Context, businessPK, oldAPIKey, newAPIKey, updatedAt string) error {
 	// Eliminar índice de API Key anterior
 	deleteOldKeyItem := types.TransactWriteItem{
@@ -0,0 +1,264 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type CampaignRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewCampaignRepository(client *dynamodb.Client, tableName string) *CampaignRepository {
+	return &CampaignRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+// CampaignRecipientSK es la clave de un destinatario válido; el índice con ceros permite leerlos en orden
+func CampaignRecipientSK(index int) string {
+	return fmt.Sprintf("RECIPIENT#%06d", index)
+}
+
+// CampaignInvalidSK es la clave de una fila descartada del CSV
+func CampaignInvalidSK(row int) string {
+	return fmt.Sprintf("INVALID#%06d", row)
+}
+
+// SetCampaignQueueKeys asigna las claves de GSI2 mientras la campaña tiene envíos pendientes
+func SetCampaignQueueKeys(campaign *models.Campaign) {
+	campaign.GSI2PK = ""
+	campaign.GSI2SK = ""
+	if campaign.Status == models.CampaignStatusScheduled || campaign.Status == models.CampaignStatusRunning {
+		campaign.GSI2PK = models.CampaignQueueKey
+		campaign.GSI2SK = campaign.NextRunAt + "#" + campaign.CampaignID
+	}
+}
+
+// Create registra la campaña con sus destinatarios. Los destinatarios se escriben primero
+// para que el worker nunca encuentre una campaña en cola sin ellos.
+func (r *CampaignRepository) Create(ctx context.Context, campaign *models.Campaign, recipients []*models.CampaignRecipient) error {
+	if err := r.PutRecipients(ctx, recipients); err != nil {
+		return err
+	}
+
+	SetCampaignQueueKeys(campaign)
+	item, err := attributevalue.MarshalMap(campaign)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+
+	return err
+}
+
+// PutRecipients guarda (o reemplaza) destinatarios de campaña
+func (r *CampaignRepository) PutRecipients(ctx context.Context, recipients []*models.CampaignRecipient) error {
+	// BatchWriteItem acepta máximo 25 escrituras por llamada
+	for start := 0; start < len(recipients); start += 25 {
+		end := start + 25
+		if end > len(recipients) {
+			end = len(recipients)
+		}
+
+		requests := make([]types.WriteRequest, 0, end-start)
+		for _, recipient := range recipients[start:end] {
+			item, err := attributevalue.MarshalMap(recipient)
+			if err != nil {
+				return err
+			}
+			requests = append(requests, types.WriteRequest{PutRequest: &types.PutRequest{Item: item}})
+		}
+
+		pending := map[string][]types.WriteRequest{r.TableName: requests}
+		for len(pending) > 0 {
+			out, err := r.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: pending,
+			})
+			if err != nil {
+				return err
+			}
+			pending = out.UnprocessedItems
+		}
+	}
+
+	return nil
+}
+
+// GetByID obtiene una campaña por su ID
+func (r *CampaignRepository) GetByID(ctx context.Context, campaignID string) (*models.Campaign, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "CAMPAIGN#" + campaignID},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("campaign not found")
+	}
+
+	var campaign models.Campaign
+	if err := attributevalue.UnmarshalMap(out.Item, &campaign); err != nil {
+		return nil, err
+	}
+
+	return &campaign, nil
+}
+
+// ListDue retorna los IDs de las campañas en cola cuya próxima ejecución ya llegó (now en RFC3339)
+func (r *CampaignRepository) ListDue(ctx context.Context, now string, limit int32) ([]string, error) {
+	out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		IndexName:              aws.String(GSI2IndexName),
+		KeyConditionExpression: aws.String("GSI2PK = :pk AND GSI2SK < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: models.CampaignQueueKey},
+			// "~" es mayor que "#": incluye las campañas programadas para este mismo segundo
+			":now": &types.AttributeValueMemberS{Value: now + "~"},
+		},
+		Limit: aws.Int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(out.Items))
+	for _, item := range out.Items {
+		var campaign models.Campaign
+		if err := attributevalue.UnmarshalMap(item, &campaign); err != nil {
+			return nil, err
+		}
+		ids = append(ids, campaign.CampaignID)
+	}
+
+	return ids, nil
+}
+
+// Save guarda la campaña solo si nadie la modificó desde que se leyó (expectedVersion).
+// El worker puede ejecutarse en paralelo: solo uno procesa cada tanda.
+func (r *CampaignRepository) Save(ctx context.Context, campaign *models.Campaign, expectedVersion int) error {
+	SetCampaignQueueKeys(campaign)
+	campaign.Version = expectedVersion + 1
+
+	item, err := attributevalue.MarshalMap(campaign)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", expectedVersion)},
+		},
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("campaign modified")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ListRecipientsFrom retorna hasta limit destinatarios válidos desde el índice from, en orden de envío
+func (r *CampaignRepository) ListRecipientsFrom(ctx context.Context, campaignID string, from int, limit int32) ([]*models.CampaignRecipient, error) {
+	out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :from AND :to"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":   &types.AttributeValueMemberS{Value: "CAMPAIGN#" + campaignID},
+			":from": &types.AttributeValueMemberS{Value: CampaignRecipientSK(from)},
+			":to":   &types.AttributeValueMemberS{Value: "RECIPIENT#~"},
+		},
+		Limit:          aws.Int32(limit),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalCampaignRecipients(out.Items)
+}
+
+// ListRecipientsPage lista los resultados de la campaña, opcionalmente filtrados por estado.
+// Los inválidos tienen su propio prefijo; el resto se filtra por estado (la página puede venir incompleta).
+func (r *CampaignRepository) ListRecipientsPage(ctx context.Context, campaignID, status string, limit int32, cursor string) ([]*models.CampaignRecipient, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "CAMPAIGN#" + campaignID},
+			":sk": &types.AttributeValueMemberS{Value: "RECIPIENT#"},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	}
+	switch status {
+	case models.CampaignRecipientInvalid:
+		input.ExpressionAttributeValues[":sk"] = &types.AttributeValueMemberS{Value: "INVALID#"}
+	case "":
+	default:
+		input.FilterExpression = aws.String("#status = :status")
+		input.ExpressionAttributeNames = map[string]string{"#status": "status"}
+		input.ExpressionAttributeValues[":status"] = &types.AttributeValueMemberS{Value: status}
+	}
+
+	out, err := r.Client.Query(ctx, input)
+	if err != nil {
+		return nil, "", err
+	}
+
+	recipients, err := unmarshalCampaignRecipients(out.Items)
+	if err != nil {
+		return nil, "", err
+	}
+
+	nextCursor, err := encodeCursor(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return recipients, nextCursor, nil
+}
+
+func unmarshalCampaignRecipients(items []map[string]types.AttributeValue) ([]*models.CampaignRecipient, error) {
+	recipients := make([]*models.CampaignRecipient, 0, len(items))
+	for _, item := range items {
+		var recipient models.CampaignRecipient
+		if err := attributevalue.UnmarshalMap(item, &recipient); err != nil {
+			return nil, err
+		}
+		recipients = append(recipients, &recipient)
+	}
+	return recipients, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type ContactRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewContactRepository(client *dynamodb.Client, tableName string) *ContactRepository {
+	return &ContactRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+func contactKey(businessID, contactID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+		"SK": &types.AttributeValueMemberS{Value: "CONTACT#" + contactID},
+	}
+}
+
+// Create registra un contacto nuevo
+func (r *ContactRepository) Create(ctx context.Context, contact *models.Contact) error {
+	item, err := attributevalue.MarshalMap(contact)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+
+	return err
+}
+
+// Update reemplaza un contacto existente; retorna error si fue eliminado
+func (r *ContactRepository) Update(ctx context.Context, contact *models.Contact) error {
+	item, err := attributevalue.MarshalMap(contact)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("contact not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByID obtiene un contacto del negocio
+func (r *ContactRepository) GetByID(ctx context.Context, businessID, contactID string) (*models.Contact, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key:       contactKey(businessID, contactID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("contact not found")
+	}
+
+	var contact models.Contact
+	if err := attributevalue.UnmarshalMap(out.Item, &contact); err != nil {
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+// Delete elimina un contacto; retorna error si no existía
+func (r *ContactRepository) Delete(ctx context.Context, businessID, contactID string) error {
+	out, err := r.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName:    aws.String(r.TableName),
+		Key:          contactKey(businessID, contactID),
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(out.Attributes) == 0 {
+		return fmt.Errorf("contact not found")
+	}
+
+	return nil
+}
+
+// ListPage lista los contactos del negocio
+func (r *ContactRepository) ListPage(ctx context.Context, businessID string, limit int32, cursor string) ([]*models.Contact, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			":sk": &types.AttributeValueMemberS{Value: "CONTACT#"},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	contacts := make([]*models.Contact, 0, len(out.Items))
+	for _, item := range out.Items {
+		var contact models.Contact
+		if err := attributevalue.UnmarshalMap(item, &contact); err != nil {
+			return nil, "", err
+		}
+		contacts = append(contacts, &contact)
+	}
+
+	nextCursor, err := encodeCursor(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return contacts, nextCursor, nil
+}
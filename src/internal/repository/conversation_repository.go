@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type ConversationRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewConversationRepository(client *dynamodb.Client, tableName string) *ConversationRepository {
+	return &ConversationRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+func conversationKey(channel, phone string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "CONVERSATION#" + channel + "#" + phone},
+		"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+	}
+}
+
+// RecordOutbound asigna la conversación al negocio que acaba de enviar al destinatario
+func (r *ConversationRepository) RecordOutbound(ctx context.Context, channel, phone, businessID, at string) error {
+	_, err := r.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(r.TableName),
+		Key:              conversationKey(channel, phone),
+		UpdateExpression: aws.String("SET channel = :channel, phone = :phone, businessId = :businessId, lastOutboundAt = :at"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":channel":    &types.AttributeValueMemberS{Value: channel},
+			":phone":      &types.AttributeValueMemberS{Value: phone},
+			":businessId": &types.AttributeValueMemberS{Value: businessID},
+			":at":         &types.AttributeValueMemberS{Value: at},
+		},
+	})
+
+	return err
+}
+
+// RecordInbound registra la hora del último mensaje recibido y retorna la conversación.
+// Falla si nadie le ha escrito antes al remitente (no hay negocio dueño).
+func (r *ConversationRepository) RecordInbound(ctx context.Context, channel, phone, at string) (*models.Conversation, error) {
+	out, err := r.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:        aws.String(r.TableName),
+		Key:              conversationKey(channel, phone),
+		UpdateExpression: aws.String("SET lastInboundAt = :at"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":at": &types.AttributeValueMemberS{Value: at},
+		},
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+		ReturnValues:        types.ReturnValueAllNew,
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return nil, fmt.Errorf("conversation not found")
+		}
+		return nil, err
+	}
+
+	var conversation models.Conversation
+	if err := attributevalue.UnmarshalMap(out.Attributes, &conversation); err != nil {
+		return nil, err
+	}
+
+	return &conversation, nil
+}
+
+// Get obtiene la conversación de un teléfono en un canal
+func (r *ConversationRepository) Get(ctx context.Context, channel, phone string) (*models.Conversation, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key:       conversationKey(channel, phone),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("conversation not found")
+	}
+
+	var conversation models.Conversation
+	if err := attributevalue.UnmarshalMap(out.Item, &conversation); err != nil {
+		return nil, err
+	}
+
+	return &conversation, nil
+}
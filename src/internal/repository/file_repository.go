@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// fileChunkSize deja margen bajo el límite de 400 KB por item de DynamoDB
+const fileChunkSize = 350 * 1024
+
+type FileRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewFileRepository(client *dynamodb.Client, tableName string) *FileRepository {
+	return &FileRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+// Create guarda el contenido en chunks y luego la metadata del archivo.
+// La metadata se escribe al final para que un archivo solo sea visible cuando está completo.
+func (r *FileRepository) Create(ctx context.Context, file *models.File, content []byte) error {
+	chunks := make([]types.WriteRequest, 0, len(content)/fileChunkSize+1)
+	for i := 0; i*fileChunkSize < len(content); i++ {
+		end := (i + 1) * fileChunkSize
+		if end > len(content) {
+			end = len(content)
+		}
+
+		chunks = append(chunks, types.WriteRequest{
+			PutRequest: &types.PutRequest{
+				Item: map[string]types.AttributeValue{
+					"PK":   &types.AttributeValueMemberS{Value: "FILE#" + file.FileID},
+					"SK":   &types.AttributeValueMemberS{Value: fmt.Sprintf("CHUNK#%04d", i)},
+					"data": &types.AttributeValueMemberB{Value: content[i*fileChunkSize : end]},
+				},
+			},
+		})
+	}
+	file.ChunkCount = len(chunks)
+
+	// BatchWriteItem acepta máximo 25 items por llamada
+	for start := 0; start < len(chunks); start += 25 {
+		end := start + 25
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+
+		pending := map[string][]types.WriteRequest{r.TableName: chunks[start:end]}
+		for len(pending) > 0 {
+			out, err := r.Client.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{
+				RequestItems: pending,
+			})
+			if err != nil {
+				return fmt.Errorf("write file chunks: %w", err)
+			}
+			pending = out.UnprocessedItems
+		}
+	}
+
+	item, err := attributevalue.MarshalMap(file)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+
+	return err
+}
+
+// GetByID obtiene la metadata de un archivo del negocio
+func (r *FileRepository) GetByID(ctx context.Context, businessID, fileID string) (*models.File, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			"SK": &types.AttributeValueMemberS{Value: "FILE#" + fileID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("file not found")
+	}
+
+	var file models.File
+	if err := attributevalue.UnmarshalMap(out.Item, &file); err != nil {
+		return nil, err
+	}
+
+	return &file, nil
+}
+
+// GetContent reensambla el contenido de un archivo a partir de sus chunks
+func (r *FileRepository) GetContent(ctx context.Context, file *models.File) ([]byte, error) {
+	content := make([]byte, 0, file.Size)
+
+	var startKey map[string]types.AttributeValue
+	for {
+		out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.TableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "FILE#" + file.FileID},
+				":sk": &types.AttributeValueMemberS{Value: "CHUNK#"},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range out.Items {
+			data, ok := item["data"].(*types.AttributeValueMemberB)
+			if !ok {
+				return nil, fmt.Errorf("corrupted file chunk")
+			}
+			content = append(content, data.Value...)
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = out.LastEvaluatedKey
+	}
+
+	if len(content) != file.Size {
+		return nil, fmt.Errorf("incomplete file content")
+	}
+
+	return content, nil
+}
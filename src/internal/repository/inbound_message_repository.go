@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type InboundMessageRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewInboundMessageRepository(client *dynamodb.Client, tableName string) *InboundMessageRepository {
+	return &InboundMessageRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+// Create guarda un mensaje recibido junto con un marcador por SID: un reintento del webhook con el mismo
+// SID no lo duplica y retorna "inbound message exists" (el SK conserva el orden por fecha para el listado)
+func (r *InboundMessageRepository) Create(ctx context.Context, message *models.InboundMessage) error {
+	message.SK = "INBOUND#" + message.ReceivedAt + "#" + message.MessageSID
+
+	item, err := attributevalue.MarshalMap(message)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{
+				Put: &types.Put{
+					TableName: aws.String(r.TableName),
+					Item: map[string]types.AttributeValue{
+						"PK":         &types.AttributeValueMemberS{Value: message.PK},
+						"SK":         &types.AttributeValueMemberS{Value: "INBOUND_SID#" + message.MessageSID},
+						"inboundSk":  &types.AttributeValueMemberS{Value: message.SK},
+						"receivedAt": &types.AttributeValueMemberS{Value: message.ReceivedAt},
+					},
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName: aws.String(r.TableName),
+					Item:      item,
+				},
+			},
+		},
+	})
+	if err != nil {
+		var canceledErr *types.TransactionCanceledException
+		if errors.As(err, &canceledErr) && len(canceledErr.CancellationReasons) > 0 &&
+			aws.ToString(canceledErr.CancellationReasons[0].Code) == "ConditionalCheckFailed" {
+			return fmt.Errorf("inbound message exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ListPage lista los mensajes recibidos por el negocio, del más reciente al más antiguo
+func (r *InboundMessageRepository) ListPage(ctx context.Context, businessID string, limit int32, cursor string) ([]*models.InboundMessage, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			":sk": &types.AttributeValueMemberS{Value: "INBOUND#"},
+		},
+		ScanIndexForward:  aws.Bool(false),
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	messages := make([]*models.InboundMessage, 0, len(out.Items))
+	for _, item := range out.Items {
+		var message models.InboundMessage
+		if err := attributevalue.UnmarshalMap(item, &message); err != nil {
+			return nil, "", err
+		}
+		messages = append(messages, &message)
+	}
+
+	nextCursor, err := encodeCursor(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return messages, nextCursor, nil
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Índices secundarios globales de la tabla NotificationService.
+// Se usan atributos sobrecargados (GSI1PK/GSI1SK, GSI2PK/GSI2SK) para que
+// varias entidades puedan compartir el mismo índice sin crear uno nuevo por caso.
+const (
+	// GSI1: templates por tipo + externalId; notificaciones por negocio + fecha
+	GSI1IndexName = "GSI1"
+	// GSI2: templates por tipo + estado activo
+	GSI2IndexName = "GSI2"
+)
+
+// encodeCursor convierte el LastEvaluatedKey de DynamoDB en un cursor opaco para la API
+func encodeCursor(lastKey map[string]types.AttributeValue) (string, error) {
+	if len(lastKey) == 0 {
+		return "", nil
+	}
+
+	var raw map[string]string
+	if err := attributevalue.UnmarshalMap(lastKey, &raw); err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+
+	jsonBytes, err := json.Marshal(raw)
+	if err != nil {
+		return "", fmt.Errorf("encode cursor: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(jsonBytes), nil
+}
+
+// decodeCursor convierte un cursor recibido por la API en un ExclusiveStartKey
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+
+	jsonBytes, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal(jsonBytes, &raw); err != nil {
+		return nil, fmt.Errorf("invalid cursor")
+	}
+
+	return attributevalue.MarshalMap(raw)
+}
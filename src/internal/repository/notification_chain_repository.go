@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type NotificationChainRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewNotificationChainRepository(client *dynamodb.Client, tableName string) *NotificationChainRepository {
+	return &NotificationChainRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+// Create registra una cadena nueva
+func (r *NotificationChainRepository) Create(ctx context.Context, chain *models.NotificationChain) error {
+	item, err := attributevalue.MarshalMap(chain)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+
+	return err
+}
+
+// GetByID obtiene una cadena por su ID
+func (r *NotificationChainRepository) GetByID(ctx context.Context, chainID string) (*models.NotificationChain, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "CHAIN#" + chainID},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("chain not found")
+	}
+
+	var chain models.NotificationChain
+	if err := attributevalue.UnmarshalMap(out.Item, &chain); err != nil {
+		return nil, err
+	}
+
+	return &chain, nil
+}
+
+// Save guarda la cadena solo si sigue en el paso y estado leídos (expectedStep, expectedStatus).
+// Los callbacks de Twilio pueden llegar repetidos o en paralelo: solo uno avanza la cadena.
+func (r *NotificationChainRepository) Save(ctx context.Context, chain *models.NotificationChain, expectedStep int, expectedStatus string) error {
+	item, err := attributevalue.MarshalMap(chain)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("currentStep = :step AND #status = :status"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":step":   &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", expectedStep)},
+			":status": &types.AttributeValueMemberS{Value: expectedStatus},
+		},
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("chain modified")
+		}
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,398 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type NotificationRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewNotificationRepository(client *dynamodb.Client, tableName string) *NotificationRepository {
+	return &NotificationRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+// SetNotificationIndexKeys asigna las claves de GSI1 para listar notificaciones por negocio
+func SetNotificationIndexKeys(notification *models.Notification) {
+	notification.GSI1PK = "BUSINESS#" + notification.BusinessID
+	notification.GSI1SK = "NOTIFICATION#" + notification.CreatedAt + "#" + notification.NotificationID
+}
+
+// Create registra una notificación enviada
+func (r *NotificationRepository) Create(ctx context.Context, notification *models.Notification) error {
+	SetNotificationIndexKeys(notification)
+	if notification.LinkClicks == nil {
+		// El map debe existir para poder incrementar linkClicks.{url} en RecordClick
+		notification.LinkClicks = map[string]int{}
+	}
+
+	item, err := attributevalue.MarshalMap(notification)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+
+	return err
+}
+
+// GetByID obtiene una notificación por su ID
+func (r *NotificationRepository) GetByID(ctx context.Context, notificationID string) (*models.Notification, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "NOTIFICATION#" + notificationID},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("notification not found")
+	}
+
+	var notification models.Notification
+	if err := attributevalue.UnmarshalMap(out.Item, &notification); err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// RecordOpen incrementa las aperturas y retorna la notificación actualizada.
+// Opens == 1 en el resultado indica que es la primera apertura.
+func (r *NotificationRepository) RecordOpen(ctx context.Context, notificationID, at string) (*models.Notification, error) {
+	return r.recordEvent(ctx, notificationID, &dynamodb.UpdateItemInput{
+		UpdateExpression: aws.String("ADD opens :one SET lastOpenedAt = :at, firstOpenedAt = if_not_exists(firstOpenedAt, :at)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one": &types.AttributeValueMemberN{Value: "1"},
+			":at":  &types.AttributeValueMemberS{Value: at},
+		},
+	})
+}
+
+// RecordClick incrementa los clics totales y los de la URL de destino.
+// Clicks == 1 en el resultado indica que es el primer clic.
+func (r *NotificationRepository) RecordClick(ctx context.Context, notificationID, url, at string) (*models.Notification, error) {
+	return r.recordEvent(ctx, notificationID, &dynamodb.UpdateItemInput{
+		UpdateExpression: aws.String("ADD clicks :one SET lastClickedAt = :at, firstClickedAt = if_not_exists(firstClickedAt, :at), linkClicks.#url = if_not_exists(linkClicks.#url, :zero) + :one"),
+		ExpressionAttributeNames: map[string]string{
+			"#url": url,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one":  &types.AttributeValueMemberN{Value: "1"},
+			":zero": &types.AttributeValueMemberN{Value: "0"},
+			":at":   &types.AttributeValueMemberS{Value: at},
+		},
+	})
+}
+
+func (r *NotificationRepository) recordEvent(ctx context.Context, notificationID string, input *dynamodb.UpdateItemInput) (*models.Notification, error) {
+	input.TableName = aws.String(r.TableName)
+	input.Key = map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "NOTIFICATION#" + notificationID},
+		"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+	}
+	input.ConditionExpression = aws.String("attribute_exists(PK)")
+	input.ReturnValues = types.ReturnValueAllNew
+
+	out, err := r.Client.UpdateItem(ctx, input)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return nil, fmt.Errorf("notification not found")
+		}
+		return nil, err
+	}
+
+	var notification models.Notification
+	if err := attributevalue.UnmarshalMap(out.Attributes, &notification); err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// SetChain asocia la notificación a una cadena de canales de respaldo y retorna la notificación actualizada
+// (con el estado de entrega que ya haya reportado el proveedor)
+func (r *NotificationRepository) SetChain(ctx context.Context, notificationID, chainID string) (*models.Notification, error) {
+	return r.recordEvent(ctx, notificationID, &dynamodb.UpdateItemInput{
+		UpdateExpression: aws.String("SET chainId = :chainId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":chainId": &types.AttributeValueMemberS{Value: chainID},
+		},
+	})
+}
+
+// SetAppointment asocia la notificación a la cita de la que es recordatorio
+func (r *NotificationRepository) SetAppointment(ctx context.Context, notificationID, appointmentID string) error {
+	_, err := r.recordEvent(ctx, notificationID, &dynamodb.UpdateItemInput{
+		UpdateExpression: aws.String("SET appointmentId = :appointmentId"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":appointmentId": &types.AttributeValueMemberS{Value: appointmentID},
+		},
+	})
+	return err
+}
+
+// UpdateStatus registra el estado de entrega reportado por el proveedor y retorna la notificación actualizada
+func (r *NotificationRepository) UpdateStatus(ctx context.Context, notificationID, status, errorMessage, at string) (*models.Notification, error) {
+	input := &dynamodb.UpdateItemInput{
+		UpdateExpression: aws.String("SET #status = :status, statusUpdatedAt = :at"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: status},
+			":at":     &types.AttributeValueMemberS{Value: at},
+		},
+	}
+	if errorMessage != "" {
+		input.UpdateExpression = aws.String("SET #status = :status, statusUpdatedAt = :at, #error = :error")
+		input.ExpressionAttributeNames["#error"] = "error"
+		input.ExpressionAttributeValues[":error"] = &types.AttributeValueMemberS{Value: errorMessage}
+	}
+
+	return r.recordEvent(ctx, notificationID, input)
+}
+
+// ListScheduledDue retorna los IDs de los envíos programados cuyo sendAt ya llegó y de los tomados cuya reserva
+// venció (now en RFC3339)
+func (r *NotificationRepository) ListScheduledDue(ctx context.Context, now string, limit int32) ([]string, error) {
+	out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		IndexName:              aws.String(GSI2IndexName),
+		KeyConditionExpression: aws.String("GSI2PK = :pk AND GSI2SK < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: models.ScheduledQueueKey},
+			// "~" es mayor que "#": incluye los envíos programados para este mismo segundo
+			":now": &types.AttributeValueMemberS{Value: now + "~"},
+		},
+		Limit: aws.Int32(limit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(out.Items))
+	for _, item := range out.Items {
+		var notification models.Notification
+		if err := attributevalue.UnmarshalMap(item, &notification); err != nil {
+			return nil, err
+		}
+		ids = append(ids, notification.NotificationID)
+	}
+
+	return ids, nil
+}
+
+// ClaimScheduled toma un envío programado para enviarlo. Solo un worker lo obtiene: falla si ya no está
+// programado o si se reprogramó después de leerlo (sendAt distinto). El envío sigue en la cola hasta leaseUntil:
+// si el worker no lo completa antes, otro worker lo encuentra y libera la reserva (FailStaleClaim).
+func (r *NotificationRepository) ClaimScheduled(ctx context.Context, notificationID, sendAt, at, leaseUntil string) error {
+	_, err := r.updateScheduled(ctx, notificationID, &dynamodb.UpdateItemInput{
+		UpdateExpression:    aws.String("SET #status = :dispatching, statusUpdatedAt = :at, GSI2SK = :queueSK"),
+		ConditionExpression: aws.String("#status = :scheduled AND sendAt = :sendAt"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":dispatching": &types.AttributeValueMemberS{Value: models.NotificationStatusDispatching},
+			":sendAt":      &types.AttributeValueMemberS{Value: sendAt},
+			":at":          &types.AttributeValueMemberS{Value: at},
+			":queueSK":     &types.AttributeValueMemberS{Value: leaseUntil + "#" + notificationID},
+		},
+	})
+	return err
+}
+
+// FailStaleClaim marca como fallido un envío cuya reserva venció sin completarse (el worker se interrumpió)
+// y lo saca de la cola. Falla si otro worker ya lo completó o lo tomó de nuevo (queueSK distinto).
+func (r *NotificationRepository) FailStaleClaim(ctx context.Context, notificationID, queueSK, errorMessage, at string) error {
+	_, err := r.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "NOTIFICATION#" + notificationID},
+			"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+		},
+		UpdateExpression:    aws.String("SET #status = :failed, statusUpdatedAt = :at, #error = :error REMOVE GSI2PK, GSI2SK"),
+		ConditionExpression: aws.String("#status = :dispatching AND GSI2SK = :queueSK"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+			"#error":  "error",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":failed":      &types.AttributeValueMemberS{Value: "failed"},
+			":dispatching": &types.AttributeValueMemberS{Value: models.NotificationStatusDispatching},
+			":queueSK":     &types.AttributeValueMemberS{Value: queueSK},
+			":error":       &types.AttributeValueMemberS{Value: errorMessage},
+			":at":          &types.AttributeValueMemberS{Value: at},
+		},
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("notification not dispatching")
+		}
+		return err
+	}
+	return nil
+}
+
+// CancelScheduled cancela un envío programado pendiente y retorna la notificación actualizada
+func (r *NotificationRepository) CancelScheduled(ctx context.Context, notificationID, at string) (*models.Notification, error) {
+	return r.updateScheduled(ctx, notificationID, &dynamodb.UpdateItemInput{
+		UpdateExpression:    aws.String("SET #status = :cancelled, statusUpdatedAt = :at REMOVE GSI2PK, GSI2SK"),
+		ConditionExpression: aws.String("#status = :scheduled"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":cancelled": &types.AttributeValueMemberS{Value: models.NotificationStatusCancelled},
+			":at":        &types.AttributeValueMemberS{Value: at},
+		},
+	})
+}
+
+// RescheduleScheduled cambia el sendAt de un envío programado pendiente y retorna la notificación actualizada
+func (r *NotificationRepository) RescheduleScheduled(ctx context.Context, notificationID, sendAt, at string) (*models.Notification, error) {
+	return r.updateScheduled(ctx, notificationID, &dynamodb.UpdateItemInput{
+		UpdateExpression:    aws.String("SET sendAt = :sendAt, GSI2SK = :queueSK, statusUpdatedAt = :at"),
+		ConditionExpression: aws.String("#status = :scheduled"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":sendAt":  &types.AttributeValueMemberS{Value: sendAt},
+			":queueSK": &types.AttributeValueMemberS{Value: sendAt + "#" + notificationID},
+			":at":      &types.AttributeValueMemberS{Value: at},
+		},
+	})
+}
+
+// CompleteScheduled registra el resultado del envío de un envío programado (sent con la notificación creada, o failed)
+// y lo saca de la cola
+func (r *NotificationRepository) CompleteScheduled(ctx context.Context, notificationID, status, dispatchedID, errorMessage, at string) error {
+	input := &dynamodb.UpdateItemInput{
+		UpdateExpression: aws.String("SET #status = :status, statusUpdatedAt = :at"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":status": &types.AttributeValueMemberS{Value: status},
+			":at":     &types.AttributeValueMemberS{Value: at},
+		},
+	}
+	if dispatchedID != "" {
+		input.UpdateExpression = aws.String(*input.UpdateExpression + ", dispatchedNotificationId = :dispatched")
+		input.ExpressionAttributeValues[":dispatched"] = &types.AttributeValueMemberS{Value: dispatchedID}
+	}
+	if errorMessage != "" {
+		input.UpdateExpression = aws.String(*input.UpdateExpression + ", #error = :error")
+		input.ExpressionAttributeNames["#error"] = "error"
+		input.ExpressionAttributeValues[":error"] = &types.AttributeValueMemberS{Value: errorMessage}
+	}
+	input.UpdateExpression = aws.String(*input.UpdateExpression + " REMOVE GSI2PK, GSI2SK")
+
+	_, err := r.recordEvent(ctx, notificationID, input)
+	return err
+}
+
+// updateScheduled aplica una actualización condicionada a que el envío siga programado
+func (r *NotificationRepository) updateScheduled(ctx context.Context, notificationID string, input *dynamodb.UpdateItemInput) (*models.Notification, error) {
+	input.TableName = aws.String(r.TableName)
+	input.Key = map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "NOTIFICATION#" + notificationID},
+		"SK": &types.AttributeValueMemberS{Value: "METADATA"},
+	}
+	input.ExpressionAttributeNames = map[string]string{"#status": "status"}
+	input.ExpressionAttributeValues[":scheduled"] = &types.AttributeValueMemberS{Value: models.NotificationStatusScheduled}
+	input.ReturnValues = types.ReturnValueAllNew
+
+	out, err := r.Client.UpdateItem(ctx, input)
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return nil, fmt.Errorf("notification not scheduled")
+		}
+		return nil, err
+	}
+
+	var notification models.Notification
+	if err := attributevalue.UnmarshalMap(out.Attributes, &notification); err != nil {
+		return nil, err
+	}
+
+	return &notification, nil
+}
+
+// IncrementTemplateStats suma contadores (sent, clickTracked, opens, uniqueOpens, clicks, uniqueClicks) a las métricas de una plantilla
+func (r *NotificationRepository) IncrementTemplateStats(ctx context.Context, businessID, templateID string, counters map[string]int, updatedAt string) error {
+	names := map[string]string{}
+	values := map[string]types.AttributeValue{
+		":templateId": &types.AttributeValueMemberS{Value: templateID},
+		":updatedAt":  &types.AttributeValueMemberS{Value: updatedAt},
+	}
+
+	addExpression := ""
+	i := 0
+	for counter, delta := range counters {
+		i++
+		nameKey := fmt.Sprintf("#c%d", i)
+		valueKey := fmt.Sprintf(":c%d", i)
+		names[nameKey] = counter
+		values[valueKey] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", delta)}
+		if addExpression != "" {
+			addExpression += ", "
+		}
+		addExpression += nameKey + " " + valueKey
+	}
+
+	_, err := r.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			"SK": &types.AttributeValueMemberS{Value: "TRACKING#TEMPLATE#" + templateID},
+		},
+		UpdateExpression:          aws.String("SET templateId = :templateId, updatedAt = :updatedAt ADD " + addExpression),
+		ExpressionAttributeNames:  names,
+		ExpressionAttributeValues: values,
+	})
+
+	return err
+}
+
+// GetTemplateStats obtiene las métricas agregadas de una plantilla
+func (r *NotificationRepository) GetTemplateStats(ctx context.Context, businessID, templateID string) (*models.TemplateTrackingStats, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			"SK": &types.AttributeValueMemberS{Value: "TRACKING#TEMPLATE#" + templateID},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("template stats not found")
+	}
+
+	var stats models.TemplateTrackingStats
+	if err := attributevalue.UnmarshalMap(out.Item, &stats); err != nil {
+		return nil, err
+	}
+
+	return &stats, nil
+}
@@ -7,6 +7,7 @@ import (
 	"notify-backend/internal/models"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
@@ -61,6 +62,20 @@ func (r *PlanRepository) GetByID(ctx context.Context, planID string) (*models.Pl
 		plan.Price = price
 	}
 
+	if maxBytesItem, ok := out.Item["attachmentMaxBytes"]; ok {
+		fmt.Sscanf(maxBytesItem.(*types.AttributeValueMemberN).Value, "%d", &plan.AttachmentMaxBytes)
+	}
+
+	if chargePerSegment, ok := out.Item["smsChargePerSegment"]; ok {
+		plan.SMSChargePerSegment = chargePerSegment.(*types.AttributeValueMemberBOOL).Value
+	}
+
+	if countryPolicies, ok := out.Item["countryPolicies"]; ok {
+		if err := attributevalue.Unmarshal(countryPolicies, &plan.CountryPolicies); err != nil {
+			return nil, fmt.Errorf("unmarshal country policies: %w", err)
+		}
+	}
+
 	return plan, nil
 }
 
@@ -77,6 +92,22 @@ func (r *PlanRepository) Create(ctx context.Context, plan *models.Plan) error {
 		"createdAt":         &types.AttributeValueMemberS{Value: plan.CreatedAt},
 	}
 
+	if plan.AttachmentMaxBytes > 0 {
+		item["attachmentMaxBytes"] = &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", plan.AttachmentMaxBytes)}
+	}
+
+	if plan.SMSChargePerSegment {
+		item["smsChargePerSegment"] = &types.AttributeValueMemberBOOL{Value: true}
+	}
+
+	if len(plan.CountryPolicies) > 0 {
+		countryPolicies, err := attributevalue.Marshal(plan.CountryPolicies)
+		if err != nil {
+			return fmt.Errorf("marshal country policies: %w", err)
+		}
+		item["countryPolicies"] = countryPolicies
+	}
+
 	_, err := r.Client.PutItem(ctx, &dynamodb.PutItemInput{
 		TableName: aws.String(r.TableName),
 		Item:      item,
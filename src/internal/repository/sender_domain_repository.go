@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type SenderDomainRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewSenderDomainRepository(client *dynamodb.Client, tableName string) *SenderDomainRepository {
+	return &SenderDomainRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+// Create registra un dominio nuevo; falla si el negocio ya lo tiene registrado
+func (r *SenderDomainRepository) Create(ctx context.Context, domain *models.SenderDomain) error {
+	item, err := attributevalue.MarshalMap(domain)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("domain already registered")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// GetByDomain obtiene un dominio registrado por el negocio
+func (r *SenderDomainRepository) GetByDomain(ctx context.Context, businessID, domain string) (*models.SenderDomain, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			"SK": &types.AttributeValueMemberS{Value: "DOMAIN#" + domain},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("domain not found")
+	}
+
+	var senderDomain models.SenderDomain
+	if err := attributevalue.UnmarshalMap(out.Item, &senderDomain); err != nil {
+		return nil, err
+	}
+
+	return &senderDomain, nil
+}
+
+// ListByBusiness retorna todos los dominios registrados por el negocio
+func (r *SenderDomainRepository) ListByBusiness(ctx context.Context, businessID string) ([]*models.SenderDomain, error) {
+	var domains []*models.SenderDomain
+
+	var startKey map[string]types.AttributeValue
+	for {
+		out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+			TableName:              aws.String(r.TableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+				":sk": &types.AttributeValueMemberS{Value: "DOMAIN#"},
+			},
+			ExclusiveStartKey: startKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range out.Items {
+			var senderDomain models.SenderDomain
+			if err := attributevalue.UnmarshalMap(item, &senderDomain); err != nil {
+				return nil, err
+			}
+			domains = append(domains, &senderDomain)
+		}
+
+		if len(out.LastEvaluatedKey) == 0 {
+			break
+		}
+		startKey = out.LastEvaluatedKey
+	}
+
+	return domains, nil
+}
+
+// UpdateVerification guarda el resultado de la verificación DNS
+func (r *SenderDomainRepository) UpdateVerification(ctx context.Context, domain *models.SenderDomain) error {
+	_, err := r.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: domain.PK},
+			"SK": &types.AttributeValueMemberS{Value: domain.SK},
+		},
+		UpdateExpression: aws.String("SET verified = :verified, verifiedAt = :verifiedAt, dkimEnabled = :dkimEnabled, updatedAt = :updatedAt"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":verified":    &types.AttributeValueMemberBOOL{Value: domain.Verified},
+			":verifiedAt":  &types.AttributeValueMemberS{Value: domain.VerifiedAt},
+			":dkimEnabled": &types.AttributeValueMemberBOOL{Value: domain.DKIMEnabled},
+			":updatedAt":   &types.AttributeValueMemberS{Value: domain.UpdatedAt},
+		},
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	})
+
+	return err
+}
@@ -0,0 +1,207 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type SuppressionRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewSuppressionRepository(client *dynamodb.Client, tableName string) *SuppressionRepository {
+	return &SuppressionRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+func suppressionSK(channel, value string) string {
+	return "SUPPRESSION#" + channel + "#" + value
+}
+
+// Put agrega o reemplaza una supresión (idempotente)
+func (r *SuppressionRepository) Put(ctx context.Context, suppression *models.Suppression) error {
+	suppression.SK = suppressionSK(suppression.Channel, suppression.Value)
+
+	item, err := attributevalue.MarshalMap(suppression)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(r.TableName),
+		Item:      item,
+	})
+
+	return err
+}
+
+// Create agrega una supresión si no existe; no reemplaza una con otra razón
+func (r *SuppressionRepository) Create(ctx context.Context, suppression *models.Suppression) error {
+	suppression.SK = suppressionSK(suppression.Channel, suppression.Value)
+
+	item, err := attributevalue.MarshalMap(suppression)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK)"),
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("suppression exists")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// DeleteWithReason elimina una supresión solo si se creó con esa razón
+func (r *SuppressionRepository) DeleteWithReason(ctx context.Context, businessID, channel, value, reason string) error {
+	_, err := r.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			"SK": &types.AttributeValueMemberS{Value: suppressionSK(channel, value)},
+		},
+		ConditionExpression: aws.String("reason = :reason"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":reason": &types.AttributeValueMemberS{Value: reason},
+		},
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("suppression not found")
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Delete elimina una supresión; retorna error si no existía
+func (r *SuppressionRepository) Delete(ctx context.Context, businessID, channel, value string) error {
+	out, err := r.Client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			"SK": &types.AttributeValueMemberS{Value: suppressionSK(channel, value)},
+		},
+		ReturnValues: types.ReturnValueAllOld,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(out.Attributes) == 0 {
+		return fmt.Errorf("suppression not found")
+	}
+
+	return nil
+}
+
+// FindSuppressed retorna cuáles de los valores están suprimidos para el negocio
+func (r *SuppressionRepository) FindSuppressed(ctx context.Context, businessID, channel string, values []string) ([]string, error) {
+	var suppressed []string
+
+	// BatchGetItem acepta máximo 100 claves por llamada
+	for start := 0; start < len(values); start += 100 {
+		end := start + 100
+		if end > len(values) {
+			end = len(values)
+		}
+
+		keys := make([]map[string]types.AttributeValue, 0, end-start)
+		seen := make(map[string]bool)
+		for _, value := range values[start:end] {
+			if seen[value] {
+				continue
+			}
+			seen[value] = true
+			keys = append(keys, map[string]types.AttributeValue{
+				"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+				"SK": &types.AttributeValueMemberS{Value: suppressionSK(channel, value)},
+			})
+		}
+
+		pending := map[string]types.KeysAndAttributes{
+			r.TableName: {Keys: keys, ProjectionExpression: aws.String("#value"), ExpressionAttributeNames: map[string]string{"#value": "value"}},
+		}
+		for len(pending) > 0 {
+			out, err := r.Client.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{
+				RequestItems: pending,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, item := range out.Responses[r.TableName] {
+				if value, ok := item["value"].(*types.AttributeValueMemberS); ok {
+					suppressed = append(suppressed, value.Value)
+				}
+			}
+			pending = out.UnprocessedKeys
+		}
+	}
+
+	return suppressed, nil
+}
+
+// ListPage lista las supresiones del negocio, opcionalmente filtradas por canal
+func (r *SuppressionRepository) ListPage(ctx context.Context, businessID, channel string, limit int32, cursor string) ([]*models.Suppression, string, error) {
+	prefix := "SUPPRESSION#"
+	if channel != "" {
+		prefix += channel + "#"
+	}
+
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			":sk": &types.AttributeValueMemberS{Value: prefix},
+		},
+		Limit:             aws.Int32(limit),
+		ExclusiveStartKey: startKey,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	suppressions := make([]*models.Suppression, 0, len(out.Items))
+	for _, item := range out.Items {
+		var suppression models.Suppression
+		if err := attributevalue.UnmarshalMap(item, &suppression); err != nil {
+			return nil, "", err
+		}
+		suppressions = append(suppressions, &suppression)
+	}
+
+	nextCursor, err := encodeCursor(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return suppressions, nextCursor, nil
+}
@@ -50,20 +50,17 @@ func (r *TemplateRepository) GetByID(ctx context.Context, templateID string) (*m
 	return &template, nil
 }
 
-// GetByTypeAndExternalID obtiene una plantilla por tipo y external ID (para búsquedas específicas)
+// GetByTypeAndExternalID obtiene una plantilla por tipo y external ID usando el GSI1
 func (r *TemplateRepository) GetByTypeAndExternalID(ctx context.Context, templateType, externalID string) (*models.Template, error) {
-	// Esto requeriría un GSI, por ahora hacemos un scan (no recomendado en producción)
-	out, err := r.Client.Scan(ctx, &dynamodb.ScanInput{
-		TableName:        aws.String(r.TableName),
-		FilterExpression: aws.String("begins_with(PK, :pk) AND #type = :type AND externalId = :externalId"),
-		ExpressionAttributeNames: map[string]string{
-			"#type": "type",
-		},
+	out, err := r.Client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		IndexName:              aws.String(GSI1IndexName),
+		KeyConditionExpression: aws.String("GSI1PK = :pk AND GSI1SK = :sk"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pk":         &types.AttributeValueMemberS{Value: "TEMPLATE#"},
-			":type":       &types.AttributeValueMemberS{Value: templateType},
-			":externalId": &types.AttributeValueMemberS{Value: externalID},
+			":pk": &types.AttributeValueMemberS{Value: templateTypeKey(templateType)},
+			":sk": &types.AttributeValueMemberS{Value: "EXTERNAL#" + externalID},
 		},
+		Limit: aws.Int32(1),
 	})
 	if err != nil {
 		return nil, err
@@ -82,22 +79,53 @@ func (r *TemplateRepository) GetByTypeAndExternalID(ctx context.Context, templat
 	return &template, nil
 }
 
-// ListByType lista todas las plantillas de un tipo específico
+// ListByType lista todas las plantillas activas de un tipo específico, recorriendo todas las páginas
 func (r *TemplateRepository) ListByType(ctx context.Context, templateType string) ([]*models.Template, error) {
-	out, err := r.Client.Scan(ctx, &dynamodb.ScanInput{
-		TableName:        aws.String(r.TableName),
-		FilterExpression: aws.String("begins_with(PK, :pk) AND #type = :type AND active = :active"),
-		ExpressionAttributeNames: map[string]string{
-			"#type": "type",
-		},
+	templates := []*models.Template{}
+	cursor := ""
+
+	for {
+		page, next, err := r.ListByTypePage(ctx, templateType, 0, cursor)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, page...)
+
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+
+	return templates, nil
+}
+
+// ListByTypePage lista una página de plantillas activas de un tipo usando el GSI2.
+// limit <= 0 deja que DynamoDB use su tamaño de página por defecto (1 MB).
+// Retorna el cursor de la siguiente página o "" si no hay más resultados.
+func (r *TemplateRepository) ListByTypePage(ctx context.Context, templateType string, limit int32, cursor string) ([]*models.Template, string, error) {
+	startKey, err := decodeCursor(cursor)
+	if err != nil {
+		return nil, "", err
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.TableName),
+		IndexName:              aws.String(GSI2IndexName),
+		KeyConditionExpression: aws.String("GSI2PK = :pk AND begins_with(GSI2SK, :sk)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pk":     &types.AttributeValueMemberS{Value: "TEMPLATE#"},
-			":type":   &types.AttributeValueMemberS{Value: templateType},
-			":active": &types.AttributeValueMemberBOOL{Value: true},
+			":pk": &types.AttributeValueMemberS{Value: templateTypeKey(templateType)},
+			":sk": &types.AttributeValueMemberS{Value: "ACTIVE#true#"},
 		},
-	})
+		ExclusiveStartKey: startKey,
+	}
+	if limit > 0 {
+		input.Limit = aws.Int32(limit)
+	}
+
+	out, err := r.Client.Query(ctx, input)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	templates := make([]*models.Template, 0, len(out.Items))
@@ -110,11 +138,18 @@ func (r *TemplateRepository) ListByType(ctx context.Context, templateType string
 		templates = append(templates, &template)
 	}
 
-	return templates, nil
+	next, err := encodeCursor(out.LastEvaluatedKey)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return templates, next, nil
 }
 
 // Create crea una nueva plantilla
 func (r *TemplateRepository) Create(ctx context.Context, template *models.Template) error {
+	SetTemplateIndexKeys(template)
+
 	item, err := attributevalue.MarshalMap(template)
 	if err != nil {
 		return err
@@ -128,6 +163,28 @@ func (r *TemplateRepository) Create(ctx context.Context, template *models.Templa
 	return err
 }
 
+// SetTemplateIndexKeys calcula las claves de GSI1 y GSI2 a partir del tipo, externalId y estado.
+// Debe llamarse cada vez que cambie alguno de esos campos para mantener los índices consistentes.
+func SetTemplateIndexKeys(template *models.Template) {
+	typeKey := templateTypeKey(template.Type)
+
+	// GSI1 es disperso: las plantillas sin externalId (ej: SMS) no se indexan,
+	// DynamoDB no permite strings vacíos en claves de índices
+	template.GSI1PK = ""
+	template.GSI1SK = ""
+	if template.ExternalID != "" {
+		template.GSI1PK = typeKey
+		template.GSI1SK = "EXTERNAL#" + template.ExternalID
+	}
+
+	template.GSI2PK = typeKey
+	template.GSI2SK = fmt.Sprintf("ACTIVE#%t#%s", template.Active, template.TemplateID)
+}
+
+func templateTypeKey(templateType string) string {
+	return "TEMPLATE_TYPE#" + templateType
+}
+
 // ValidateTemplateParameters valida que los parámetros proporcionados coincidan con la plantilla
 func (r *TemplateRepository) ValidateTemplateParameters(template *models.Template, providedParams map[string]string) *models.TemplateValidation {
 	validation := &models.TemplateValidation{
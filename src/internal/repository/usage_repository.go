@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -93,6 +94,11 @@ func (r *UsageRepository) Create(ctx context.Context, usage *models.Usage) error
 }
 
 func (r *UsageRepository) IncrementUsage(ctx context.Context, businessID, usageSK string) error {
+	return r.IncrementUsageBy(ctx, businessID, usageSK, 1)
+}
+
+// IncrementUsageBy suma n unidades al contador del período (ej: un email con varios destinatarios)
+func (r *UsageRepository) IncrementUsageBy(ctx context.Context, businessID, usageSK string, n int) error {
 	_, err := r.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
 		TableName: aws.String(r.TableName),
 		Key: map[string]types.AttributeValue{
@@ -101,7 +107,7 @@ func (r *UsageRepository) IncrementUsage(ctx context.Context, businessID, usageS
 		},
 		UpdateExpression: aws.String("ADD notificationCount :inc SET updatedAt = :updatedAt"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":inc":       &types.AttributeValueMemberN{Value: "1"},
+			":inc":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", n)},
 			":updatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339)},
 		},
 	})
@@ -109,6 +115,44 @@ func (r *UsageRepository) IncrementUsage(ctx context.Context, businessID, usageS
 	return err
 }
 
+// ReserveUsage suma n unidades solo si el contador no supera limit; retorna el contador resultante.
+// Reserva la cuota de un lote completo en una sola operación atómica.
+func (r *UsageRepository) ReserveUsage(ctx context.Context, businessID, usageSK string, n, limit int) (int, error) {
+	out, err := r.Client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			"SK": &types.AttributeValueMemberS{Value: usageSK},
+		},
+		UpdateExpression:    aws.String("ADD notificationCount :inc SET updatedAt = :updatedAt"),
+		ConditionExpression: aws.String("notificationCount <= :max"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":inc":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", n)},
+			":max":       &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", limit-n)},
+			":updatedAt": &types.AttributeValueMemberS{Value: time.Now().Format(time.RFC3339)},
+		},
+		ReturnValues: types.ReturnValueUpdatedNew,
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return 0, fmt.Errorf("notification limit reached")
+		}
+		return 0, err
+	}
+
+	var count int
+	if value, ok := out.Attributes["notificationCount"].(*types.AttributeValueMemberN); ok {
+		fmt.Sscanf(value.Value, "%d", &count)
+	}
+	return count, nil
+}
+
+// ReleaseUsage devuelve n unidades reservadas que no se usaron (ej: envíos fallidos de un lote)
+func (r *UsageRepository) ReleaseUsage(ctx context.Context, businessID, usageSK string, n int) error {
+	return r.IncrementUsageBy(ctx, businessID, usageSK, -n)
+}
+
 func (r *UsageRepository) CheckAndCreateNewPeriod(ctx context.Context, businessID, planID string, periodDays int) (*models.Usage, error) {
 	// Intentar obtener el uso actual
 	currentUsage, err := r.GetCurrentUsage(ctx, businessID)
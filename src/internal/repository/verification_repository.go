@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"notify-backend/internal/models"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+type VerificationRepository struct {
+	Client    *dynamodb.Client
+	TableName string
+}
+
+func NewVerificationRepository(client *dynamodb.Client, tableName string) *VerificationRepository {
+	return &VerificationRepository{
+		Client:    client,
+		TableName: tableName,
+	}
+}
+
+// Get obtiene la verificación de un destinatario (lectura consistente: los intentos se cuentan sobre el último estado)
+func (r *VerificationRepository) Get(ctx context.Context, businessID, to string) (*models.Verification, error) {
+	out, err := r.Client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.TableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: "BUSINESS#" + businessID},
+			"SK": &types.AttributeValueMemberS{Value: "VERIFICATION#" + to},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Item == nil {
+		return nil, fmt.Errorf("verification not found")
+	}
+
+	var verification models.Verification
+	if err := attributevalue.UnmarshalMap(out.Item, &verification); err != nil {
+		return nil, err
+	}
+
+	return &verification, nil
+}
+
+// Save guarda la verificación solo si nadie la modificó desde que se leyó (expectedVersion; 0 si es nueva)
+func (r *VerificationRepository) Save(ctx context.Context, verification *models.Verification, expectedVersion int) error {
+	verification.Version = expectedVersion + 1
+
+	item, err := attributevalue.MarshalMap(verification)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.Client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(r.TableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: fmt.Sprintf("%d", expectedVersion)},
+		},
+	})
+	if err != nil {
+		var conditionErr *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionErr) {
+			return fmt.Errorf("verification modified")
+		}
+		return err
+	}
+
+	return nil
+}
@@ -4,12 +4,25 @@ import (
 	"context"
 	"fmt"
 	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
 	"notify-backend/internal/repository"
 	"notify-backend/internal/utils"
+	"strings"
 	"time"
 )
 
-func RegenerateAPIKeyService(email, phone, currentAPIKey string) (string, error) {
+// samePhone compara el teléfono recibido con el registrado, en el formato en que se haya enviado
+// o normalizado con el país por defecto del negocio
+func samePhone(business *models.Business, input string) bool {
+	if business.Phone == input {
+		return true
+	}
+	number, err := phone.Parse(input, business.DefaultCountry)
+	return err == nil && number.E164 == business.Phone
+}
+
+func RegenerateAPIKeyService(email, phoneNumber, currentAPIKey string) (string, error) {
 	client, _ := db.NewDynamoClient()
 	repo := repository.NewBusinessRepository(client, "NotificationService")
 	ctx := context.TODO()
@@ -20,8 +33,8 @@ func RegenerateAPIKeyService(email, phone, currentAPIKey string) (string, error)
 		return "", fmt.Errorf("business not found")
 	}
 
-	// Verificar que el email y phone coincidan
-	if business.Email != email || business.Phone != phone {
+	// Verificar que el email y phone coincidan (el teléfono se compara normalizado a E.164)
+	if business.Email != email || !samePhone(business, phoneNumber) {
 		return "", fmt.Errorf("invalid credentials")
 	}
 
@@ -51,16 +64,151 @@ func GetBusinessInfoService(apiKey string) (*BusinessInfo, error) {
 		return nil, fmt.Errorf("authentication failed")
 	}
 
-	info := &BusinessInfo{
-		IDBusiness: business.PK[9:], // Remover "BUSINESS#"
-		Name:       business.Name,
-		Email:      business.Email,
-		Phone:      business.Phone,
-		PlanID:     business.PlanID,
-		CreatedAt:  business.CreatedAt,
+	return newBusinessInfo(business), nil
+}
+
+// UpdateAccountSettingsRequest usa punteros para distinguir un campo omitido (sin cambios)
+// de uno enviado vacío (volver al valor por defecto de la plataforma)
+type UpdateAccountSettingsRequest struct {
+	EmailProvider       *string `json:"email_provider"`
+	DefaultSenderDomain *string `json:"default_sender_domain"`
+	WebhookURL          *string `json:"webhook_url"`
+	DefaultCountry      *string `json:"default_country"`
+
+	// Políticas de países de destino por canal (sms, whatsapp); null elimina la del canal
+	CountryPolicies map[string]*models.CountryPolicy `json:"country_policies"`
+
+	// Horario de envío de SMS y WhatsApp; start y end vacíos lo eliminan
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+}
+
+func UpdateAccountSettingsService(apiKey string, req UpdateAccountSettingsRequest) (*BusinessInfo, error) {
+	client, _ := db.NewDynamoClient()
+	repo := repository.NewBusinessRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := repo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	settings := map[string]interface{}{}
+
+	if req.EmailProvider != nil {
+		provider := strings.ToLower(strings.TrimSpace(*req.EmailProvider))
+		if provider != "" && !IsValidEmailProvider(provider) {
+			return nil, fmt.Errorf("invalid email provider")
+		}
+		settings["emailProvider"] = provider
+		business.EmailProvider = provider
+	}
+
+	if req.DefaultSenderDomain != nil {
+		domain := strings.TrimSpace(*req.DefaultSenderDomain)
+		if domain != "" {
+			domain, err = normalizeDomain(domain)
+			if err != nil {
+				return nil, err
+			}
+			domainRepo := repository.NewSenderDomainRepository(client, "NotificationService")
+			senderDomain, err := domainRepo.GetByDomain(ctx, business.PK[9:], domain)
+			if err != nil || !senderDomain.Verified {
+				return nil, fmt.Errorf("sender not verified")
+			}
+		}
+		settings["defaultSenderDomain"] = domain
+		business.DefaultSenderDomain = domain
 	}
 
-	return info, nil
+	if req.WebhookURL != nil {
+		webhookURL := strings.TrimSpace(*req.WebhookURL)
+		if webhookURL != "" {
+			if err := validateWebhookURL(webhookURL); err != nil {
+				return nil, err
+			}
+			// El secreto se genera una sola vez; cambiar la URL no lo rota
+			if business.WebhookSecret == "" {
+				secret, err := generateWebhookSecret()
+				if err != nil {
+					return nil, fmt.Errorf("service unavailable")
+				}
+				settings["webhookSecret"] = secret
+				business.WebhookSecret = secret
+			}
+		} else {
+			settings["webhookSecret"] = ""
+			business.WebhookSecret = ""
+		}
+		settings["webhookUrl"] = webhookURL
+		business.WebhookURL = webhookURL
+	}
+
+	if req.DefaultCountry != nil {
+		country := strings.ToUpper(strings.TrimSpace(*req.DefaultCountry))
+		if country != "" && !phone.IsSupportedCountry(country) {
+			return nil, fmt.Errorf("invalid country")
+		}
+		settings["defaultCountry"] = country
+		business.DefaultCountry = country
+	}
+
+	if req.CountryPolicies != nil {
+		policies := map[string]models.CountryPolicy{}
+		for channel, policy := range business.CountryPolicies {
+			policies[channel] = policy
+		}
+		for channel, policy := range req.CountryPolicies {
+			if channel != models.CountryPolicyChannelSMS && channel != models.CountryPolicyChannelWhatsApp {
+				return nil, fmt.Errorf("invalid channel")
+			}
+			if policy == nil {
+				delete(policies, channel)
+				continue
+			}
+			normalized, err := normalizeCountryPolicy(*policy)
+			if err != nil {
+				return nil, err
+			}
+			if len(normalized.Allowed) == 0 && len(normalized.Denied) == 0 {
+				delete(policies, channel)
+				continue
+			}
+			policies[channel] = normalized
+		}
+
+		if len(policies) > 0 {
+			settings["countryPolicies"] = policies
+		} else {
+			settings["countryPolicies"] = nil
+			policies = nil
+		}
+		business.CountryPolicies = policies
+	}
+
+	if req.DeliveryWindow != nil {
+		var window *models.DeliveryWindow
+		if strings.TrimSpace(req.DeliveryWindow.Start) != "" || strings.TrimSpace(req.DeliveryWindow.End) != "" {
+			window, err = normalizeDeliveryWindow(req.DeliveryWindow)
+			if err != nil {
+				return nil, err
+			}
+			settings["deliveryWindow"] = window
+		} else {
+			settings["deliveryWindow"] = nil
+		}
+		business.DeliveryWindow = window
+	}
+
+	if len(settings) == 0 {
+		return nil, fmt.Errorf("no settings to update")
+	}
+
+	if err := repo.UpdateSettings(ctx, business.PK, settings, time.Now().Format(time.RFC3339)); err != nil {
+		fmt.Printf("Failed to update settings: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	return newBusinessInfo(business), nil
 }
 
 type BusinessInfo struct {
@@ -70,4 +218,33 @@ type BusinessInfo struct {
 	Phone      string `json:"phone"`
 	PlanID     string `json:"plan_id"`
 	CreatedAt  string `json:"created_at"`
+
+	EmailProvider       string `json:"email_provider,omitempty"`
+	DefaultSenderDomain string `json:"default_sender_domain,omitempty"`
+	WebhookURL          string `json:"webhook_url,omitempty"`
+	WebhookSecret       string `json:"webhook_secret,omitempty"` // Para validar X-Notify-Signature
+	DefaultCountry      string `json:"default_country,omitempty"`
+
+	CountryPolicies map[string]models.CountryPolicy `json:"country_policies,omitempty"`
+	DeliveryWindow  *models.DeliveryWindow          `json:"delivery_window,omitempty"`
+}
+
+func newBusinessInfo(business *models.Business) *BusinessInfo {
+	return &BusinessInfo{
+		IDBusiness: business.PK[9:], // Remover "BUSINESS#"
+		Name:       business.Name,
+		Email:      business.Email,
+		Phone:      business.Phone,
+		PlanID:     business.PlanID,
+		CreatedAt:  business.CreatedAt,
+
+		EmailProvider:       business.EmailProvider,
+		DefaultSenderDomain: business.DefaultSenderDomain,
+		WebhookURL:          business.WebhookURL,
+		WebhookSecret:       business.WebhookSecret,
+		DefaultCountry:      business.DefaultCountry,
+
+		CountryPolicies: business.CountryPolicies,
+		DeliveryWindow:  business.DeliveryWindow,
+	}
 }
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/models"
+	"notify-backend/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+)
+
+// Respuestas a un recordatorio que pide confirmación. YES y CANCEL también son palabras clave (START y STOP):
+// solo cuentan como respuesta si el remitente tiene un recordatorio esperando confirmación.
+// En WhatsApp, los botones de respuesta rápida de la plantilla envían su payload (CONFIRM, CANCEL).
+var (
+	confirmReplies = map[string]bool{"1": true, "SI": true, "SÍ": true, "YES": true, "CONFIRMAR": true, "CONFIRMO": true, "CONFIRM": true}
+	cancelReplies  = map[string]bool{"2": true, "NO": true, "CANCEL": true, "CANCELAR": true, "CANCELO": true}
+)
+
+// maxAppointmentUpdateAttempts son las lecturas y escrituras de la cita al aplicar una respuesta
+// antes de descartarla por cambios simultáneos
+const maxAppointmentUpdateAttempts = 3
+
+// AppointmentReplyEvent es el evento appointment.confirmed / appointment.cancelled enviado al webhook del negocio
+type AppointmentReplyEvent struct {
+	Appointment    AppointmentResponse `json:"appointment"`
+	Channel        string              `json:"channel"`
+	From           string              `json:"from"`
+	MessageSID     string              `json:"message_sid"`     // Respuesta del destinatario
+	NotificationID string              `json:"notification_id"` // Recordatorio respondido
+}
+
+// detectAppointmentReply retorna confirmed o cancelled si la respuesta (payload del botón o texto) es una de las esperadas
+func detectAppointmentReply(buttonPayload, body string) string {
+	for _, value := range []string{buttonPayload, body} {
+		word := strings.ToUpper(strings.Trim(strings.TrimSpace(value), ".!¡"))
+		if word == "" {
+			continue
+		}
+		if confirmReplies[word] {
+			return models.AppointmentConfirmationConfirmed
+		}
+		if cancelReplies[word] {
+			return models.AppointmentConfirmationCancelled
+		}
+	}
+	return ""
+}
+
+// recordAppointmentReminderSent asocia el recordatorio enviado con la cita y, si la cita pide confirmación,
+// deja al destinatario esperando respuesta. Los errores solo se registran: el recordatorio ya fue enviado.
+func recordAppointmentReminderSent(ctx context.Context, client *dynamodb.Client, notification *models.Notification, dispatchedID string) {
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+	appointmentRepo := repository.NewAppointmentRepository(client, "NotificationService")
+
+	// Las respuestas rápidas de WhatsApp indican el mensaje respondido (OriginalRepliedMessageSid)
+	if err := notificationRepo.SetAppointment(ctx, dispatchedID, notification.AppointmentID); err != nil {
+		fmt.Printf("Failed to link reminder %s: %v\n", dispatchedID, err)
+	}
+
+	appointment, err := appointmentRepo.AwaitConfirmation(ctx, notification.BusinessID, notification.AppointmentID)
+	if err != nil {
+		if err.Error() != "appointment not awaiting confirmation" {
+			fmt.Printf("Failed to update appointment %s: %v\n", notification.AppointmentID, err)
+		}
+		return
+	}
+
+	if len(notification.Recipients) == 0 {
+		return
+	}
+	err = appointmentRepo.PutReplyLink(ctx, &models.AppointmentReplyLink{
+		PK:             "APPOINTMENT_REPLY#" + notification.Channel + "#" + notification.Recipients[0],
+		SK:             "BUSINESS#" + notification.BusinessID,
+		AppointmentID:  appointment.AppointmentID,
+		NotificationID: dispatchedID,
+		ExpiresAt:      appointment.StartAt,
+		CreatedAt:      time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		fmt.Printf("Failed to store reply link for appointment %s: %v\n", appointment.AppointmentID, err)
+	}
+}
+
+// appointmentReply es la respuesta de un mensaje entrante a un recordatorio de cita
+type appointmentReply struct {
+	Answer         string // confirmed, cancelled
+	AppointmentID  string
+	NotificationID string // Recordatorio respondido
+}
+
+// findAppointmentReply retorna la respuesta si el mensaje responde a un recordatorio de una cita que sigue
+// esperando confirmación; nil en otro caso. Solo lee: se evalúa antes que las palabras clave.
+func findAppointmentReply(ctx context.Context, client *dynamodb.Client, message *models.InboundMessage, buttonPayload, repliedSID string) *appointmentReply {
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+	appointmentRepo := repository.NewAppointmentRepository(client, "NotificationService")
+
+	answer := detectAppointmentReply(buttonPayload, message.Body)
+	if answer == "" {
+		return nil
+	}
+
+	// 1. Respuesta a un mensaje concreto (WhatsApp); 2. último recordatorio enviado al teléfono
+	reply := &appointmentReply{Answer: answer}
+	if repliedSID != "" {
+		if notification, err := notificationRepo.GetByID(ctx, repliedSID); err == nil && notification.BusinessID == message.BusinessID {
+			reply.AppointmentID, reply.NotificationID = notification.AppointmentID, notification.NotificationID
+		}
+	}
+	if reply.AppointmentID == "" {
+		link, err := appointmentRepo.GetReplyLink(ctx, message.Channel, message.From, message.BusinessID)
+		if err != nil {
+			if err.Error() != "reply link not found" {
+				fmt.Printf("Failed to get reply link for %s: %v\n", message.From, err)
+			}
+			return nil
+		}
+		if expiresAt, err := time.Parse(time.RFC3339, link.ExpiresAt); err != nil || time.Now().After(expiresAt) {
+			return nil
+		}
+		reply.AppointmentID, reply.NotificationID = link.AppointmentID, link.NotificationID
+	}
+	if reply.AppointmentID == "" {
+		return nil
+	}
+
+	appointment, err := appointmentRepo.GetByID(ctx, message.BusinessID, reply.AppointmentID)
+	if err != nil {
+		if err.Error() != "appointment not found" {
+			fmt.Printf("Failed to get appointment %s: %v\n", reply.AppointmentID, err)
+		}
+		return nil
+	}
+	if appointment.Status != models.AppointmentStatusScheduled || !appointment.RequestConfirmation {
+		return nil
+	}
+	if startAt, _, err := appointmentStart(appointment); err == nil && !startAt.After(time.Now()) {
+		return nil
+	}
+
+	return reply
+}
+
+// handleAppointmentReply aplica la respuesta de un destinatario a un recordatorio de cita y avisa al negocio.
+// Retorna el texto de la respuesta automática (vacío si la cita ya no espera confirmación).
+func handleAppointmentReply(ctx context.Context, client *dynamodb.Client, business *models.Business, message *models.InboundMessage, reply *appointmentReply) string {
+	appointmentRepo := repository.NewAppointmentRepository(client, "NotificationService")
+	answer, appointmentID, notificationID := reply.Answer, reply.AppointmentID, reply.NotificationID
+
+	// La cita se vuelve a leer si otro cambio (PATCH, otro recordatorio enviado) la modificó entre la lectura y la escritura
+	var appointment *models.Appointment
+	date, changed := "", false
+	for attempt := 1; ; attempt++ {
+		var err error
+		appointment, err = appointmentRepo.GetByID(ctx, message.BusinessID, appointmentID)
+		if err != nil {
+			fmt.Printf("Failed to get appointment %s: %v\n", appointmentID, err)
+			return ""
+		}
+		if appointment.Status != models.AppointmentStatusScheduled || !appointment.RequestConfirmation {
+			return ""
+		}
+
+		if startAt, loc, err := appointmentStart(appointment); err == nil {
+			if !startAt.After(time.Now()) {
+				return ""
+			}
+			date = formatAppointmentDate(startAt, loc)
+		}
+
+		// Repetir la misma respuesta no genera otro evento
+		if appointment.Confirmation == answer {
+			break
+		}
+
+		now := time.Now().UTC().Format(time.RFC3339)
+		appointment.Confirmation = answer
+		appointment.ConfirmationAt = now
+		appointment.UpdatedAt = now
+		if answer == models.AppointmentConfirmationCancelled {
+			appointment.Status = models.AppointmentStatusCancelled
+			appointment.CancelledAt = now
+		}
+
+		err = appointmentRepo.Update(ctx, appointment, appointment.Version)
+		if err == nil {
+			changed = true
+			break
+		}
+		if err.Error() != "appointment modified" || attempt == maxAppointmentUpdateAttempts {
+			fmt.Printf("Failed to update appointment %s: %v\n", appointmentID, err)
+			return ""
+		}
+	}
+
+	if changed {
+		// Los recordatorios se cancelan después de guardar: son los de la versión que quedó cancelada
+		if answer == models.AppointmentConfirmationCancelled {
+			cancelAppointmentReminders(business.APIKey, appointment.Reminders)
+		}
+
+		deliverWebhook(business, "appointment."+answer, AppointmentReplyEvent{
+			Appointment:    newAppointmentResponse(appointment),
+			Channel:        message.Channel,
+			From:           message.From,
+			MessageSID:     message.MessageSID,
+			NotificationID: notificationID,
+		})
+	}
+
+	if answer == models.AppointmentConfirmationCancelled {
+		return fmt.Sprintf("%s: cancelamos tu %s del %s.", business.Name, appointment.ServiceName, date)
+	}
+	return fmt.Sprintf("%s: confirmamos tu %s del %s. ¡Te esperamos!", business.Name, appointment.ServiceName, date)
+}
@@ -0,0 +1,606 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/repository"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/uuid"
+)
+
+// Plantillas de recordatorio por defecto. WhatsApp: "tu {{3}} está programado para {{4}}";
+// SMS: "tu $service es el $date. Responde 1 para confirmar o 2 para cancelar"
+const (
+	appointmentDefaultTemplate    = "recordatorio_general"
+	appointmentDefaultSMSTemplate = "sms_recordatorio_cita"
+)
+
+// maxAppointmentReminders limita los recordatorios por cita
+const maxAppointmentReminders = 5
+
+// appointmentDefaultReminders se usan cuando la cita se crea sin reminders
+var appointmentDefaultReminders = []string{"24h", "2h"}
+
+var spanishMonths = []string{
+	"enero", "febrero", "marzo", "abril", "mayo", "junio",
+	"julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre",
+}
+
+// AppointmentRequest crea o actualiza una cita. En PATCH los campos omitidos no cambian;
+// parameters y reminders se reemplazan completos.
+type AppointmentRequest struct {
+	Channel      *string           `json:"channel"` // whatsapp (por defecto), sms
+	To           *string           `json:"to"`
+	ContactID    *string           `json:"contact_id"` // En lugar de to
+	TemplateID   *string           `json:"template_id"`
+	CustomerName *string           `json:"customer_name"`
+	ServiceName  *string           `json:"service_name"`
+	StartAt      *string           `json:"start_at"` // RFC3339
+	Timezone     *string           `json:"timezone"` // IANA; por defecto la del contacto o UTC
+	Parameters   map[string]string `json:"parameters"`
+	Reminders    []string          `json:"reminders"` // Anticipación respecto al inicio: "24h", "2h", "30m"
+
+	RequestConfirmation *bool `json:"request_confirmation"` // Aceptar respuestas 1 (confirmar) y 2 (cancelar)
+}
+
+type AppointmentResponse struct {
+	AppointmentID string                        `json:"appointment_id"`
+	Status        string                        `json:"status"`
+	Channel       string                        `json:"channel"`
+	To            string                        `json:"to,omitempty"`
+	ContactID     string                        `json:"contact_id,omitempty"`
+	TemplateID    string                        `json:"template_id"`
+	CustomerName  string                        `json:"customer_name,omitempty"`
+	ServiceName   string                        `json:"service_name"`
+	StartAt       string                        `json:"start_at"` // En la zona horaria de la cita
+	Timezone      string                        `json:"timezone"`
+	Parameters    map[string]string             `json:"parameters,omitempty"`
+	Reminders     []AppointmentReminderResponse `json:"reminders"`
+
+	RequestConfirmation bool   `json:"request_confirmation"`
+	Confirmation        string `json:"confirmation,omitempty"` // pending, confirmed, cancelled
+	ConfirmationAt      string `json:"confirmation_at,omitempty"`
+
+	CreatedAt   string `json:"created_at"`
+	UpdatedAt   string `json:"updated_at"`
+	CancelledAt string `json:"cancelled_at,omitempty"`
+}
+
+type AppointmentReminderResponse struct {
+	Before         string `json:"before"`
+	SendAt         string `json:"send_at"`
+	Status         string `json:"status"`                    // scheduled, skipped, cancelled
+	NotificationID string `json:"notification_id,omitempty"` // Resultado del envío en GET /v1/notifications/{id}
+}
+
+type ListAppointmentsResponse struct {
+	Appointments []AppointmentResponse `json:"appointments"`
+	NextCursor   string                `json:"next_cursor,omitempty"`
+}
+
+func newAppointmentResponse(appointment *models.Appointment) AppointmentResponse {
+	resp := AppointmentResponse{
+		AppointmentID: appointment.AppointmentID,
+		Status:        appointment.Status,
+		Channel:       appointment.Channel,
+		To:            appointment.To,
+		ContactID:     appointment.ContactID,
+		TemplateID:    appointment.TemplateID,
+		CustomerName:  appointment.CustomerName,
+		ServiceName:   appointment.ServiceName,
+		StartAt:       appointment.StartAt,
+		Timezone:      appointment.Timezone,
+		Parameters:    appointment.Parameters,
+		Reminders:     make([]AppointmentReminderResponse, 0, len(appointment.Reminders)),
+
+		RequestConfirmation: appointment.RequestConfirmation,
+		Confirmation:        appointment.Confirmation,
+		ConfirmationAt:      appointment.ConfirmationAt,
+
+		CreatedAt:   appointment.CreatedAt,
+		UpdatedAt:   appointment.UpdatedAt,
+		CancelledAt: appointment.CancelledAt,
+	}
+	if startAt, loc, err := appointmentStart(appointment); err == nil {
+		resp.StartAt = startAt.In(loc).Format(time.RFC3339)
+	}
+	for _, reminder := range appointment.Reminders {
+		resp.Reminders = append(resp.Reminders, AppointmentReminderResponse{
+			Before:         reminder.Before,
+			SendAt:         reminder.SendAt,
+			Status:         reminder.Status,
+			NotificationID: reminder.NotificationID,
+		})
+	}
+	return resp
+}
+
+func CreateAppointmentService(apiKey string, req AppointmentRequest) (*AppointmentResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	appointmentRepo := repository.NewAppointmentRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	if req.Reminders == nil {
+		req.Reminders = appointmentDefaultReminders
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	appointmentID := uuid.New().String()
+	appointment := &models.Appointment{
+		PK:            business.PK,
+		SK:            "APPOINTMENT#" + appointmentID,
+		AppointmentID: appointmentID,
+		Status:        models.AppointmentStatusScheduled,
+		Channel:       NotificationTypeWhatsApp,
+		CreatedAt:     now,
+	}
+	if err := applyAppointmentRequest(ctx, client, appointment, business, req, now); err != nil {
+		return nil, err
+	}
+
+	if err := scheduleAppointmentReminders(ctx, client, apiKey, business, appointment); err != nil {
+		return nil, err
+	}
+
+	if err := appointmentRepo.Create(ctx, appointment); err != nil {
+		fmt.Printf("Failed to create appointment: %v\n", err)
+		cancelAppointmentReminders(apiKey, appointment.Reminders)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := newAppointmentResponse(appointment)
+	return &resp, nil
+}
+
+func GetAppointmentService(apiKey, appointmentID string) (*AppointmentResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	appointmentRepo := repository.NewAppointmentRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	appointment, err := getAppointment(ctx, appointmentRepo, business.PK[9:], appointmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := newAppointmentResponse(appointment)
+	return &resp, nil
+}
+
+// UpdateAppointmentService aplica los cambios y reemplaza los recordatorios pendientes:
+// los nuevos se programan antes de cancelar los anteriores, así un error deja la cita como estaba.
+func UpdateAppointmentService(apiKey, appointmentID string, req AppointmentRequest) (*AppointmentResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	appointmentRepo := repository.NewAppointmentRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	appointment, err := getAppointment(ctx, appointmentRepo, business.PK[9:], appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if appointment.Status == models.AppointmentStatusCancelled {
+		return nil, fmt.Errorf("appointment cancelled")
+	}
+
+	if req.Reminders == nil {
+		for _, reminder := range appointment.Reminders {
+			req.Reminders = append(req.Reminders, reminder.Before)
+		}
+	}
+
+	previous, version := appointment.Reminders, appointment.Version
+	now := time.Now().UTC().Format(time.RFC3339)
+	if err := applyAppointmentRequest(ctx, client, appointment, business, req, now); err != nil {
+		return nil, err
+	}
+
+	if err := scheduleAppointmentReminders(ctx, client, apiKey, business, appointment); err != nil {
+		return nil, err
+	}
+
+	// Si la cita cambió mientras tanto (otro PATCH o la respuesta del destinatario) los nuevos recordatorios se cancelan
+	if err := appointmentRepo.Update(ctx, appointment, version); err != nil {
+		cancelAppointmentReminders(apiKey, appointment.Reminders)
+		if err.Error() == "appointment modified" {
+			return nil, err
+		}
+		fmt.Printf("Failed to update appointment %s: %v\n", appointmentID, err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	cancelAppointmentReminders(apiKey, previous)
+
+	resp := newAppointmentResponse(appointment)
+	return &resp, nil
+}
+
+// CancelAppointmentService cancela la cita y sus recordatorios pendientes (la cuota reservada se libera)
+func CancelAppointmentService(apiKey, appointmentID string) (*AppointmentResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	appointmentRepo := repository.NewAppointmentRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	appointment, err := getAppointment(ctx, appointmentRepo, business.PK[9:], appointmentID)
+	if err != nil {
+		return nil, err
+	}
+	if appointment.Status == models.AppointmentStatusCancelled {
+		return nil, fmt.Errorf("appointment cancelled")
+	}
+
+	// Los recordatorios se cancelan después de guardar: si la cita cambió mientras tanto no se toca nada
+	now := time.Now().UTC().Format(time.RFC3339)
+	appointment.Status = models.AppointmentStatusCancelled
+	appointment.UpdatedAt = now
+	appointment.CancelledAt = now
+	if err := appointmentRepo.Update(ctx, appointment, appointment.Version); err != nil {
+		if err.Error() == "appointment modified" {
+			return nil, err
+		}
+		fmt.Printf("Failed to cancel appointment %s: %v\n", appointmentID, err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	cancelAppointmentReminders(apiKey, appointment.Reminders)
+
+	resp := newAppointmentResponse(appointment)
+	return &resp, nil
+}
+
+func ListAppointmentsService(apiKey string, limit int32, cursor string) (*ListAppointmentsResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	appointmentRepo := repository.NewAppointmentRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	appointments, nextCursor, err := appointmentRepo.ListPage(ctx, business.PK[9:], limit, cursor)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return nil, err
+		}
+		fmt.Printf("Failed to list appointments: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := &ListAppointmentsResponse{
+		Appointments: make([]AppointmentResponse, 0, len(appointments)),
+		NextCursor:   nextCursor,
+	}
+	for _, appointment := range appointments {
+		resp.Appointments = append(resp.Appointments, newAppointmentResponse(appointment))
+	}
+
+	return resp, nil
+}
+
+func getAppointment(ctx context.Context, appointmentRepo *repository.AppointmentRepository, businessID, appointmentID string) (*models.Appointment, error) {
+	appointment, err := appointmentRepo.GetByID(ctx, businessID, appointmentID)
+	if err != nil {
+		if err.Error() == "appointment not found" {
+			return nil, err
+		}
+		fmt.Printf("Failed to get appointment %s: %v\n", appointmentID, err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	return appointment, nil
+}
+
+// applyAppointmentRequest valida y aplica los campos enviados. Los recordatorios quedan
+// sin programar (ver scheduleAppointmentReminders).
+func applyAppointmentRequest(ctx context.Context, client *dynamodb.Client, appointment *models.Appointment, business *models.Business, req AppointmentRequest, now string) error {
+	if req.Channel != nil {
+		appointment.Channel = strings.TrimSpace(*req.Channel)
+	}
+	if appointment.Channel != NotificationTypeWhatsApp && appointment.Channel != NotificationTypeSMS {
+		return fmt.Errorf("invalid channel")
+	}
+
+	if req.To != nil {
+		appointment.To = strings.TrimSpace(*req.To)
+		if appointment.To != "" && req.ContactID == nil {
+			appointment.ContactID = ""
+		}
+	}
+	if req.ContactID != nil {
+		appointment.ContactID = strings.TrimSpace(*req.ContactID)
+		if appointment.ContactID != "" && req.To == nil {
+			appointment.To = ""
+		}
+	}
+	if appointment.To == "" && appointment.ContactID == "" {
+		return fmt.Errorf("to or contact_id is required")
+	}
+	if appointment.To != "" && appointment.ContactID != "" {
+		return fmt.Errorf("contact_id cannot be combined with to")
+	}
+
+	if req.TemplateID != nil {
+		appointment.TemplateID = strings.TrimSpace(*req.TemplateID)
+	}
+	if appointment.TemplateID == "" {
+		appointment.TemplateID = appointmentDefaultTemplate
+		if appointment.Channel == NotificationTypeSMS {
+			appointment.TemplateID = appointmentDefaultSMSTemplate
+		}
+	}
+
+	if req.CustomerName != nil {
+		appointment.CustomerName = strings.TrimSpace(*req.CustomerName)
+	}
+	if req.ServiceName != nil {
+		appointment.ServiceName = strings.TrimSpace(*req.ServiceName)
+	}
+	if appointment.ServiceName == "" {
+		return fmt.Errorf("service_name is required")
+	}
+
+	if req.StartAt != nil {
+		startAt, err := time.Parse(time.RFC3339, strings.TrimSpace(*req.StartAt))
+		if err != nil || !startAt.After(time.Now()) {
+			return fmt.Errorf("invalid start_at")
+		}
+		// Una cita movida se vuelve a confirmar con los nuevos recordatorios
+		if value := startAt.UTC().Format(time.RFC3339); value != appointment.StartAt {
+			appointment.StartAt = value
+			appointment.Confirmation = ""
+			appointment.ConfirmationAt = ""
+		}
+	}
+	if appointment.StartAt == "" {
+		return fmt.Errorf("invalid start_at")
+	}
+
+	if req.Timezone != nil {
+		appointment.Timezone = strings.TrimSpace(*req.Timezone)
+	}
+
+	// Con contact_id, la zona horaria y el nombre por defecto son los del contacto
+	if appointment.ContactID != "" && (appointment.Timezone == "" || appointment.CustomerName == "") {
+		contactRepo := repository.NewContactRepository(client, "NotificationService")
+		contact, err := getContact(ctx, contactRepo, business.PK[9:], appointment.ContactID)
+		if err != nil {
+			return err
+		}
+		if appointment.Timezone == "" {
+			appointment.Timezone = contact.Timezone
+		}
+		if appointment.CustomerName == "" {
+			appointment.CustomerName = contact.Name
+		}
+	}
+	if appointment.Timezone == "" {
+		appointment.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(appointment.Timezone); err != nil || appointment.Timezone == "Local" {
+		return fmt.Errorf("invalid timezone")
+	}
+
+	if req.Parameters != nil {
+		appointment.Parameters = req.Parameters
+	}
+	if req.RequestConfirmation != nil {
+		appointment.RequestConfirmation = *req.RequestConfirmation
+	}
+
+	offsets, err := parseAppointmentReminders(req.Reminders)
+	if err != nil {
+		return err
+	}
+	appointment.Reminders = make([]models.AppointmentReminder, 0, len(offsets))
+	for _, before := range offsets {
+		appointment.Reminders = append(appointment.Reminders, models.AppointmentReminder{Before: before})
+	}
+
+	appointment.UpdatedAt = now
+	return nil
+}
+
+// parseAppointmentReminders valida las anticipaciones (duraciones de Go en minutos enteros, ej: 24h, 1h30m)
+// y las ordena de mayor a menor, es decir, en orden de envío
+func parseAppointmentReminders(values []string) ([]string, error) {
+	if len(values) > maxAppointmentReminders {
+		return nil, fmt.Errorf("too many reminders")
+	}
+
+	durations := make([]time.Duration, 0, len(values))
+	for _, value := range values {
+		before, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || before < time.Minute || before > maxScheduleAhead || before%time.Minute != 0 {
+			return nil, fmt.Errorf("invalid reminders")
+		}
+		for _, existing := range durations {
+			if existing == before {
+				return nil, fmt.Errorf("invalid reminders")
+			}
+		}
+		durations = append(durations, before)
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] > durations[j] })
+
+	offsets := make([]string, 0, len(durations))
+	for _, before := range durations {
+		offsets = append(offsets, formatReminderOffset(before))
+	}
+	return offsets, nil
+}
+
+// formatReminderOffset muestra la anticipación sin unidades vacías (24h en lugar de 24h0m0s)
+func formatReminderOffset(before time.Duration) string {
+	value := ""
+	if hours := before / time.Hour; hours > 0 {
+		value = fmt.Sprintf("%dh", hours)
+	}
+	if minutes := (before % time.Hour) / time.Minute; minutes > 0 {
+		value += fmt.Sprintf("%dm", minutes)
+	}
+	return value
+}
+
+func appointmentStart(appointment *models.Appointment) (time.Time, *time.Location, error) {
+	startAt, err := time.Parse(time.RFC3339, appointment.StartAt)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	loc, err := time.LoadLocation(appointment.Timezone)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	return startAt, loc, nil
+}
+
+// formatAppointmentDate escribe la fecha de la cita en la zona horaria del cliente ("1 de diciembre a las 10:00 AM")
+func formatAppointmentDate(startAt time.Time, loc *time.Location) string {
+	local := startAt.In(loc)
+	return fmt.Sprintf("%d de %s a las %s", local.Day(), spanishMonths[local.Month()-1], local.Format("3:04 PM"))
+}
+
+// appointmentParameters son los parámetros de la plantilla de recordatorio (company, name, service, date);
+// los parameters de la cita tienen prioridad
+func appointmentParameters(business *models.Business, appointment *models.Appointment, startAt time.Time, loc *time.Location) map[string]string {
+	params := map[string]string{
+		"company": business.Name,
+		"service": appointment.ServiceName,
+		"date":    formatAppointmentDate(startAt, loc),
+	}
+	if appointment.CustomerName != "" {
+		params["name"] = appointment.CustomerName
+	}
+	for key, value := range appointment.Parameters {
+		params[key] = value
+	}
+	return params
+}
+
+// scheduleAppointmentReminders programa cada recordatorio como un envío programado del canal de la cita.
+// Los que ya pasaron se omiten; si uno falla se cancelan los ya programados y se retorna el error del canal.
+func scheduleAppointmentReminders(ctx context.Context, client *dynamodb.Client, apiKey string, business *models.Business, appointment *models.Appointment) error {
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+
+	startAt, loc, err := appointmentStart(appointment)
+	if err != nil {
+		return fmt.Errorf("invalid start_at")
+	}
+
+	now := time.Now()
+	for i := range appointment.Reminders {
+		reminder := &appointment.Reminders[i]
+		before, _ := time.ParseDuration(reminder.Before)
+		sendAt := startAt.Add(-before)
+		reminder.SendAt = sendAt.UTC().Format(time.RFC3339)
+
+		// Un minuto de margen para que send_at siga en el futuro al validarse
+		if !sendAt.After(now.Add(time.Minute)) {
+			reminder.Status = models.AppointmentReminderSkipped
+			continue
+		}
+		if sendAt.After(now.Add(maxScheduleAhead)) {
+			cancelAppointmentReminders(apiKey, appointment.Reminders[:i])
+			return fmt.Errorf("reminder too far in advance")
+		}
+
+		result, err := sendAppointmentReminder(apiKey, business, appointment, startAt, loc, reminder.SendAt)
+		if err != nil {
+			cancelAppointmentReminders(apiKey, appointment.Reminders[:i])
+			return err
+		}
+		notificationID := result.NotificationID
+
+		reminder.Status = models.AppointmentReminderScheduled
+		reminder.NotificationID = notificationID
+
+		// Al enviarse, el recordatorio queda asociado a la cita para recibir la respuesta
+		if err := notificationRepo.SetAppointment(ctx, notificationID, appointment.AppointmentID); err != nil {
+			fmt.Printf("Failed to link reminder %s: %v\n", notificationID, err)
+		}
+	}
+
+	return nil
+}
+
+// sendAppointmentReminder programa un recordatorio y retorna el envío programado. Es transaccional: no espera
+// al horario de envío (no se aplaza más allá de la cita) ni requiere el consentimiento explícito del contacto.
+func sendAppointmentReminder(apiKey string, business *models.Business, appointment *models.Appointment, startAt time.Time, loc *time.Location, sendAt string) (*scheduledNotification, error) {
+	params := appointmentParameters(business, appointment, startAt, loc)
+
+	if appointment.Channel == NotificationTypeSMS {
+		result, err := SendSMSService(apiKey, SendSMSRequest{
+			To:         appointment.To,
+			ContactID:  appointment.ContactID,
+			TemplateID: appointment.TemplateID,
+			Parameters: params,
+			SendAt:     sendAt,
+			Category:   DeliveryCategoryTransactional,
+			timezone:   appointment.Timezone,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &scheduledNotification{NotificationID: result.NotificationID, SendAt: result.SendAt}, nil
+	}
+
+	result, err := SendWhatsAppService(apiKey, SendWhatsAppRequest{
+		To:         appointment.To,
+		ContactID:  appointment.ContactID,
+		TemplateID: appointment.TemplateID,
+		Parameters: params,
+		SendAt:     sendAt,
+		Category:   DeliveryCategoryTransactional,
+		timezone:   appointment.Timezone,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &scheduledNotification{NotificationID: result.NotificationID, SendAt: result.SendAt}, nil
+}
+
+// cancelAppointmentReminders cancela los recordatorios programados que aún no se enviaron y libera su cuota.
+// Los que el worker ya tomó conservan su estado: el resultado está en la notificación.
+func cancelAppointmentReminders(apiKey string, reminders []models.AppointmentReminder) {
+	for i := range reminders {
+		reminder := &reminders[i]
+		if reminder.Status != models.AppointmentReminderScheduled || reminder.NotificationID == "" {
+			continue
+		}
+		if _, err := CancelScheduledNotificationService(apiKey, reminder.NotificationID); err != nil {
+			if err.Error() != "notification not scheduled" {
+				fmt.Printf("Failed to cancel reminder %s: %v\n", reminder.NotificationID, err)
+			}
+			continue
+		}
+		reminder.Status = models.AppointmentReminderCancelled
+	}
+}
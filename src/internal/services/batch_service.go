@@ -0,0 +1,530 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
+	"notify-backend/internal/repository"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+// Límites del envío por lotes
+const (
+	maxBatchRecipients = 100 // Por llamada a la API: el lote debe terminar dentro del timeout de API Gateway (29 s)
+	batchConcurrency   = 10  // Envíos simultáneos al proveedor
+)
+
+// SendBatchRequest envía una plantilla a varios destinatarios.
+// Los parámetros de cada destinatario se combinan con los comunes (y tienen prioridad).
+type SendBatchRequest struct {
+	Type       string            `json:"type"` // sms, whatsapp, email
+	TemplateID string            `json:"template_id"`
+	Parameters map[string]string `json:"parameters"`
+	Recipients []BatchRecipient  `json:"recipients"`
+
+	// Horario de envío (sms, whatsapp): los destinatarios fuera de él se programan para el siguiente horario permitido
+	Category       string                 `json:"category"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+}
+
+type BatchRecipient struct {
+	To         string            `json:"to"`
+	Parameters map[string]string `json:"parameters"`
+}
+
+// BatchRecipientResult es el resultado de un destinatario, en el mismo orden del request
+type BatchRecipientResult struct {
+	Index          int    `json:"index"`
+	To             string `json:"to"`
+	Success        bool   `json:"success"`
+	NotificationID string `json:"notification_id,omitempty"`
+	Error          string `json:"error,omitempty"`
+
+	// Solo si se aplazó por el horario de envío: notification_id es el envío programado
+	Status string `json:"status,omitempty"` // scheduled
+	SendAt string `json:"send_at,omitempty"`
+}
+
+type SendBatchResponse struct {
+	Success           bool                   `json:"success"`
+	Type              string                 `json:"type"`
+	TemplateUsed      string                 `json:"template_used"`
+	Total             int                    `json:"total"`
+	Sent              int                    `json:"sent"`
+	Scheduled         int                    `json:"scheduled"` // Aplazados por el horario de envío
+	Failed            int                    `json:"failed"`
+	NotificationCount int                    `json:"notification_count"`
+	NotificationLeft  int                    `json:"notification_left"`
+	Results           []BatchRecipientResult `json:"results"`
+}
+
+// batchItem es un destinatario validado, listo para enviar
+type batchItem struct {
+	to       string // Teléfono en E.164 o email normalizado
+	units    int    // Unidades de cuota reservadas
+	send     func(ctx context.Context) (notificationID, providerMessageID string, err error)
+	provider string
+
+	number  *phone.Number // sms y whatsapp: para el horario de envío
+	request interface{}   // Petición del canal que se programa si el destinatario está fuera del horario
+	sendAt  time.Time     // Asignado si se aplaza
+
+	providerMessageID string // Asignado al enviar
+}
+
+// batchChannel valida y prepara cada destinatario de un canal
+type batchChannel struct {
+	suppressionChannel string
+	prepare            func(to string, params map[string]string) (*batchItem, error)
+}
+
+func SendBatchService(apiKey string, req SendBatchRequest) (*SendBatchResponse, error) {
+	if req.Type != NotificationTypeSMS && req.Type != NotificationTypeWhatsApp && req.Type != NotificationTypeEmail {
+		return nil, fmt.Errorf("invalid notification type")
+	}
+	if req.TemplateID == "" {
+		return nil, fmt.Errorf("template_id is required")
+	}
+	if len(req.Recipients) == 0 {
+		return nil, fmt.Errorf("recipients is required")
+	}
+	if len(req.Recipients) > maxBatchRecipients {
+		return nil, fmt.Errorf("too many recipients")
+	}
+
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	return sendBatch(ctx, client, business, req)
+}
+
+// sendBatch envía el lote para un negocio ya autenticado (API o campañas)
+func sendBatch(ctx context.Context, client *dynamodb.Client, business *models.Business, req SendBatchRequest) (*SendBatchResponse, error) {
+	planRepo := repository.NewPlanRepository(client, "NotificationService")
+	usageRepo := repository.NewUsageRepository(client, "NotificationService")
+	templateRepo := repository.NewTemplateRepository(client, "NotificationService")
+	suppressionRepo := repository.NewSuppressionRepository(client, "NotificationService")
+	conversationRepo := repository.NewConversationRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+	domainRepo := repository.NewSenderDomainRepository(client, "NotificationService")
+
+	category, err := normalizeDeliveryCategory(req.Category)
+	if err != nil {
+		return nil, err
+	}
+	window, err := normalizeDeliveryWindow(req.DeliveryWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	// Plan y plantilla se consultan una sola vez para todo el lote
+	businessID := business.PK[9:] // Remover "BUSINESS#"
+
+	plan, err := planRepo.GetByID(ctx, business.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	template, err := templateRepo.GetByID(ctx, req.TemplateID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template")
+	}
+	if template.Type != req.Type {
+		return nil, fmt.Errorf("invalid template type")
+	}
+	if !template.Active {
+		return nil, fmt.Errorf("template not available")
+	}
+	if template.TemplateID == "sms_verification_code" {
+		category = DeliveryCategoryOTP
+	}
+
+	var channel *batchChannel
+	switch req.Type {
+	case NotificationTypeSMS:
+		channel, err = smsBatchChannel(business, plan, template, templateRepo)
+	case NotificationTypeWhatsApp:
+		channel, err = whatsAppBatchChannel(business, plan, template, templateRepo)
+	case NotificationTypeEmail:
+		channel, err = emailBatchChannel(ctx, domainRepo, business, template, templateRepo)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 1. Validar todos los destinatarios antes de enviar: los inválidos no se envían ni consumen cuota
+	results := make([]BatchRecipientResult, len(req.Recipients))
+	items := make([]*batchItem, len(req.Recipients))
+	seen := map[string]bool{}
+	for i, recipient := range req.Recipients {
+		results[i] = BatchRecipientResult{Index: i, To: recipient.To}
+
+		params := make(map[string]string, len(req.Parameters)+len(recipient.Parameters))
+		for key, value := range req.Parameters {
+			params[key] = value
+		}
+		for key, value := range recipient.Parameters {
+			params[key] = value
+		}
+
+		item, err := channel.prepare(recipient.To, params)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if seen[item.to] {
+			results[i].Error = "duplicate recipient"
+			continue
+		}
+		seen[item.to] = true
+		results[i].To = item.to
+		items[i] = item
+	}
+
+	// 2. Supresiones de todo el lote en una sola consulta
+	values := []string{}
+	for _, item := range items {
+		if item != nil {
+			values = append(values, item.to)
+		}
+	}
+	suppressed, err := suppressionRepo.FindSuppressed(ctx, businessID, channel.suppressionChannel, values)
+	if err != nil {
+		fmt.Printf("Failed to check suppressions: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	suppressedSet := map[string]bool{}
+	for _, value := range suppressed {
+		suppressedSet[value] = true
+	}
+
+	// Los destinatarios fuera del horario de envío no se envían ahora: se programan después del lote
+	deferred := make([]*batchItem, len(items))
+	units := 0
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+		if suppressedSet[item.to] {
+			results[i].Error = "recipient suppressed: " + item.to
+			items[i] = nil
+			continue
+		}
+		if item.number != nil {
+			locations := recipientLocations("", item.number, business.DefaultCountry)
+			if sendAt, isDeferred := deliveryTime(business, window, category, locations, time.Now()); isDeferred {
+				item.sendAt = sendAt
+				deferred[i] = item
+				items[i] = nil
+				continue
+			}
+		}
+		units += item.units
+	}
+
+	usage, err := usageRepo.CheckAndCreateNewPeriod(ctx, businessID, business.PlanID, plan.PeriodDays)
+	if err != nil {
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	response := &SendBatchResponse{
+		Success:           true,
+		Type:              req.Type,
+		TemplateUsed:      template.Name,
+		Total:             len(req.Recipients),
+		NotificationCount: usage.NotificationCount,
+		Results:           results,
+	}
+
+	if units > 0 {
+		// 3. Reservar la cuota de todo el lote de forma atómica
+		count, err := usageRepo.ReserveUsage(ctx, businessID, usage.SK, units, plan.NotificationLimit)
+		if err != nil {
+			if err.Error() == "notification limit reached" {
+				return nil, err
+			}
+			fmt.Printf("Failed to reserve usage: %v\n", err)
+			return nil, fmt.Errorf("service unavailable")
+		}
+
+		// 4. Enviar con concurrencia limitada. Cada envío se registra apenas termina (los callbacks de estado
+		// pueden llegar antes de que acabe el lote) y cada fallo devuelve su cuota en ese momento: si la
+		// ejecución se corta, lo enviado ya quedó registrado y lo fallido ya no ocupa cuota.
+		var mu sync.Mutex
+		released := 0
+		sendBatchItems(ctx, items, results, func(i int, item *batchItem) {
+			if !results[i].Success {
+				if err := usageRepo.ReleaseUsage(ctx, businessID, usage.SK, item.units); err != nil {
+					fmt.Printf("Failed to release usage: %v\n", err)
+				}
+				mu.Lock()
+				released += item.units
+				mu.Unlock()
+				return
+			}
+
+			if req.Type != NotificationTypeEmail {
+				// Las respuestas del destinatario se asignan a este negocio
+				recordOutboundConversation(ctx, conversationRepo, req.Type, item.to, businessID)
+			}
+
+			recordNotification(ctx, notificationRepo, &models.Notification{
+				PK:                "NOTIFICATION#" + results[i].NotificationID,
+				SK:                "METADATA",
+				NotificationID:    results[i].NotificationID,
+				BusinessID:        businessID,
+				Channel:           req.Type,
+				Recipients:        []string{item.to},
+				TemplateID:        template.TemplateID,
+				Status:            "sent",
+				Provider:          item.provider,
+				ProviderMessageID: item.providerMessageID,
+				CreatedAt:         time.Now().Format(time.RFC3339),
+			})
+		})
+
+		response.NotificationCount = count - released
+	}
+
+	// 6. Programar los aplazados: cada uno reserva su cuota y el worker lo envía con el servicio del canal
+	for i, item := range deferred {
+		if item == nil {
+			continue
+		}
+		scheduled, err := scheduleNotification(ctx, client, businessID, plan, usage, req.Type, []string{item.to}, template.TemplateID, item.units, item.sendAt, item.request)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		results[i].Success = true
+		results[i].NotificationID = scheduled.NotificationID
+		results[i].Status = models.NotificationStatusScheduled
+		results[i].SendAt = scheduled.SendAt
+		response.NotificationCount = scheduled.NotificationCount
+	}
+
+	for _, result := range results {
+		if result.Success && result.Status == models.NotificationStatusScheduled {
+			response.Scheduled++
+		} else if result.Success {
+			response.Sent++
+		} else {
+			response.Failed++
+		}
+	}
+
+	response.NotificationLeft = plan.NotificationLimit - response.NotificationCount
+	if response.NotificationLeft < 0 {
+		response.NotificationLeft = 0
+	}
+
+	return response, nil
+}
+
+// sendBatchItems envía los destinatarios válidos con un máximo de batchConcurrency envíos simultáneos.
+// finish se llama desde el mismo envío, con el resultado ya asignado.
+func sendBatchItems(ctx context.Context, items []*batchItem, results []BatchRecipientResult, finish func(i int, item *batchItem)) {
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, batchConcurrency)
+
+	for i, item := range items {
+		if item == nil {
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(i int, item *batchItem) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			notificationID, providerMessageID, err := item.send(ctx)
+			if err != nil {
+				results[i].Error = err.Error()
+			} else {
+				results[i].Success = true
+				results[i].NotificationID = notificationID
+				item.providerMessageID = providerMessageID
+			}
+			finish(i, item)
+		}(i, item)
+	}
+
+	wg.Wait()
+}
+
+// validateBatchParameters valida los parámetros de la plantilla para un destinatario
+func validateBatchParameters(templateRepo *repository.TemplateRepository, template *models.Template, params map[string]string) error {
+	validation := templateRepo.ValidateTemplateParameters(template, params)
+	if !validation.Valid && len(validation.MissingParams) > 0 {
+		return fmt.Errorf("missing required parameters")
+	}
+	return nil
+}
+
+func smsBatchChannel(business *models.Business, plan *models.Plan, template *models.Template, templateRepo *repository.TemplateRepository) (*batchChannel, error) {
+	twilioClient := GetTwilioClient()
+	twilioPhoneNumber := GetTwilioPhoneNumber()
+	if twilioClient == nil || twilioPhoneNumber == "" {
+		return nil, fmt.Errorf("service temporarily unavailable")
+	}
+
+	return &batchChannel{
+		suppressionChannel: models.SuppressionChannelPhone,
+		prepare: func(to string, params map[string]string) (*batchItem, error) {
+			number, err := phone.Parse(to, business.DefaultCountry)
+			if err != nil {
+				return nil, err
+			}
+			if number.LineType == phone.LineTypeFixedLine {
+				return nil, fmt.Errorf("phone number cannot receive sms")
+			}
+			if err := checkDestinationCountry(business, plan, models.CountryPolicyChannelSMS, number); err != nil {
+				return nil, err
+			}
+			if err := validateBatchParameters(templateRepo, template, params); err != nil {
+				return nil, err
+			}
+			if template.TemplateID == "sms_verification_code" && !validateVerificationCode(params["codigo"]) {
+				return nil, fmt.Errorf("invalid verification code format")
+			}
+
+			params["empresa"] = business.Name
+			message := buildSMSMessage(template.Description, params)
+			segmentation := segmentSMS(message)
+			if segmentation.Characters > maxSMSCharacters {
+				return nil, fmt.Errorf("message too long")
+			}
+
+			units := 1
+			if plan.SMSChargePerSegment {
+				units = segmentation.Segments
+			}
+
+			return &batchItem{
+				to:       number.E164,
+				units:    units,
+				provider: "twilio",
+				number:   number,
+				request:  SendSMSRequest{To: number.E164, TemplateID: template.TemplateID, Parameters: params},
+				send: func(ctx context.Context) (string, string, error) {
+					sid, err := sendTwilioSMS(twilioClient, twilioPhoneNumber, number.E164, message, nil)
+					return sid, sid, err
+				},
+			}, nil
+		},
+	}, nil
+}
+
+func whatsAppBatchChannel(business *models.Business, plan *models.Plan, template *models.Template, templateRepo *repository.TemplateRepository) (*batchChannel, error) {
+	// El lote no admite adjuntos: las plantillas con header multimedia se envían una por una
+	if template.MediaHeader != "" {
+		return nil, fmt.Errorf("template requires media")
+	}
+
+	twilioClient := GetTwilioClient()
+	twilioWhatsAppNumber := GetTwilioWhatsAppNumber()
+	if twilioClient == nil || twilioWhatsAppNumber == "" {
+		return nil, fmt.Errorf("service temporarily unavailable")
+	}
+
+	return &batchChannel{
+		suppressionChannel: models.SuppressionChannelPhone,
+		prepare: func(to string, params map[string]string) (*batchItem, error) {
+			number, err := phone.Parse(to, business.DefaultCountry)
+			if err != nil {
+				return nil, err
+			}
+			if err := checkDestinationCountry(business, plan, models.CountryPolicyChannelWhatsApp, number); err != nil {
+				return nil, err
+			}
+			if err := validateBatchParameters(templateRepo, template, params); err != nil {
+				return nil, err
+			}
+
+			return &batchItem{
+				to:       number.E164,
+				units:    1,
+				provider: "twilio",
+				number:   number,
+				request:  SendWhatsAppRequest{To: number.E164, TemplateID: template.TemplateID, Parameters: params},
+				send: func(ctx context.Context) (string, string, error) {
+					message := &twilioApi.CreateMessageParams{}
+					message.SetTo("whatsapp:" + number.E164)
+					setWhatsAppTemplate(message, template, params, "")
+
+					sid, err := sendTwilioWhatsApp(twilioClient, twilioWhatsAppNumber, message)
+					return sid, sid, err
+				},
+			}, nil
+		},
+	}, nil
+}
+
+func emailBatchChannel(ctx context.Context, domainRepo *repository.SenderDomainRepository, business *models.Business, template *models.Template, templateRepo *repository.TemplateRepository) (*batchChannel, error) {
+	provider, err := resolveEmailProvider(ctx, business.EmailProvider)
+	if err != nil {
+		fmt.Printf("Email provider error: %v\n", err)
+		return nil, fmt.Errorf("email service not configured")
+	}
+
+	from, signer, err := resolveEmailSender(ctx, domainRepo, business, "", "")
+	if err != nil {
+		return nil, err
+	}
+	if from.Address == "" {
+		return nil, fmt.Errorf("email service not configured")
+	}
+
+	subjectTemplate := template.Subject
+	if subjectTemplate == "" {
+		subjectTemplate = template.Name
+	}
+
+	businessID := business.PK[9:]
+
+	return &batchChannel{
+		suppressionChannel: models.SuppressionChannelEmail,
+		prepare: func(to string, params map[string]string) (*batchItem, error) {
+			recipients, err := validateEmailRecipients([]string{to}, nil, nil, "")
+			if err != nil {
+				return nil, err
+			}
+			if err := validateBatchParameters(templateRepo, template, params); err != nil {
+				return nil, err
+			}
+
+			params["empresa"] = business.Name
+			email := newEmailMessage(from, recipients, buildSMSMessage(subjectTemplate, params), buildTemplateEmailBody(template, params), template.HTML)
+			email.DKIM = signer
+
+			address := strings.ToLower(recipients.To[0])
+			if unsubscribeHeaders, err := listUnsubscribeHeaders(businessID, address); err == nil {
+				email.ExtraHeaders = append(email.ExtraHeaders, unsubscribeHeaders...)
+			}
+
+			return &batchItem{
+				to:       address,
+				units:    1,
+				provider: provider.Name(),
+				send: func(ctx context.Context) (string, string, error) {
+					providerMessageID, err := sendEmailWithProvider(ctx, provider, email)
+					return email.MessageID, providerMessageID, err
+				},
+			}, nil
+		},
+	}, nil
+}
@@ -5,19 +5,26 @@ import (
 	"fmt"
 	"notify-backend/internal/db"
 	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
 	"notify-backend/internal/repository"
 	"notify-backend/internal/utils"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type BusinessRegisterResult struct {
-	BusinessID string
-	APIKey     string
+	BusinessID     string
+	APIKey         string
+	Phone          string // Teléfono normalizado a E.164
+	DefaultCountry string
 }
 
-func BusinessRegisterService(name string, email string, phone string, planID string) (*BusinessRegisterResult, error) {
+// BusinessRegisterService registra un negocio. country (ISO alfa-2, opcional) permite enviar
+// el teléfono en formato nacional y queda como país por defecto del negocio; si no se envía,
+// se usa el país detectado en el teléfono.
+func BusinessRegisterService(name string, email string, phoneInput string, country string, planID string) (*BusinessRegisterResult, error) {
 	client, _ := db.NewDynamoClient()
 	repo := repository.NewBusinessRepository(client, "NotificationService")
 	planRepo := repository.NewPlanRepository(client, "NotificationService")
@@ -44,8 +51,21 @@ func BusinessRegisterService(name string, email string, phone string, planID str
 		return nil, fmt.Errorf("email already registered")
 	}
 
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country != "" && !phone.IsSupportedCountry(country) {
+		return nil, fmt.Errorf("invalid country")
+	}
+
+	number, err := phone.Parse(phoneInput, country)
+	if err != nil {
+		return nil, err
+	}
+	if country == "" && phone.IsSupportedCountry(number.Country) {
+		country = number.Country
+	}
+
 	// Verificar phone existente
-	phoneExists, err := repo.PhoneExists(ctx, phone)
+	phoneExists, err := repo.PhoneExists(ctx, number.E164)
 	if err != nil {
 		return nil, fmt.Errorf("service unavailable")
 	}
@@ -66,10 +86,12 @@ func BusinessRegisterService(name string, email string, phone string, planID str
 		SK:        "METADATA",
 		Name:      name,
 		Email:     email,
-		Phone:     phone,
+		Phone:     number.E164,
 		PlanID:    planID,
 		APIKey:    apiKey,
 		CreatedAt: time.Now().Format(time.RFC3339),
+
+		DefaultCountry: country,
 	}
 
 	if err := repo.Create(ctx, item); err != nil {
@@ -96,7 +118,9 @@ func BusinessRegisterService(name string, email string, phone string, planID str
 	}
 
 	return &BusinessRegisterResult{
-		BusinessID: id,
-		APIKey:     apiKey,
+		BusinessID:     id,
+		APIKey:         apiKey,
+		Phone:          number.E164,
+		DefaultCountry: country,
 	}, nil
 }
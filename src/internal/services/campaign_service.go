@@ -0,0 +1,735 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
+	"notify-backend/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/uuid"
+)
+
+// Límites de las campañas
+const (
+	maxCampaignCSVBytes    = 3 << 20 // CSV decodificado; el body de la Lambda admite hasta 6 MB
+	maxCampaignRecipients  = 10000
+	defaultCampaignRate    = 60  // Destinatarios por minuto
+	maxCampaignRate        = 500 // Una tanda por minuto (el worker no tiene el límite de la API)
+	maxCampaignSchedule    = 30 * 24 * time.Hour
+	maxCampaignInvalidRows = 100 // Filas inválidas incluidas en la respuesta de creación
+
+	campaignRunInterval = time.Minute      // Entre tandas
+	campaignLease       = 5 * time.Minute  // Reserva de la tanda en curso frente a otros workers
+	campaignLimitRetry  = 15 * time.Minute // Reintento cuando el plan no tiene cuota
+)
+
+// campaignFatalErrors detienen la campaña: ninguna tanda posterior podría enviarse
+var campaignFatalErrors = map[string]bool{
+	"authentication failed":   true,
+	"invalid template":        true,
+	"invalid template type":   true,
+	"template not available":  true,
+	"template requires media": true,
+}
+
+// CreateCampaignRequest crea una campaña desde un CSV con cabecera.
+// Columns asigna columnas del CSV a parámetros de la plantilla; vacío = cada columna con su nombre.
+type CreateCampaignRequest struct {
+	Name          string            `json:"name"`
+	Type          string            `json:"type"` // whatsapp, sms
+	TemplateID    string            `json:"template_id"`
+	CSV           string            `json:"csv"`          // Contenido en base64
+	PhoneColumn   string            `json:"phone_column"` // Por defecto "phone"
+	Columns       map[string]string `json:"columns"`      // columna -> parámetro
+	Parameters    map[string]string `json:"parameters"`   // Comunes a todos los destinatarios
+	ScheduledAt   string            `json:"scheduled_at"` // RFC3339; vacío = ahora
+	RatePerMinute int               `json:"rate_per_minute"`
+
+	// Horario de envío: los destinatarios fuera de él se programan para el siguiente horario permitido
+	Category       string                 `json:"category"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+}
+
+type CampaignRecipientResponse struct {
+	Row            int               `json:"row"`
+	To             string            `json:"to"`
+	Parameters     map[string]string `json:"parameters,omitempty"`
+	Status         string            `json:"status"`
+	Error          string            `json:"error,omitempty"`
+	NotificationID string            `json:"notification_id,omitempty"`
+	ProcessedAt    string            `json:"processed_at,omitempty"`
+}
+
+type CampaignResponse struct {
+	CampaignID    string  `json:"campaign_id"`
+	Name          string  `json:"name"`
+	Type          string  `json:"type"`
+	TemplateID    string  `json:"template_id"`
+	Status        string  `json:"status"`
+	ScheduledAt   string  `json:"scheduled_at"`
+	RatePerMinute int     `json:"rate_per_minute"`
+	Total         int     `json:"total"`
+	Sent          int     `json:"sent"`
+	Scheduled     int     `json:"scheduled"` // Aplazados por el horario de envío
+	Failed        int     `json:"failed"`
+	Sending       int     `json:"sending"` // Tanda en curso; en una campaña terminada, interrumpidos sin resultado
+	Pending       int     `json:"pending"`
+	Invalid       int     `json:"invalid"`
+	Progress      float64 `json:"progress"` // Porcentaje de destinatarios válidos procesados
+	LastError     string  `json:"last_error,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+	StartedAt     string  `json:"started_at,omitempty"`
+	CompletedAt   string  `json:"completed_at,omitempty"`
+
+	// Solo al crear: primeras filas descartadas (el resto en /recipients?status=invalid)
+	InvalidRows []CampaignRecipientResponse `json:"invalid_rows,omitempty"`
+}
+
+type ListCampaignRecipientsResponse struct {
+	Recipients []CampaignRecipientResponse `json:"recipients"`
+	NextCursor string                      `json:"next_cursor,omitempty"`
+}
+
+func newCampaignResponse(campaign *models.Campaign) *CampaignResponse {
+	processed := campaign.Sent + campaign.Scheduled + campaign.Failed + campaign.Sending
+	progress := 100.0
+	if campaign.Total > 0 {
+		progress = float64(processed*10000/campaign.Total) / 100
+	}
+
+	return &CampaignResponse{
+		CampaignID:    campaign.CampaignID,
+		Name:          campaign.Name,
+		Type:          campaign.Type,
+		TemplateID:    campaign.TemplateID,
+		Status:        campaign.Status,
+		ScheduledAt:   campaign.ScheduledAt,
+		RatePerMinute: campaign.RatePerMinute,
+		Total:         campaign.Total,
+		Sent:          campaign.Sent,
+		Scheduled:     campaign.Scheduled,
+		Failed:        campaign.Failed,
+		Sending:       campaign.Sending,
+		Pending:       campaign.Total - processed,
+		Invalid:       campaign.Invalid,
+		Progress:      progress,
+		LastError:     campaign.LastError,
+		CreatedAt:     campaign.CreatedAt,
+		StartedAt:     campaign.StartedAt,
+		CompletedAt:   campaign.CompletedAt,
+	}
+}
+
+func newCampaignRecipientResponse(recipient *models.CampaignRecipient) CampaignRecipientResponse {
+	return CampaignRecipientResponse{
+		Row:            recipient.Row,
+		To:             recipient.To,
+		Parameters:     recipient.Parameters,
+		Status:         recipient.Status,
+		Error:          recipient.Error,
+		NotificationID: recipient.NotificationID,
+		ProcessedAt:    recipient.ProcessedAt,
+	}
+}
+
+// CreateCampaignService valida el CSV y programa la campaña. Las filas inválidas (teléfono, país de destino,
+// parámetros, duplicados) se descartan y se reportan; las supresiones se revisan al enviar cada tanda.
+func CreateCampaignService(apiKey string, req CreateCampaignRequest) (*CampaignResponse, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if req.Type != NotificationTypeSMS && req.Type != NotificationTypeWhatsApp {
+		return nil, fmt.Errorf("invalid campaign type")
+	}
+	if req.TemplateID == "" {
+		return nil, fmt.Errorf("template_id is required")
+	}
+
+	if req.RatePerMinute == 0 {
+		req.RatePerMinute = defaultCampaignRate
+	}
+	if req.RatePerMinute < 1 || req.RatePerMinute > maxCampaignRate {
+		return nil, fmt.Errorf("invalid rate_per_minute")
+	}
+	category, err := normalizeDeliveryCategory(req.Category)
+	if err != nil {
+		return nil, err
+	}
+	window, err := normalizeDeliveryWindow(req.DeliveryWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	scheduledAt := now
+	if req.ScheduledAt != "" {
+		parsed, err := time.Parse(time.RFC3339, req.ScheduledAt)
+		if err != nil || parsed.Before(now.Add(-time.Minute)) || parsed.After(now.Add(maxCampaignSchedule)) {
+			return nil, fmt.Errorf("invalid scheduled_at")
+		}
+		if parsed.After(now) {
+			scheduledAt = parsed.UTC()
+		}
+	}
+
+	content, err := base64.StdEncoding.DecodeString(req.CSV)
+	if err != nil || len(content) == 0 {
+		return nil, fmt.Errorf("invalid csv")
+	}
+	if len(content) > maxCampaignCSVBytes {
+		return nil, fmt.Errorf("csv too large")
+	}
+
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	planRepo := repository.NewPlanRepository(client, "NotificationService")
+	templateRepo := repository.NewTemplateRepository(client, "NotificationService")
+	campaignRepo := repository.NewCampaignRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	businessID := business.PK[9:] // Remover "BUSINESS#"
+
+	plan, err := planRepo.GetByID(ctx, business.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	template, err := templateRepo.GetByID(ctx, req.TemplateID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid template")
+	}
+	if template.Type != req.Type {
+		return nil, fmt.Errorf("invalid template type")
+	}
+	if !template.Active {
+		return nil, fmt.Errorf("template not available")
+	}
+	if req.Type == NotificationTypeWhatsApp && template.MediaHeader != "" {
+		return nil, fmt.Errorf("template requires media")
+	}
+
+	rows, err := parseCampaignCSV(content, req.PhoneColumn, req.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	campaignID := uuid.New().String()
+	pk := "CAMPAIGN#" + campaignID
+	channel := models.CountryPolicyChannelSMS
+	if req.Type == NotificationTypeWhatsApp {
+		channel = models.CountryPolicyChannelWhatsApp
+	}
+
+	var recipients []*models.CampaignRecipient
+	var invalid []*models.CampaignRecipient
+	seen := map[string]bool{}
+	for _, row := range rows {
+		params := make(map[string]string, len(req.Parameters)+len(row.params))
+		for key, value := range req.Parameters {
+			params[key] = value
+		}
+		for key, value := range row.params {
+			params[key] = value
+		}
+
+		to, err := validateCampaignRow(business, plan, channel, row.phone, func(e164 string) error {
+			if seen[e164] {
+				return fmt.Errorf("duplicate recipient")
+			}
+			return validateBatchParameters(templateRepo, template, params)
+		})
+		if err != nil {
+			invalid = append(invalid, &models.CampaignRecipient{
+				PK:         pk,
+				SK:         repository.CampaignInvalidSK(row.number),
+				Row:        row.number,
+				To:         row.phone,
+				Parameters: row.params,
+				Status:     models.CampaignRecipientInvalid,
+				Error:      err.Error(),
+			})
+			continue
+		}
+		seen[to] = true
+
+		recipients = append(recipients, &models.CampaignRecipient{
+			PK:         pk,
+			SK:         repository.CampaignRecipientSK(len(recipients)),
+			Row:        row.number,
+			To:         to,
+			Parameters: row.params,
+			Status:     models.CampaignRecipientPending,
+		})
+	}
+
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("no valid recipients")
+	}
+
+	createdAt := now.Format(time.RFC3339)
+	status := models.CampaignStatusScheduled
+	if !scheduledAt.After(now) {
+		status = models.CampaignStatusRunning
+	}
+
+	campaign := &models.Campaign{
+		PK:            pk,
+		SK:            "METADATA",
+		CampaignID:    campaignID,
+		BusinessID:    businessID,
+		Name:          strings.TrimSpace(req.Name),
+		Type:          req.Type,
+		TemplateID:    template.TemplateID,
+		Parameters:    req.Parameters,
+		Status:        status,
+		RatePerMinute: req.RatePerMinute,
+		ScheduledAt:   scheduledAt.Format(time.RFC3339),
+		NextRunAt:     scheduledAt.Format(time.RFC3339),
+		Total:         len(recipients),
+		Invalid:       len(invalid),
+		CreatedAt:     createdAt,
+		UpdatedAt:     createdAt,
+
+		Category:       category,
+		DeliveryWindow: window,
+	}
+
+	if err := campaignRepo.Create(ctx, campaign, append(recipients, invalid...)); err != nil {
+		fmt.Printf("Failed to create campaign: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := newCampaignResponse(campaign)
+	for _, recipient := range invalid {
+		if len(resp.InvalidRows) == maxCampaignInvalidRows {
+			break
+		}
+		resp.InvalidRows = append(resp.InvalidRows, newCampaignRecipientResponse(recipient))
+	}
+
+	return resp, nil
+}
+
+// validateCampaignRow normaliza el teléfono de una fila y aplica las reglas del canal antes de check
+func validateCampaignRow(business *models.Business, plan *models.Plan, channel, value string, check func(e164 string) error) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("phone is required")
+	}
+
+	number, err := phone.Parse(value, business.DefaultCountry)
+	if err != nil {
+		return "", err
+	}
+	if channel == models.CountryPolicyChannelSMS && number.LineType == phone.LineTypeFixedLine {
+		return "", fmt.Errorf("phone number cannot receive sms")
+	}
+	if err := checkDestinationCountry(business, plan, channel, number); err != nil {
+		return "", err
+	}
+	if err := check(number.E164); err != nil {
+		return "", err
+	}
+
+	return number.E164, nil
+}
+
+// campaignRow es una fila de datos del CSV
+type campaignRow struct {
+	number int // Fila en el archivo (la cabecera es la 1)
+	phone  string
+	params map[string]string
+}
+
+// parseCampaignCSV lee el CSV (separado por comas o punto y coma) y aplica la asignación de columnas
+func parseCampaignCSV(content []byte, phoneColumn string, columns map[string]string) ([]campaignRow, error) {
+	content = bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")) // BOM de Excel
+
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	// Excel en configuración regional en español exporta con punto y coma
+	if firstLine, _, _ := bytes.Cut(content, []byte("\n")); bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("invalid csv")
+	}
+	indexes := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		if _, exists := indexes[name]; !exists && name != "" {
+			indexes[name] = i
+		}
+	}
+
+	if phoneColumn == "" {
+		phoneColumn = "phone"
+	}
+	phoneIndex, ok := indexes[phoneColumn]
+	if !ok {
+		return nil, fmt.Errorf("phone column not found")
+	}
+
+	if len(columns) == 0 {
+		columns = map[string]string{}
+		for name := range indexes {
+			if name != phoneColumn {
+				columns[name] = name
+			}
+		}
+	}
+	mapping := map[int]string{}
+	for column, param := range columns {
+		index, ok := indexes[column]
+		if !ok {
+			return nil, fmt.Errorf("column not found: %s", column)
+		}
+		if param == "" {
+			return nil, fmt.Errorf("invalid column mapping")
+		}
+		mapping[index] = param
+	}
+
+	var rows []campaignRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid csv")
+		}
+		line, _ := reader.FieldPos(0)
+
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+		if len(rows) == maxCampaignRecipients {
+			return nil, fmt.Errorf("too many recipients")
+		}
+
+		row := campaignRow{number: line, params: map[string]string{}}
+		if phoneIndex < len(record) {
+			row.phone = strings.TrimSpace(record[phoneIndex])
+		}
+		for index, param := range mapping {
+			// Las celdas vacías no se envían: la plantilla las reporta como parámetros faltantes
+			if index < len(record) && strings.TrimSpace(record[index]) != "" {
+				row.params[param] = strings.TrimSpace(record[index])
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("no valid recipients")
+	}
+
+	return rows, nil
+}
+
+// GetCampaignService retorna el estado y el progreso de una campaña del negocio
+func GetCampaignService(apiKey, campaignID string) (*CampaignResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	campaignRepo := repository.NewCampaignRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	campaign, err := getBusinessCampaign(ctx, campaignRepo, business.PK[9:], campaignID)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCampaignResponse(campaign), nil
+}
+
+// ListCampaignRecipientsService lista los resultados por destinatario, opcionalmente filtrados por estado
+func ListCampaignRecipientsService(apiKey, campaignID, status string, limit int32, cursor string) (*ListCampaignRecipientsResponse, error) {
+	switch status {
+	case "", models.CampaignRecipientPending, models.CampaignRecipientSending, models.CampaignRecipientSent,
+		models.CampaignRecipientScheduled, models.CampaignRecipientFailed, models.CampaignRecipientInvalid:
+	default:
+		return nil, fmt.Errorf("invalid status")
+	}
+
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	campaignRepo := repository.NewCampaignRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	if _, err := getBusinessCampaign(ctx, campaignRepo, business.PK[9:], campaignID); err != nil {
+		return nil, err
+	}
+
+	recipients, nextCursor, err := campaignRepo.ListRecipientsPage(ctx, campaignID, status, limit, cursor)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return nil, err
+		}
+		fmt.Printf("Failed to list campaign recipients: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := &ListCampaignRecipientsResponse{
+		Recipients: make([]CampaignRecipientResponse, 0, len(recipients)),
+		NextCursor: nextCursor,
+	}
+	for _, recipient := range recipients {
+		resp.Recipients = append(resp.Recipients, newCampaignRecipientResponse(recipient))
+	}
+
+	return resp, nil
+}
+
+// getBusinessCampaign obtiene la campaña solo si pertenece al negocio
+func getBusinessCampaign(ctx context.Context, campaignRepo *repository.CampaignRepository, businessID, campaignID string) (*models.Campaign, error) {
+	campaign, err := campaignRepo.GetByID(ctx, campaignID)
+	if err != nil {
+		if err.Error() == "campaign not found" {
+			return nil, err
+		}
+		fmt.Printf("Failed to get campaign %s: %v\n", campaignID, err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	if campaign.BusinessID != businessID {
+		return nil, fmt.Errorf("campaign not found")
+	}
+	return campaign, nil
+}
+
+// ProcessCampaignsService envía una tanda de cada campaña cuya próxima ejecución ya llegó.
+// La ejecuta el worker programado cada minuto; deja de tomar campañas cuando queda menos de reserve de tiempo.
+func ProcessCampaignsService(ctx context.Context, reserve time.Duration) error {
+	client, _ := db.NewDynamoClient()
+	campaignRepo := repository.NewCampaignRepository(client, "NotificationService")
+
+	ids, err := campaignRepo.ListDue(ctx, time.Now().UTC().Format(time.RFC3339), 50)
+	if err != nil {
+		fmt.Printf("Failed to list due campaigns: %v\n", err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	for _, id := range ids {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < reserve {
+			break
+		}
+		if err := processCampaign(ctx, client, campaignRepo, id); err != nil {
+			fmt.Printf("Failed to process campaign %s: %v\n", id, err)
+		}
+	}
+
+	return nil
+}
+
+// processCampaign envía la siguiente tanda (hasta ratePerMinute destinatarios) con el envío por lotes
+func processCampaign(ctx context.Context, client *dynamodb.Client, campaignRepo *repository.CampaignRepository, campaignID string) error {
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+
+	campaign, err := campaignRepo.GetByID(ctx, campaignID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if campaign.Status != models.CampaignStatusScheduled && campaign.Status != models.CampaignStatusRunning {
+		return nil
+	}
+	if nextRun, err := time.Parse(time.RFC3339, campaign.NextRunAt); err == nil && nextRun.After(now) {
+		return nil // El índice es eventualmente consistente: otro worker ya la tomó
+	}
+
+	// 1. Reservar la tanda: otro worker no la toma hasta que venza la reserva
+	if campaign.Status == models.CampaignStatusScheduled {
+		campaign.Status = models.CampaignStatusRunning
+		campaign.StartedAt = now.Format(time.RFC3339)
+	}
+	campaign.NextRunAt = now.Add(campaignLease).Format(time.RFC3339)
+	campaign.UpdatedAt = now.Format(time.RFC3339)
+	if err := campaignRepo.Save(ctx, campaign, campaign.Version); err != nil {
+		if err.Error() == "campaign modified" {
+			return nil
+		}
+		return err
+	}
+	version := campaign.Version
+
+	// 2. Siguiente tanda de destinatarios
+	recipients, err := campaignRepo.ListRecipientsFrom(ctx, campaignID, campaign.Cursor, int32(campaign.RatePerMinute))
+	if err != nil {
+		return err
+	}
+
+	if len(recipients) == 0 {
+		finishCampaign(campaign, models.CampaignStatusCompleted, "")
+		return campaignRepo.Save(ctx, campaign, version)
+	}
+
+	business, err := businessRepo.GetByPK(ctx, "BUSINESS#"+campaign.BusinessID)
+	if err != nil {
+		return retryCampaign(ctx, campaignRepo, campaign, version, "service unavailable", campaignRunInterval)
+	}
+
+	// Fuera del horario de envío de toda la tanda la campaña espera al siguiente inicio del horario:
+	// programar cada destinatario saltaría rate_per_minute y dejaría envíos pendientes al completarla
+	if resumeAt, wait := campaignWindowWait(business, campaign, recipients, now); wait {
+		return retryCampaign(ctx, campaignRepo, campaign, version, "", resumeAt.Sub(now))
+	}
+
+	req := SendBatchRequest{
+		Type:       campaign.Type,
+		TemplateID: campaign.TemplateID,
+		Parameters: campaign.Parameters,
+
+		Category:       campaign.Category,
+		DeliveryWindow: campaign.DeliveryWindow,
+	}
+	for _, recipient := range recipients {
+		req.Recipients = append(req.Recipients, BatchRecipient{To: recipient.To, Parameters: recipient.Parameters})
+	}
+
+	// 3. Avanzar el cursor antes de enviar: si el worker se interrumpe, al vencer la reserva la tanda no se
+	// vuelve a enviar (sus destinatarios quedan sending, sin resultado conocido)
+	for _, recipient := range recipients {
+		recipient.Status = models.CampaignRecipientSending
+	}
+	campaign.Cursor += len(recipients)
+	campaign.Sending += len(recipients)
+	if err := campaignRepo.Save(ctx, campaign, version); err != nil {
+		if err.Error() == "campaign modified" {
+			return nil
+		}
+		return err
+	}
+	version = campaign.Version
+	if err := campaignRepo.PutRecipients(ctx, recipients); err != nil {
+		fmt.Printf("Failed to save campaign %s recipients: %v\n", campaignID, err)
+	}
+
+	// 4. Enviar; los errores del lote completo no consumen destinatarios: la tanda vuelve a quedar pendiente
+	result, err := sendBatch(ctx, client, business, req)
+	if err != nil {
+		for _, recipient := range recipients {
+			recipient.Status = models.CampaignRecipientPending
+		}
+		if err := campaignRepo.PutRecipients(ctx, recipients); err != nil {
+			fmt.Printf("Failed to save campaign %s recipients: %v\n", campaignID, err)
+		}
+		campaign.Cursor -= len(recipients)
+		campaign.Sending -= len(recipients)
+
+		errMsg := err.Error()
+		if campaignFatalErrors[errMsg] {
+			finishCampaign(campaign, models.CampaignStatusFailed, errMsg)
+			return campaignRepo.Save(ctx, campaign, version)
+		}
+		if errMsg == "notification limit reached" {
+			return retryCampaign(ctx, campaignRepo, campaign, version, errMsg, campaignLimitRetry)
+		}
+		return retryCampaign(ctx, campaignRepo, campaign, version, errMsg, campaignRunInterval)
+	}
+
+	// 5. Guardar el resultado de cada destinatario
+	processedAt := time.Now().UTC().Format(time.RFC3339)
+	for i, recipientResult := range result.Results {
+		recipient := recipients[i]
+		recipient.ProcessedAt = processedAt
+		if recipientResult.Success && recipientResult.Status == models.NotificationStatusScheduled {
+			recipient.Status = models.CampaignRecipientScheduled
+			recipient.NotificationID = recipientResult.NotificationID
+			campaign.Scheduled++
+		} else if recipientResult.Success {
+			recipient.Status = models.CampaignRecipientSent
+			recipient.NotificationID = recipientResult.NotificationID
+			campaign.Sent++
+		} else {
+			recipient.Status = models.CampaignRecipientFailed
+			recipient.Error = recipientResult.Error
+			campaign.Failed++
+		}
+	}
+	if err := campaignRepo.PutRecipients(ctx, recipients); err != nil {
+		fmt.Printf("Failed to save campaign %s results: %v\n", campaignID, err)
+	}
+
+	campaign.Sending -= len(recipients)
+	campaign.LastError = ""
+	if campaign.Cursor >= campaign.Total {
+		finishCampaign(campaign, models.CampaignStatusCompleted, "")
+	} else {
+		campaign.NextRunAt = now.Add(campaignRunInterval).Format(time.RFC3339)
+		campaign.UpdatedAt = processedAt
+	}
+
+	return campaignRepo.Save(ctx, campaign, version)
+}
+
+// campaignWindowWait retorna cuándo reanudar la campaña si ningún destinatario de la tanda está dentro
+// del horario de envío (el primer inicio del horario entre ellos)
+func campaignWindowWait(business *models.Business, campaign *models.Campaign, recipients []*models.CampaignRecipient, now time.Time) (time.Time, bool) {
+	category, err := normalizeDeliveryCategory(campaign.Category)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var resumeAt time.Time
+	for _, recipient := range recipients {
+		number, err := phone.Parse(recipient.To, business.DefaultCountry)
+		if err != nil {
+			return time.Time{}, false // El envío por lotes reporta el destinatario inválido
+		}
+		at, deferred := deliveryTime(business, campaign.DeliveryWindow, category, recipientLocations("", number, business.DefaultCountry), now)
+		if !deferred {
+			return time.Time{}, false
+		}
+		if resumeAt.IsZero() || at.Before(resumeAt) {
+			resumeAt = at
+		}
+	}
+	return resumeAt, !resumeAt.IsZero()
+}
+
+// retryCampaign deja la tanda pendiente para la siguiente ejecución, con el error visible en el progreso
+func retryCampaign(ctx context.Context, campaignRepo *repository.CampaignRepository, campaign *models.Campaign, version int, errMsg string, after time.Duration) error {
+	now := time.Now().UTC()
+	campaign.LastError = errMsg
+	campaign.NextRunAt = now.Add(after).Format(time.RFC3339)
+	campaign.UpdatedAt = now.Format(time.RFC3339)
+	return campaignRepo.Save(ctx, campaign, version)
+}
+
+// finishCampaign saca la campaña de la cola
+func finishCampaign(campaign *models.Campaign, status, errMsg string) {
+	now := time.Now().UTC().Format(time.RFC3339)
+	campaign.Status = status
+	campaign.LastError = errMsg
+	campaign.NextRunAt = ""
+	campaign.CompletedAt = now
+	campaign.UpdatedAt = now
+}
@@ -0,0 +1,522 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
+	"notify-backend/internal/repository"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	_ "time/tzdata" // Las zonas horarias no dependen de la imagen de la Lambda
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/uuid"
+)
+
+// Límites de los atributos personalizados
+const (
+	maxContactAttributes     = 50
+	maxContactAttributeValue = 1024
+)
+
+var (
+	contactAttributeKeyRegex = regexp.MustCompile(`^[A-Za-z0-9_]{1,64}$`)
+	localeRegex              = regexp.MustCompile(`^[a-z]{2,3}(-([A-Z]{2}|[0-9]{3}))?$`)
+)
+
+// ContactRequest crea o actualiza un contacto. En PATCH los campos omitidos no cambian,
+// "" borra el campo y null borra un atributo o el consentimiento de un canal.
+type ContactRequest struct {
+	Name       *string            `json:"name"`
+	Phone      *string            `json:"phone"`
+	Email      *string            `json:"email"`
+	WhatsAppID *string            `json:"whatsapp_id"`
+	Locale     *string            `json:"locale"`
+	Timezone   *string            `json:"timezone"`
+	Attributes map[string]*string `json:"attributes"`
+	Consent    map[string]*bool   `json:"consent"` // sms, whatsapp, email
+}
+
+type ContactResponse struct {
+	ContactID  string                           `json:"contact_id"`
+	Name       string                           `json:"name,omitempty"`
+	Phone      string                           `json:"phone,omitempty"`
+	Email      string                           `json:"email,omitempty"`
+	WhatsAppID string                           `json:"whatsapp_id,omitempty"`
+	Locale     string                           `json:"locale,omitempty"`
+	Timezone   string                           `json:"timezone,omitempty"`
+	Attributes map[string]string                `json:"attributes"`
+	Consent    map[string]models.ContactConsent `json:"consent"`
+	CreatedAt  string                           `json:"created_at"`
+	UpdatedAt  string                           `json:"updated_at"`
+}
+
+type ListContactsResponse struct {
+	Contacts   []ContactResponse `json:"contacts"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+func newContactResponse(contact *models.Contact) ContactResponse {
+	resp := ContactResponse{
+		ContactID:  contact.ContactID,
+		Name:       contact.Name,
+		Phone:      contact.Phone,
+		Email:      contact.Email,
+		WhatsAppID: contact.WhatsAppID,
+		Locale:     contact.Locale,
+		Timezone:   contact.Timezone,
+		Attributes: contact.Attributes,
+		Consent:    contact.Consent,
+		CreatedAt:  contact.CreatedAt,
+		UpdatedAt:  contact.UpdatedAt,
+	}
+	if resp.Attributes == nil {
+		resp.Attributes = map[string]string{}
+	}
+	if resp.Consent == nil {
+		resp.Consent = map[string]models.ContactConsent{}
+	}
+	return resp
+}
+
+func CreateContactService(apiKey string, req ContactRequest) (*ContactResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	contactRepo := repository.NewContactRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	contactID := uuid.New().String()
+	contact := &models.Contact{
+		PK:        business.PK,
+		SK:        "CONTACT#" + contactID,
+		ContactID: contactID,
+		CreatedAt: now,
+	}
+	if err := applyContactRequest(contact, business, req, now); err != nil {
+		return nil, err
+	}
+
+	if err := contactRepo.Create(ctx, contact); err != nil {
+		fmt.Printf("Failed to create contact: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	if err := syncConsentSuppressions(ctx, client, business, contact, req.Consent); err != nil {
+		return nil, err
+	}
+
+	resp := newContactResponse(contact)
+	return &resp, nil
+}
+
+func GetContactService(apiKey, contactID string) (*ContactResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	contactRepo := repository.NewContactRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	contact, err := getContact(ctx, contactRepo, business.PK[9:], contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := newContactResponse(contact)
+	return &resp, nil
+}
+
+func UpdateContactService(apiKey, contactID string, req ContactRequest) (*ContactResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	contactRepo := repository.NewContactRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	contact, err := getContact(ctx, contactRepo, business.PK[9:], contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applyContactRequest(contact, business, req, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return nil, err
+	}
+
+	if err := contactRepo.Update(ctx, contact); err != nil {
+		if err.Error() == "contact not found" {
+			return nil, err
+		}
+		fmt.Printf("Failed to update contact %s: %v\n", contactID, err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	if err := syncConsentSuppressions(ctx, client, business, contact, req.Consent); err != nil {
+		return nil, err
+	}
+
+	resp := newContactResponse(contact)
+	return &resp, nil
+}
+
+func DeleteContactService(apiKey, contactID string) error {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	contactRepo := repository.NewContactRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return fmt.Errorf("authentication failed")
+	}
+
+	if err := contactRepo.Delete(ctx, business.PK[9:], contactID); err != nil {
+		if err.Error() == "contact not found" {
+			return err
+		}
+		fmt.Printf("Failed to delete contact %s: %v\n", contactID, err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	return nil
+}
+
+func ListContactsService(apiKey string, limit int32, cursor string) (*ListContactsResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	contactRepo := repository.NewContactRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	contacts, nextCursor, err := contactRepo.ListPage(ctx, business.PK[9:], limit, cursor)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return nil, err
+		}
+		fmt.Printf("Failed to list contacts: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := &ListContactsResponse{
+		Contacts:   make([]ContactResponse, 0, len(contacts)),
+		NextCursor: nextCursor,
+	}
+	for _, contact := range contacts {
+		resp.Contacts = append(resp.Contacts, newContactResponse(contact))
+	}
+
+	return resp, nil
+}
+
+func getContact(ctx context.Context, contactRepo *repository.ContactRepository, businessID, contactID string) (*models.Contact, error) {
+	contact, err := contactRepo.GetByID(ctx, businessID, contactID)
+	if err != nil {
+		if err.Error() == "contact not found" {
+			return nil, err
+		}
+		fmt.Printf("Failed to get contact %s: %v\n", contactID, err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	return contact, nil
+}
+
+// applyContactRequest valida y aplica los campos enviados. Los teléfonos se guardan en E.164
+// (los nacionales usan el país por defecto del negocio) y los emails en minúsculas.
+func applyContactRequest(contact *models.Contact, business *models.Business, req ContactRequest, now string) error {
+	if req.Name != nil {
+		contact.Name = strings.TrimSpace(*req.Name)
+	}
+
+	for _, field := range []struct {
+		value  *string
+		target *string
+	}{{req.Phone, &contact.Phone}, {req.WhatsAppID, &contact.WhatsAppID}} {
+		if field.value == nil {
+			continue
+		}
+		*field.target = ""
+		if value := strings.TrimSpace(*field.value); value != "" {
+			number, err := phone.Parse(value, business.DefaultCountry)
+			if err != nil {
+				return err
+			}
+			*field.target = number.E164
+		}
+	}
+
+	if req.Email != nil {
+		contact.Email = ""
+		if value := strings.TrimSpace(*req.Email); value != "" {
+			email, err := validateEmailAddress(value)
+			if err != nil {
+				return err
+			}
+			contact.Email = strings.ToLower(email)
+		}
+	}
+
+	if req.Locale != nil {
+		locale, err := normalizeLocale(*req.Locale)
+		if err != nil {
+			return err
+		}
+		contact.Locale = locale
+	}
+
+	if req.Timezone != nil {
+		timezone := strings.TrimSpace(*req.Timezone)
+		if timezone != "" {
+			if _, err := time.LoadLocation(timezone); err != nil || timezone == "Local" {
+				return fmt.Errorf("invalid timezone")
+			}
+		}
+		contact.Timezone = timezone
+	}
+
+	if len(req.Attributes) > 0 {
+		if contact.Attributes == nil {
+			contact.Attributes = map[string]string{}
+		}
+		for key, value := range req.Attributes {
+			if !contactAttributeKeyRegex.MatchString(key) {
+				return fmt.Errorf("invalid attributes")
+			}
+			if value == nil {
+				delete(contact.Attributes, key)
+				continue
+			}
+			if len(*value) > maxContactAttributeValue {
+				return fmt.Errorf("invalid attributes")
+			}
+			contact.Attributes[key] = *value
+		}
+		if len(contact.Attributes) > maxContactAttributes {
+			return fmt.Errorf("too many attributes")
+		}
+	}
+
+	if len(req.Consent) > 0 {
+		if contact.Consent == nil {
+			contact.Consent = map[string]models.ContactConsent{}
+		}
+		for channel, granted := range req.Consent {
+			if channel != NotificationTypeSMS && channel != NotificationTypeWhatsApp && channel != NotificationTypeEmail {
+				return fmt.Errorf("invalid channel")
+			}
+			if granted == nil {
+				delete(contact.Consent, channel)
+				continue
+			}
+			// La fecha solo cambia cuando cambia el consentimiento
+			if current, ok := contact.Consent[channel]; !ok || current.Granted != *granted {
+				contact.Consent[channel] = models.ContactConsent{Granted: *granted, UpdatedAt: now}
+			}
+		}
+	}
+
+	if contact.Phone == "" && contact.Email == "" && contact.WhatsAppID == "" {
+		return fmt.Errorf("contact address is required")
+	}
+
+	contact.UpdatedAt = now
+	return nil
+}
+
+// normalizeLocale acepta es, es-CO, es_co, pt-BR, es-419; "" borra el idioma
+func normalizeLocale(value string) (string, error) {
+	value = strings.ReplaceAll(strings.TrimSpace(value), "_", "-")
+	if value == "" {
+		return "", nil
+	}
+
+	language, region, hasRegion := strings.Cut(value, "-")
+	locale := strings.ToLower(language)
+	if hasRegion {
+		locale += "-" + strings.ToUpper(region)
+	}
+	if !localeRegex.MatchString(locale) {
+		return "", fmt.Errorf("invalid locale")
+	}
+	return locale, nil
+}
+
+// consentSuppressionKey retorna la supresión que corresponde al consentimiento de un canal del contacto
+// (el teléfono cubre SMS y WhatsApp); vacío si el contacto no tiene dirección para el canal
+func consentSuppressionKey(contact *models.Contact, channel string) (string, string) {
+	switch channel {
+	case NotificationTypeSMS:
+		return models.SuppressionChannelPhone, contact.Phone
+	case NotificationTypeWhatsApp:
+		if contact.WhatsAppID != "" {
+			return models.SuppressionChannelPhone, contact.WhatsAppID
+		}
+		return models.SuppressionChannelPhone, contact.Phone
+	case NotificationTypeEmail:
+		return models.SuppressionChannelEmail, contact.Email
+	}
+	return "", ""
+}
+
+// syncConsentSuppressions refleja en la lista de supresión los consentimientos enviados: retirarlo
+// suprime la dirección del canal para todos los envíos (también sin contact_id, lotes y campañas) y
+// otorgarlo quita solo la supresión creada por el consentimiento (no un STOP ni una baja manual).
+// Una dirección de teléfono compartida por SMS y WhatsApp sigue suprimida mientras uno de los dos esté retirado.
+func syncConsentSuppressions(ctx context.Context, client *dynamodb.Client, business *models.Business, contact *models.Contact, consent map[string]*bool) error {
+	suppressionRepo := repository.NewSuppressionRepository(client, "NotificationService")
+	businessID := business.PK[9:]
+
+	for channel := range consent {
+		suppressionChannel, value := consentSuppressionKey(contact, channel)
+		if value == "" {
+			continue
+		}
+
+		withdrawn := false
+		for other, current := range contact.Consent {
+			otherChannel, otherValue := consentSuppressionKey(contact, other)
+			if !current.Granted && otherChannel == suppressionChannel && otherValue == value {
+				withdrawn = true
+			}
+		}
+
+		var err error
+		if withdrawn {
+			err = suppressionRepo.Create(ctx, &models.Suppression{
+				PK:         business.PK,
+				BusinessID: businessID,
+				Channel:    suppressionChannel,
+				Value:      value,
+				Reason:     SuppressionReasonConsent,
+				Source:     "contact:" + contact.ContactID,
+				CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+			})
+			if err != nil && err.Error() == "suppression exists" {
+				err = nil
+			}
+		} else {
+			err = suppressionRepo.DeleteWithReason(ctx, businessID, suppressionChannel, value, SuppressionReasonConsent)
+			if err != nil && err.Error() == "suppression not found" {
+				err = nil
+			}
+		}
+		if err != nil {
+			fmt.Printf("Failed to update consent suppression for contact %s: %v\n", contact.ContactID, err)
+			return fmt.Errorf("service unavailable")
+		}
+	}
+
+	return nil
+}
+
+// contactRecipient es un destinatario resuelto desde un contacto
+type contactRecipient struct {
+	To         string
+	TemplateID string
+	Parameters map[string]string
+	Timezone   string // Para el horario de envío
+}
+
+// resolveContactRecipient resuelve contact_id para un canal: la dirección del canal, la variante
+// de la plantilla para el idioma del contacto y sus atributos como parámetros (los enviados tienen prioridad).
+// Rechaza el envío si el contacto retiró el consentimiento del canal y, en SMS y WhatsApp enviados con
+// category marketing explícita, si no lo otorgó (sin categoría o en email solo lo bloquea el retiro).
+func resolveContactRecipient(ctx context.Context, client *dynamodb.Client, businessID, contactID, channel, category, to, templateID string, params map[string]string) (*contactRecipient, error) {
+	if to != "" {
+		return nil, fmt.Errorf("contact_id cannot be combined with to")
+	}
+
+	contactRepo := repository.NewContactRepository(client, "NotificationService")
+	templateRepo := repository.NewTemplateRepository(client, "NotificationService")
+
+	contact, err := getContact(ctx, contactRepo, businessID, contactID)
+	if err != nil {
+		return nil, err
+	}
+
+	consent, ok := contact.Consent[channel]
+	if ok && !consent.Granted {
+		return nil, fmt.Errorf("contact consent not granted")
+	}
+	if !ok && channel != NotificationTypeEmail && strings.ToLower(strings.TrimSpace(category)) == DeliveryCategoryMarketing {
+		return nil, fmt.Errorf("contact consent not granted")
+	}
+
+	recipient := &contactRecipient{TemplateID: templateID, Parameters: map[string]string{}, Timezone: contact.Timezone}
+	switch channel {
+	case NotificationTypeSMS:
+		recipient.To = contact.Phone
+	case NotificationTypeWhatsApp:
+		recipient.To = contact.WhatsAppID
+		if recipient.To == "" {
+			recipient.To = contact.Phone
+		}
+	case NotificationTypeEmail:
+		recipient.To = contact.Email
+	}
+	if recipient.To == "" {
+		return nil, fmt.Errorf("contact has no address for channel")
+	}
+
+	// Sin plantilla válida el servicio del canal reporta el error; los atributos no se agregan
+	var parameters []string
+	if templateID != "" {
+		if template, err := templateRepo.GetByID(ctx, templateID); err == nil {
+			template = templateVariant(ctx, templateRepo, template, contact.Locale)
+			recipient.TemplateID = template.TemplateID
+			parameters = template.Parameters
+		}
+	}
+
+	// Solo los atributos que la plantilla usa: los demás serían parámetros extra
+	for key, value := range contact.Attributes {
+		if slices.Contains(parameters, key) {
+			recipient.Parameters[key] = value
+		}
+	}
+	for key, value := range params {
+		recipient.Parameters[key] = value
+	}
+
+	return recipient, nil
+}
+
+// templateVariant elige la variante para el idioma: primero el locale exacto (es-CO), luego el idioma (es).
+// Si la variante no existe, es de otro canal o no está activa, se usa la plantilla original.
+func templateVariant(ctx context.Context, templateRepo *repository.TemplateRepository, template *models.Template, locale string) *models.Template {
+	if locale == "" || len(template.Variants) == 0 {
+		return template
+	}
+
+	language, _, _ := strings.Cut(locale, "-")
+	for _, candidate := range []string{locale, language} {
+		variantID, ok := template.Variants[candidate]
+		if !ok {
+			continue
+		}
+		variant, err := templateRepo.GetByID(ctx, variantID)
+		if err != nil || variant.Type != template.Type || !variant.Active {
+			continue
+		}
+		return variant
+	}
+
+	return template
+}
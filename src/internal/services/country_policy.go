@@ -0,0 +1,101 @@
+package services
+
+import (
+	"fmt"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
+	"slices"
+	"strings"
+)
+
+// highRiskCountries es la política por defecto de la plataforma: destinos frecuentes de fraude
+// por tráfico internacional (SMS pumping, IRSF) con tarifas de terminación altas.
+// Solo se envían si el plan (configurado por la plataforma) los incluye explícitamente en su lista de permitidos.
+var highRiskCountries = map[string]bool{
+	// África
+	"CF": true, "CG": true, "CD": true, "ER": true, "GN": true, "GW": true, "KM": true, "LR": true,
+	"MG": true, "MR": true, "SL": true, "SO": true, "SS": true, "ST": true, "TD": true, "ZW": true,
+	// Asia central y Cáucaso
+	"AF": true, "AZ": true, "BY": true, "KG": true, "TJ": true, "TM": true, "UZ": true,
+	// Oceanía y territorios remotos
+	"CK": true, "FM": true, "KI": true, "MH": true, "NR": true, "NU": true, "PG": true, "PW": true,
+	"SB": true, "TK": true, "TO": true, "TV": true, "VU": true, "WS": true,
+	"AC": true, "FK": true, "IO": true, "SH": true,
+	// Otros
+	"CU": true, "KP": true,
+}
+
+// destinationLabel identifica el destino en los errores: el país o, para redes sin país
+// (satelitales, +881/+882/+883), el prefijo internacional
+func destinationLabel(number *phone.Number) string {
+	if number.Country != "" {
+		return number.Country
+	}
+	if len(number.E164) > 4 {
+		return number.E164[:4]
+	}
+	return number.E164
+}
+
+// checkDestinationCountry aplica las políticas de países del plan y del negocio, y la política por defecto.
+// Una lista de permitidos restringe a esos países; los bloqueados siempre tienen prioridad.
+// Los destinos de alto riesgo y las redes sin país solo se aceptan si el plan los permite explícitamente:
+// la política del negocio se edita con su API Key y solo puede restringir, nunca levantar ese bloqueo.
+func checkDestinationCountry(business *models.Business, plan *models.Plan, channel string, number *phone.Number) error {
+	planPolicy := plan.CountryPolicies[channel]
+	businessPolicy := business.CountryPolicies[channel]
+	country := number.Country
+
+	blocked := fmt.Errorf("destination country not allowed: %s", destinationLabel(number))
+
+	if slices.Contains(planPolicy.Denied, country) || slices.Contains(businessPolicy.Denied, country) {
+		return blocked
+	}
+	if len(planPolicy.Allowed) > 0 && !slices.Contains(planPolicy.Allowed, country) {
+		return blocked
+	}
+	if len(businessPolicy.Allowed) > 0 && !slices.Contains(businessPolicy.Allowed, country) {
+		return blocked
+	}
+
+	if (country == "" || highRiskCountries[country]) && !slices.Contains(planPolicy.Allowed, country) {
+		return blocked
+	}
+
+	return nil
+}
+
+// normalizeCountryPolicy valida los códigos de país y elimina duplicados.
+// Un país no puede estar permitido y bloqueado a la vez.
+func normalizeCountryPolicy(policy models.CountryPolicy) (models.CountryPolicy, error) {
+	normalize := func(codes []string) ([]string, error) {
+		result := []string{}
+		for _, code := range codes {
+			code = strings.ToUpper(strings.TrimSpace(code))
+			if !phone.IsKnownCountry(code) {
+				return nil, fmt.Errorf("invalid country")
+			}
+			if !slices.Contains(result, code) {
+				result = append(result, code)
+			}
+		}
+		return result, nil
+	}
+
+	allowed, err := normalize(policy.Allowed)
+	if err != nil {
+		return models.CountryPolicy{}, err
+	}
+	denied, err := normalize(policy.Denied)
+	if err != nil {
+		return models.CountryPolicy{}, err
+	}
+
+	for _, code := range allowed {
+		if slices.Contains(denied, code) {
+			return models.CountryPolicy{}, fmt.Errorf("invalid country policy")
+		}
+	}
+
+	return models.CountryPolicy{Allowed: allowed, Denied: denied}, nil
+}
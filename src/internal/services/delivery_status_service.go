@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/repository"
+	"notify-backend/internal/utils"
+	"os"
+	"time"
+
+	twilioClient "github.com/twilio/twilio-go/client"
+	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+// TwilioStatusPath es la ruta pública del callback de estado de los mensajes (StatusCallback)
+const TwilioStatusPath = "/v1/status/twilio"
+
+// twilioDeliveryStatuses son los estados de Twilio que se registran; los intermedios
+// (queued, accepted, sending, sent) no cambian el estado "sent" de la notificación
+var twilioDeliveryStatuses = map[string]bool{
+	"delivered":   true,
+	"read":        true, // WhatsApp
+	"undelivered": true,
+	"failed":      true,
+}
+
+// setTwilioStatusCallback pide a Twilio que reporte el estado de entrega del mensaje
+func setTwilioStatusCallback(params *twilioApi.CreateMessageParams) {
+	callbackURL, err := utils.PublicURL(TwilioStatusPath)
+	if err != nil {
+		// Sin URL pública no hay reportes de entrega (ni fallback por no entrega)
+		return
+	}
+	params.SetStatusCallback(callbackURL)
+}
+
+// HandleTwilioStatusService procesa el callback de estado de un mensaje de SMS o WhatsApp:
+// actualiza la notificación y, si pertenece a una cadena, pasa al siguiente canal cuando no se entregó
+func HandleTwilioStatusService(requestURL string, params map[string]string, signature string) error {
+	authToken := os.Getenv("TWILIO_AUTH_TOKEN")
+	if authToken == "" {
+		return fmt.Errorf("service unavailable")
+	}
+
+	validator := twilioClient.NewRequestValidator(authToken)
+	if !validator.Validate(requestURL, params, signature) {
+		return fmt.Errorf("invalid signature")
+	}
+
+	status := params["MessageStatus"]
+	if !twilioDeliveryStatuses[status] {
+		return nil
+	}
+
+	client, _ := db.NewDynamoClient()
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	notification, err := notificationRepo.GetByID(ctx, params["MessageSid"])
+	if err != nil {
+		// Mensajes sin registro (ej: respuestas automáticas a STOP/HELP)
+		return nil
+	}
+
+	// Los callbacks pueden llegar fuera de orden: "read" es el estado final en WhatsApp
+	if notification.Status == "read" {
+		return nil
+	}
+
+	errorMessage := ""
+	if params["ErrorCode"] != "" {
+		errorMessage = "twilio error " + params["ErrorCode"]
+	}
+
+	notification, err = notificationRepo.UpdateStatus(ctx, notification.NotificationID, status, errorMessage, time.Now().UTC().Format(time.RFC3339))
+	if err != nil {
+		fmt.Printf("Failed to update status of %s: %v\n", params["MessageSid"], err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	if notification.ChainID != "" {
+		advanceNotificationChain(ctx, notification)
+	}
+
+	return nil
+}
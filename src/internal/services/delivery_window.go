@@ -0,0 +1,189 @@
+package services
+
+import (
+	"fmt"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
+	"strings"
+	"time"
+)
+
+// Categorías de envío. Los códigos de verificación y los mensajes transaccionales no esperan
+// al horario de envío; el resto (marketing, por defecto) se aplaza si cae fuera de él.
+const (
+	DeliveryCategoryMarketing     = "marketing"
+	DeliveryCategoryTransactional = "transactional"
+	DeliveryCategoryOTP           = "otp"
+)
+
+// countryTimezones son las zonas horarias de los países con reglas de numeración.
+// En los países con varias zonas el envío debe estar dentro del horario en todas (territorio continental).
+var countryTimezones = map[string][]string{
+	"US": {"America/New_York", "America/Chicago", "America/Denver", "America/Los_Angeles"},
+	"CA": {"America/Halifax", "America/Toronto", "America/Winnipeg", "America/Edmonton", "America/Vancouver"},
+	"PR": {"America/Puerto_Rico"},
+	"DO": {"America/Santo_Domingo"},
+
+	"MX": {"America/Cancun", "America/Mexico_City", "America/Mazatlan", "America/Tijuana"},
+	"GT": {"America/Guatemala"},
+	"SV": {"America/El_Salvador"},
+	"HN": {"America/Tegucigalpa"},
+	"NI": {"America/Managua"},
+	"CR": {"America/Costa_Rica"},
+	"PA": {"America/Panama"},
+	"CO": {"America/Bogota"},
+	"VE": {"America/Caracas"},
+	"EC": {"America/Guayaquil"},
+	"PE": {"America/Lima"},
+	"BO": {"America/La_Paz"},
+	"CL": {"America/Santiago"},
+	"AR": {"America/Argentina/Buenos_Aires"},
+	"PY": {"America/Asuncion"},
+	"UY": {"America/Montevideo"},
+	"BR": {"America/Sao_Paulo", "America/Manaus", "America/Rio_Branco"},
+
+	"ES": {"Europe/Madrid", "Atlantic/Canary"},
+	"PT": {"Europe/Lisbon"},
+	"FR": {"Europe/Paris"},
+	"GB": {"Europe/London"},
+	"DE": {"Europe/Berlin"},
+	"IT": {"Europe/Rome"},
+}
+
+// normalizeDeliveryCategory valida la categoría del envío (vacío = marketing)
+func normalizeDeliveryCategory(category string) (string, error) {
+	category = strings.ToLower(strings.TrimSpace(category))
+	switch category {
+	case "":
+		return DeliveryCategoryMarketing, nil
+	case DeliveryCategoryMarketing, DeliveryCategoryTransactional, DeliveryCategoryOTP:
+		return category, nil
+	}
+	return "", fmt.Errorf("invalid category")
+}
+
+// parseClock convierte HH:MM en minutos desde la medianoche
+func parseClock(value string) (int, bool) {
+	clock, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, false
+	}
+	return clock.Hour()*60 + clock.Minute(), true
+}
+
+// normalizeDeliveryWindow valida un horario de envío; nil es sin horario
+func normalizeDeliveryWindow(window *models.DeliveryWindow) (*models.DeliveryWindow, error) {
+	if window == nil {
+		return nil, nil
+	}
+
+	start, okStart := parseClock(window.Start)
+	end, okEnd := parseClock(window.End)
+	if !okStart || !okEnd || start == end {
+		return nil, fmt.Errorf("invalid delivery window")
+	}
+
+	return &models.DeliveryWindow{
+		Start: fmt.Sprintf("%02d:%02d", start/60, start%60),
+		End:   fmt.Sprintf("%02d:%02d", end/60, end%60),
+	}, nil
+}
+
+// recipientLocations retorna las zonas horarias del destinatario: la del contacto o la cita, las del país
+// del teléfono o, si no se conocen, las del país por defecto del negocio (UTC como último recurso)
+func recipientLocations(timezone string, number *phone.Number, defaultCountry string) []*time.Location {
+	if timezone != "" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			return []*time.Location{loc}
+		}
+	}
+
+	zones := countryTimezones[number.Country]
+	if len(zones) == 0 {
+		zones = countryTimezones[defaultCountry]
+	}
+
+	locations := []*time.Location{}
+	for _, zone := range zones {
+		if loc, err := time.LoadLocation(zone); err == nil {
+			locations = append(locations, loc)
+		}
+	}
+	if len(locations) == 0 {
+		locations = append(locations, time.UTC)
+	}
+	return locations
+}
+
+// inDeliveryWindow indica si at es hora permitida en loc
+func inDeliveryWindow(window *models.DeliveryWindow, at time.Time, loc *time.Location) bool {
+	start, _ := parseClock(window.Start)
+	end, _ := parseClock(window.End)
+
+	local := at.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return minute >= start && minute < end
+	}
+	return minute >= start || minute < end
+}
+
+// nextWindowStart retorna el siguiente inicio del horario en loc a partir de at
+func nextWindowStart(window *models.DeliveryWindow, at time.Time, loc *time.Location) time.Time {
+	start, _ := parseClock(window.Start)
+
+	local := at.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), start/60, start%60, 0, 0, loc)
+	if next.Before(at) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, start/60, start%60, 0, 0, loc)
+	}
+	return next
+}
+
+// deliveryTime retorna cuándo enviar un mensaje previsto para at: at si está dentro del horario
+// (o la categoría no lo respeta) o el primer momento posterior permitido en todas las zonas del destinatario.
+// El segundo valor indica si el envío se aplazó.
+func deliveryTime(business *models.Business, window *models.DeliveryWindow, category string, locations []*time.Location, at time.Time) (time.Time, bool) {
+	if window == nil {
+		window = business.DeliveryWindow
+	}
+	if window == nil || category == DeliveryCategoryOTP || category == DeliveryCategoryTransactional {
+		return at, false
+	}
+
+	next := at
+	// Cada ajuste solo avanza: alcanza con recorrer las zonas unas pocas veces
+	for i := 0; i <= 2*len(locations); i++ {
+		moved := false
+		for _, loc := range locations {
+			if !inDeliveryWindow(window, next, loc) {
+				next = nextWindowStart(window, next, loc)
+				moved = true
+			}
+		}
+		if !moved {
+			break
+		}
+	}
+
+	return next.UTC(), !next.Equal(at)
+}
+
+// scheduleInDeliveryWindow aplica el horario de envío a un SMS o WhatsApp previsto para sendAt (cero = ahora).
+// Retorna la fecha de envío programado (cero si se envía ya) y si se aplazó por el horario.
+// En una cadena de canales no se aplaza: el paso falla y se intenta el siguiente canal.
+func scheduleInDeliveryWindow(business *models.Business, window *models.DeliveryWindow, category, timezone string, number *phone.Number, sendAt time.Time, inChain bool) (time.Time, bool, error) {
+	at := sendAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	deliverAt, deferred := deliveryTime(business, window, category, recipientLocations(timezone, number, business.DefaultCountry), at)
+	if !deferred {
+		return sendAt, false, nil
+	}
+	if inChain {
+		return time.Time{}, false, fmt.Errorf("outside delivery window")
+	}
+	return deliverAt, true, nil
+}
@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+
+	"notify-backend/internal/repository"
+
+	"github.com/gabriel-vasile/mimetype"
+)
+
+// defaultAttachmentMaxBytes aplica a planes que no definen attachmentMaxBytes. Decodificado: en base64 ocupa
+// un tercio más y el body de la Lambda admite hasta 6 MB
+const defaultAttachmentMaxBytes = 4 * 1024 * 1024
+
+// EmailAttachment es un adjunto recibido en la API.
+// Se envía el contenido en base64 (content) o la referencia a un archivo subido antes (file_id).
+type EmailAttachment struct {
+	Filename    string `json:"filename"`
+	Content     string `json:"content,omitempty"`      // Contenido en base64
+	FileID      string `json:"file_id,omitempty"`      // ID devuelto por /v1/files
+	ContentType string `json:"content_type,omitempty"` // Opcional, se valida contra el contenido real
+	ContentID   string `json:"content_id,omitempty"`   // Si se indica, es una imagen inline referenciada como cid:{content_id}
+}
+
+// blockedAttachmentTypes son tipos ejecutables que los proveedores de correo suelen rechazar
+var blockedAttachmentTypes = []string{
+	"application/x-msdownload",
+	"application/x-executable",
+	"application/x-elf",
+	"application/x-mach-binary",
+	"application/vnd.microsoft.portable-executable",
+	"application/x-sh",
+	"application/x-bat",
+}
+
+var cidReferenceRegex = regexp.MustCompile(`(?i)cid:([^"'\s)>]+)`)
+
+// contentIDRegex limita content_id a caracteres seguros: va en el header Content-ID del adjunto
+var contentIDRegex = regexp.MustCompile(`^[A-Za-z0-9._@-]{1,128}$`)
+
+// attachmentLimit retorna el tamaño máximo de adjuntos permitido por el plan
+func attachmentLimit(planLimit int) int {
+	if planLimit > 0 {
+		return planLimit
+	}
+	return defaultAttachmentMaxBytes
+}
+
+// detectContentType detecta el MIME type real del contenido.
+// El tipo declarado solo se respeta si es compatible con lo detectado
+// o si la detección no es concluyente (application/octet-stream).
+func detectContentType(data []byte, declared string) string {
+	detected := mimetype.Detect(data)
+	declared = strings.ToLower(strings.TrimSpace(declared))
+
+	if declared != "" {
+		if detected.Is(declared) || detected.Is("application/octet-stream") {
+			return declared
+		}
+	}
+
+	// Quitar parámetros como "; charset=utf-8" para usar el tipo base
+	contentType := detected.String()
+	if semi := strings.Index(contentType, ";"); semi != -1 {
+		contentType = contentType[:semi]
+	}
+	return contentType
+}
+
+// isBlockedContentType indica si el tipo de contenido no se permite como adjunto
+func isBlockedContentType(contentType string) bool {
+	for _, blocked := range blockedAttachmentTypes {
+		if contentType == blocked {
+			return true
+		}
+	}
+	return false
+}
+
+// sanitizeFilename elimina rutas y saltos de línea del nombre de archivo
+func sanitizeFilename(filename string) string {
+	filename = strings.NewReplacer("\r", "", "\n", "", "\\", "/").Replace(filename)
+	filename = strings.TrimSpace(path.Base(filename))
+	if filename == "." || filename == "/" {
+		return ""
+	}
+	return filename
+}
+
+// resolveEmailAttachments decodifica o carga los adjuntos, detecta su tipo y valida
+// el tamaño total contra el límite del plan y las referencias cid: del HTML
+func resolveEmailAttachments(ctx context.Context, fileRepo *repository.FileRepository, businessID string, attachments []EmailAttachment, maxBytes int, htmlBody string) ([]emailAttachment, error) {
+	resolved := make([]emailAttachment, 0, len(attachments))
+	contentIDs := make(map[string]bool)
+	totalSize := 0
+
+	for _, attachment := range attachments {
+		hasContent := attachment.Content != ""
+		hasFile := attachment.FileID != ""
+		if hasContent == hasFile {
+			return nil, fmt.Errorf("invalid attachment")
+		}
+
+		var data []byte
+		filename := sanitizeFilename(attachment.Filename)
+		declaredType := attachment.ContentType
+
+		if hasContent {
+			decoded, err := base64.StdEncoding.DecodeString(attachment.Content)
+			if err != nil {
+				return nil, fmt.Errorf("invalid attachment")
+			}
+			data = decoded
+		} else {
+			file, err := fileRepo.GetByID(ctx, businessID, attachment.FileID)
+			if err != nil {
+				return nil, fmt.Errorf("file not found")
+			}
+			content, err := fileRepo.GetContent(ctx, file)
+			if err != nil {
+				fmt.Printf("Failed to load file %s: %v\n", file.FileID, err)
+				return nil, fmt.Errorf("service unavailable")
+			}
+			data = content
+			if filename == "" {
+				filename = file.Filename
+			}
+			if declaredType == "" {
+				declaredType = file.ContentType
+			}
+		}
+
+		if filename == "" || len(data) == 0 {
+			return nil, fmt.Errorf("invalid attachment")
+		}
+
+		totalSize += len(data)
+		if totalSize > maxBytes {
+			return nil, fmt.Errorf("attachments too large")
+		}
+
+		contentType := detectContentType(data, declaredType)
+		if isBlockedContentType(contentType) {
+			return nil, fmt.Errorf("attachment type not allowed")
+		}
+
+		contentID := strings.Trim(strings.TrimSpace(attachment.ContentID), "<>")
+		if contentID != "" {
+			if !contentIDRegex.MatchString(contentID) {
+				return nil, fmt.Errorf("invalid content id")
+			}
+			if htmlBody == "" || !strings.HasPrefix(contentType, "image/") {
+				return nil, fmt.Errorf("invalid inline image")
+			}
+			contentIDs[contentID] = true
+		}
+
+		resolved = append(resolved, emailAttachment{
+			Filename:    filename,
+			ContentType: contentType,
+			ContentID:   contentID,
+			Data:        data,
+		})
+	}
+
+	// Toda referencia cid: del HTML debe tener su imagen inline
+	for _, match := range cidReferenceRegex.FindAllStringSubmatch(htmlBody, -1) {
+		if !contentIDs[match[1]] {
+			return nil, fmt.Errorf("inline image not found: %s", match[1])
+		}
+	}
+
+	return resolved, nil
+}
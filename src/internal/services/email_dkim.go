@@ -0,0 +1,189 @@
+package services
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dkimKeyBits es el tamaño de las llaves RSA generadas por dominio
+const dkimKeyBits = 2048
+
+// dkimSignedHeaders son los headers que se firman, si están presentes en el mensaje
+var dkimSignedHeaders = []string{
+	"From", "To", "Cc", "Reply-To", "Subject", "Date", "Message-ID", "MIME-Version", "Content-Type",
+}
+
+// dkimSigner firma mensajes con DKIM (RFC 6376) usando rsa-sha256 y canonicalización relaxed/relaxed
+type dkimSigner struct {
+	Domain   string
+	Selector string
+	Key      *rsa.PrivateKey
+}
+
+// generateDKIMKey genera una llave RSA y retorna la privada en PEM y la pública en base64 (valor p= del DNS)
+func generateDKIMKey() (privatePEM string, publicKey string, err error) {
+	key, err := rsa.GenerateKey(rand.Reader, dkimKeyBits)
+	if err != nil {
+		return "", "", err
+	}
+
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", "", err
+	}
+
+	privateBlock := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}
+	return string(pem.EncodeToMemory(privateBlock)), base64.StdEncoding.EncodeToString(publicDER), nil
+}
+
+// newDKIMSigner construye el firmador a partir de la llave guardada en PEM
+func newDKIMSigner(domain, selector, privatePEM string) (*dkimSigner, error) {
+	block, _ := pem.Decode([]byte(privatePEM))
+	if block == nil {
+		return nil, fmt.Errorf("invalid DKIM private key")
+	}
+
+	key, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DKIM private key: %v", err)
+	}
+
+	return &dkimSigner{Domain: domain, Selector: selector, Key: key}, nil
+}
+
+// dkimDNSRecord retorna el valor del registro TXT {selector}._domainkey.{domain}
+func dkimDNSRecord(publicKey string) string {
+	return "v=DKIM1; k=rsa; p=" + publicKey
+}
+
+// Sign calcula la firma de un mensaje ya serializado y lo retorna con el header DKIM-Signature al inicio
+func (s *dkimSigner) Sign(msg []byte) ([]byte, error) {
+	headerEnd := bytes.Index(msg, []byte("\r\n\r\n"))
+	if headerEnd == -1 {
+		return nil, fmt.Errorf("dkim: message without body separator")
+	}
+	headers := parseRawHeaders(string(msg[:headerEnd+2]))
+	body := msg[headerEnd+4:]
+
+	bodyHash := sha256.Sum256(dkimRelaxedBody(body))
+
+	// Cada header se firma una vez, usando la última aparición (RFC 6376 §5.4.2)
+	var signedNames []string
+	var canonical strings.Builder
+	for _, name := range dkimSignedHeaders {
+		raw, ok := lastRawHeader(headers, name)
+		if !ok {
+			continue
+		}
+		signedNames = append(signedNames, strings.ToLower(name))
+		canonical.WriteString(dkimRelaxedHeader(raw))
+		canonical.WriteString("\r\n")
+	}
+
+	value := fmt.Sprintf("v=1; a=rsa-sha256; c=relaxed/relaxed; d=%s; s=%s; t=%d; h=%s; bh=%s; b=",
+		s.Domain, s.Selector, time.Now().Unix(), strings.Join(signedNames, ":"),
+		base64.StdEncoding.EncodeToString(bodyHash[:]))
+
+	// El propio DKIM-Signature (con b= vacío) se incluye al final, sin CRLF
+	canonical.WriteString(dkimRelaxedHeader("DKIM-Signature: " + value))
+
+	digest := sha256.Sum256([]byte(canonical.String()))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.Key, crypto.SHA256, digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("dkim: %v", err)
+	}
+
+	var signed bytes.Buffer
+	writeHeader(&signed, "DKIM-Signature", value+base64.StdEncoding.EncodeToString(signature))
+	signed.Write(msg)
+
+	return signed.Bytes(), nil
+}
+
+// parseRawHeaders separa el bloque de headers en headers completos (con sus continuaciones)
+func parseRawHeaders(block string) []string {
+	var headers []string
+	for _, line := range strings.SplitAfter(block, "\r\n") {
+		if line == "" {
+			continue
+		}
+		if (line[0] == ' ' || line[0] == '\t') && len(headers) > 0 {
+			headers[len(headers)-1] += line
+			continue
+		}
+		headers = append(headers, line)
+	}
+	return headers
+}
+
+// lastRawHeader busca la última aparición de un header (sin el CRLF final)
+func lastRawHeader(headers []string, name string) (string, bool) {
+	for i := len(headers) - 1; i >= 0; i-- {
+		colon := strings.Index(headers[i], ":")
+		if colon != -1 && strings.EqualFold(strings.TrimSpace(headers[i][:colon]), name) {
+			return strings.TrimSuffix(headers[i], "\r\n"), true
+		}
+	}
+	return "", false
+}
+
+// dkimRelaxedHeader aplica la canonicalización relaxed a un header (RFC 6376 §3.4.2)
+func dkimRelaxedHeader(raw string) string {
+	colon := strings.Index(raw, ":")
+	name := strings.ToLower(strings.TrimSpace(raw[:colon]))
+
+	value := strings.ReplaceAll(raw[colon+1:], "\r\n", "")
+	value = strings.TrimSpace(collapseDKIMWhitespace(value))
+
+	return name + ":" + value
+}
+
+// dkimRelaxedBody aplica la canonicalización relaxed al cuerpo (RFC 6376 §3.4.4)
+func dkimRelaxedBody(body []byte) []byte {
+	lines := strings.Split(string(body), "\r\n")
+
+	canonical := make([]string, 0, len(lines))
+	for _, line := range lines {
+		canonical = append(canonical, strings.TrimRight(collapseDKIMWhitespace(line), " "))
+	}
+
+	// Eliminar líneas vacías al final
+	for len(canonical) > 0 && canonical[len(canonical)-1] == "" {
+		canonical = canonical[:len(canonical)-1]
+	}
+	if len(canonical) == 0 {
+		return nil
+	}
+
+	return []byte(strings.Join(canonical, "\r\n") + "\r\n")
+}
+
+// collapseDKIMWhitespace reduce cada secuencia de espacios y tabs a un solo espacio
+func collapseDKIMWhitespace(s string) string {
+	var b strings.Builder
+	inWhitespace := false
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' || s[i] == '\t' {
+			inWhitespace = true
+			continue
+		}
+		if inWhitespace {
+			b.WriteByte(' ')
+			inWhitespace = false
+		}
+		b.WriteByte(s[i])
+	}
+	if inWhitespace {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
@@ -0,0 +1,437 @@
+package services
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// emailMessage representa un email listo para serializarse en formato MIME (RFC 5322 / RFC 2045)
+type emailMessage struct {
+	From      mail.Address
+	To        []string
+	Cc        []string
+	Bcc       []string // Solo se usan en el sobre SMTP, nunca se escriben como header
+	ReplyTo   string
+	Subject   string
+	TextBody  string
+	HTMLBody  string
+	MessageID string // Sin los signos < >
+	Date      time.Time
+
+	Attachments  []emailAttachment // Adjuntos e imágenes inline (ContentID != "")
+	ExtraHeaders []mimeHeader      // Headers personalizados ya validados
+
+	DKIM *dkimSigner // Si no es nil, Bytes() firma el mensaje con la llave del dominio remitente
+}
+
+// emailAttachment es un adjunto ya resuelto y validado, listo para incluirse en el mensaje
+type emailAttachment struct {
+	Filename    string
+	ContentType string
+	ContentID   string // Si no está vacío, la parte es inline y se referencia como cid:{ContentID}
+	Data        []byte
+}
+
+// mimeHeader es un header con orden estable (a diferencia de un map)
+type mimeHeader struct {
+	Key   string
+	Value string
+}
+
+// newEmailMessage construye un mensaje con Message-ID y Date generados.
+// Si el body es HTML se genera automáticamente la alternativa en texto plano.
+func newEmailMessage(from mail.Address, recipients *emailRecipients, subject, body string, isHTML bool) *emailMessage {
+	msg := &emailMessage{
+		From:      from,
+		To:        recipients.To,
+		Cc:        recipients.Cc,
+		Bcc:       recipients.Bcc,
+		ReplyTo:   recipients.ReplyTo,
+		Subject:   subject,
+		MessageID: generateMessageID(from.Address),
+		Date:      time.Now(),
+	}
+
+	if isHTML {
+		msg.HTMLBody = body
+		msg.TextBody = htmlToText(body)
+	} else {
+		msg.TextBody = body
+	}
+
+	return msg
+}
+
+// generateMessageID genera un Message-ID único usando el dominio del remitente
+func generateMessageID(fromAddress string) string {
+	domain := "localhost"
+	if at := strings.LastIndex(fromAddress, "@"); at != -1 && at < len(fromAddress)-1 {
+		domain = fromAddress[at+1:]
+	}
+	return fmt.Sprintf("%s@%s", uuid.New().String(), domain)
+}
+
+// Headers retorna los headers de nivel superior en el orden en que se escriben.
+// Los destinatarios BCC no aparecen en ningún header.
+func (m *emailMessage) Headers() []mimeHeader {
+	headers := []mimeHeader{
+		{"From", m.From.String()},
+		{"To", strings.Join(m.To, ", ")},
+	}
+	if len(m.Cc) > 0 {
+		headers = append(headers, mimeHeader{"Cc", strings.Join(m.Cc, ", ")})
+	}
+	if m.ReplyTo != "" {
+		headers = append(headers, mimeHeader{"Reply-To", m.ReplyTo})
+	}
+
+	headers = append(headers,
+		mimeHeader{"Subject", encodeHeaderValue(m.Subject)},
+		mimeHeader{"Date", m.Date.Format(time.RFC1123Z)},
+		mimeHeader{"Message-ID", "<" + m.MessageID + ">"},
+	)
+	headers = append(headers, m.ExtraHeaders...)
+
+	return append(headers, mimeHeader{"MIME-Version", "1.0"})
+}
+
+// Recipients retorna los destinatarios del sobre SMTP (to + cc + bcc)
+func (m *emailMessage) Recipients() []string {
+	all := make([]string, 0, len(m.To)+len(m.Cc)+len(m.Bcc))
+	all = append(all, m.To...)
+	all = append(all, m.Cc...)
+	return append(all, m.Bcc...)
+}
+
+// Bytes serializa el mensaje completo: headers + cuerpo MIME
+func (m *emailMessage) Bytes() ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, h := range m.Headers() {
+		writeHeader(&buf, h.Key, h.Value)
+	}
+
+	root, err := m.rootEntity()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range entityHeaderOrder {
+		if value := root.header().Get(key); value != "" {
+			writeHeader(&buf, key, value)
+		}
+	}
+	buf.WriteString("\r\n")
+
+	if err := root.writeContent(&buf); err != nil {
+		return nil, err
+	}
+
+	if m.DKIM != nil {
+		return m.DKIM.Sign(buf.Bytes())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// rootEntity arma el árbol MIME según el contenido del mensaje:
+//
+//	multipart/mixed             (solo si hay adjuntos)
+//	├─ multipart/related        (solo si hay imágenes inline)
+//	│  ├─ multipart/alternative (solo si hay HTML)
+//	│  │  ├─ text/plain
+//	│  │  └─ text/html
+//	│  └─ imágenes inline (cid:)
+//	└─ adjuntos
+func (m *emailMessage) rootEntity() (mimeEntity, error) {
+	var body mimeEntity = &textEntity{contentType: "text/plain", body: m.TextBody}
+
+	if m.HTMLBody != "" {
+		alternative, err := newMultipartEntity("alternative")
+		if err != nil {
+			return nil, err
+		}
+		// text/plain primero y text/html al final (la preferida por el cliente)
+		alternative.children = []mimeEntity{
+			&textEntity{contentType: "text/plain", body: m.TextBody},
+			&textEntity{contentType: "text/html", body: m.HTMLBody},
+		}
+		body = alternative
+	}
+
+	var inline, attached []mimeEntity
+	for _, attachment := range m.Attachments {
+		entity := &attachmentEntity{attachment: attachment}
+		if attachment.ContentID != "" {
+			inline = append(inline, entity)
+		} else {
+			attached = append(attached, entity)
+		}
+	}
+
+	if len(inline) > 0 {
+		related, err := newMultipartEntity("related")
+		if err != nil {
+			return nil, err
+		}
+		related.children = append([]mimeEntity{body}, inline...)
+		body = related
+	}
+
+	if len(attached) > 0 {
+		mixed, err := newMultipartEntity("mixed")
+		if err != nil {
+			return nil, err
+		}
+		mixed.children = append([]mimeEntity{body}, attached...)
+		body = mixed
+	}
+
+	return body, nil
+}
+
+// entityHeaderOrder fija el orden de los headers de contenido en el nivel superior
+var entityHeaderOrder = []string{"Content-Type", "Content-Transfer-Encoding", "Content-Disposition", "Content-ID"}
+
+// mimeEntity es una parte MIME que conoce sus headers y sabe escribir su contenido
+type mimeEntity interface {
+	header() textproto.MIMEHeader
+	writeContent(buf *bytes.Buffer) error
+}
+
+// textEntity es una parte de texto con su Content-Transfer-Encoding real
+type textEntity struct {
+	contentType string
+	body        string
+}
+
+func (e *textEntity) header() textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", e.contentType+"; charset=UTF-8")
+	h.Set("Content-Transfer-Encoding", chooseTransferEncoding(e.body))
+	return h
+}
+
+func (e *textEntity) writeContent(buf *bytes.Buffer) error {
+	return writeEncodedBody(buf, e.body, chooseTransferEncoding(e.body))
+}
+
+// attachmentEntity es un adjunto o imagen inline codificado en base64
+type attachmentEntity struct {
+	attachment emailAttachment
+}
+
+func (e *attachmentEntity) header() textproto.MIMEHeader {
+	a := e.attachment
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", mime.FormatMediaType(a.ContentType, map[string]string{"name": a.Filename}))
+	h.Set("Content-Transfer-Encoding", "base64")
+
+	disposition := "attachment"
+	if a.ContentID != "" {
+		disposition = "inline"
+		h.Set("Content-ID", "<"+a.ContentID+">")
+	}
+	h.Set("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": a.Filename}))
+
+	return h
+}
+
+func (e *attachmentEntity) writeContent(buf *bytes.Buffer) error {
+	writeBase64Lines(buf, e.attachment.Data)
+	return nil
+}
+
+// multipartEntity agrupa otras partes bajo un multipart/{subtype}
+type multipartEntity struct {
+	subtype  string
+	boundary string
+	children []mimeEntity
+}
+
+func newMultipartEntity(subtype string) (*multipartEntity, error) {
+	boundary, err := randomBoundary()
+	if err != nil {
+		return nil, err
+	}
+	return &multipartEntity{subtype: subtype, boundary: boundary}, nil
+}
+
+func (e *multipartEntity) header() textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", fmt.Sprintf("multipart/%s; boundary=%q", e.subtype, e.boundary))
+	return h
+}
+
+func (e *multipartEntity) writeContent(buf *bytes.Buffer) error {
+	mw := multipart.NewWriter(buf)
+	if err := mw.SetBoundary(e.boundary); err != nil {
+		return err
+	}
+
+	for _, child := range e.children {
+		part, err := mw.CreatePart(child.header())
+		if err != nil {
+			return err
+		}
+
+		var childBuf bytes.Buffer
+		if err := child.writeContent(&childBuf); err != nil {
+			return err
+		}
+		if _, err := part.Write(childBuf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	return mw.Close()
+}
+
+// chooseTransferEncoding usa quoted-printable para texto mayormente ASCII
+// y base64 cuando la proporción de bytes no ASCII haría crecer demasiado el QP
+func chooseTransferEncoding(body string) string {
+	if body == "" {
+		return "quoted-printable"
+	}
+
+	nonASCII := 0
+	for i := 0; i < len(body); i++ {
+		if body[i] >= 0x80 {
+			nonASCII++
+		}
+	}
+
+	if nonASCII*3 > len(body) {
+		return "base64"
+	}
+	return "quoted-printable"
+}
+
+// writeEncodedBody escribe el body codificado según el Content-Transfer-Encoding indicado
+func writeEncodedBody(buf *bytes.Buffer, body, encoding string) error {
+	// Normalizar saltos de línea a CRLF como exige SMTP
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.ReplaceAll(body, "\n", "\r\n")
+
+	switch encoding {
+	case "base64":
+		writeBase64Lines(buf, []byte(body))
+		return nil
+	default:
+		qp := quotedprintable.NewWriter(buf)
+		if _, err := qp.Write([]byte(body)); err != nil {
+			return err
+		}
+		if err := qp.Close(); err != nil {
+			return err
+		}
+		buf.WriteString("\r\n")
+		return nil
+	}
+}
+
+// writeBase64Lines escribe datos en base64 con líneas de 76 caracteres (RFC 2045)
+func writeBase64Lines(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	if encoded != "" {
+		buf.WriteString(encoded + "\r\n")
+	}
+}
+
+// encodeHeaderValue aplica RFC 2047 (encoded-words) solo si el valor tiene caracteres no ASCII
+func encodeHeaderValue(value string) string {
+	return mime.QEncoding.Encode("UTF-8", value)
+}
+
+// writeHeader escribe un header plegando líneas largas en los espacios (RFC 5322 §2.2.3)
+func writeHeader(buf *bytes.Buffer, key, value string) {
+	line := key + ": "
+	lineLen := len(line)
+	buf.WriteString(line)
+
+	words := strings.Split(value, " ")
+	for i, word := range words {
+		if i > 0 {
+			if lineLen+1+len(word) > 76 {
+				buf.WriteString("\r\n ")
+				lineLen = 1
+			} else {
+				buf.WriteString(" ")
+				lineLen++
+			}
+		}
+		buf.WriteString(word)
+		lineLen += len(word)
+	}
+
+	buf.WriteString("\r\n")
+}
+
+// randomBoundary genera un boundary aleatorio para partes multipart
+func randomBoundary() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "=_notify_" + hex.EncodeToString(b), nil
+}
+
+var (
+	htmlDropBlocksRegex = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlLinkRegex       = regexp.MustCompile(`(?is)<a\s[^>]*href\s*=\s*["']([^"']+)["'][^>]*>(.*?)</a>`)
+	htmlLineBreakRegex  = regexp.MustCompile(`(?i)<br\s*/?>`)
+	htmlBlockEndRegex   = regexp.MustCompile(`(?i)</(p|div|h[1-6]|li|tr|table|ul|ol|blockquote)>`)
+	htmlListItemRegex   = regexp.MustCompile(`(?i)<li[^>]*>`)
+	htmlTagRegex        = regexp.MustCompile(`(?s)<[^>]+>`)
+	blankLinesRegex     = regexp.MustCompile(`\n{3,}`)
+)
+
+// htmlToText genera una versión en texto plano legible a partir de un body HTML
+func htmlToText(htmlBody string) string {
+	text := htmlDropBlocksRegex.ReplaceAllString(htmlBody, "")
+
+	// Los links conservan la URL entre paréntesis para que sigan siendo usables
+	text = htmlLinkRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := htmlLinkRegex.FindStringSubmatch(match)
+		href := parts[1]
+		label := strings.TrimSpace(htmlTagRegex.ReplaceAllString(parts[2], ""))
+		if label == "" || label == href {
+			return href
+		}
+		return fmt.Sprintf("%s (%s)", label, href)
+	})
+
+	text = htmlLineBreakRegex.ReplaceAllString(text, "\n")
+	text = htmlBlockEndRegex.ReplaceAllString(text, "\n")
+	text = htmlListItemRegex.ReplaceAllString(text, "- ")
+	text = htmlTagRegex.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+
+	// Normalizar espacios por línea
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	text = strings.Join(lines, "\n")
+	text = blankLinesRegex.ReplaceAllString(text, "\n\n")
+
+	return strings.TrimSpace(text)
+}
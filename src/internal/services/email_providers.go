@@ -0,0 +1,403 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+// Proveedores de email soportados (EMAIL_PROVIDER o emailProvider del negocio)
+const (
+	emailProviderSMTP     = "smtp"
+	emailProviderSES      = "ses"
+	emailProviderSendGrid = "sendgrid"
+)
+
+const defaultEmailProviderTimeout = 10 * time.Second
+
+// emailProvider entrega un email ya construido y retorna el ID asignado por el proveedor
+type emailProvider interface {
+	Name() string
+	Send(ctx context.Context, email *emailMessage) (string, error)
+}
+
+// emailProviderError normaliza los errores de los proveedores HTTP
+type emailProviderError struct {
+	Provider   string
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *emailProviderError) Error() string {
+	return fmt.Sprintf("%s error (status %d, code %s): %s", e.Provider, e.StatusCode, e.Code, e.Message)
+}
+
+// Throttled indica que el proveedor rechazó el envío por límite de velocidad
+func (e *emailProviderError) Throttled() bool {
+	return e.StatusCode == http.StatusTooManyRequests
+}
+
+// Rejected indica un rechazo permanente del mensaje (reintentar no sirve)
+func (e *emailProviderError) Rejected() bool {
+	return e.StatusCode >= 400 && e.StatusCode < 500 && !e.Throttled() &&
+		e.StatusCode != http.StatusUnauthorized && e.StatusCode != http.StatusForbidden
+}
+
+// IsValidEmailProvider indica si el nombre corresponde a un proveedor soportado
+func IsValidEmailProvider(name string) bool {
+	return name == emailProviderSMTP || name == emailProviderSES || name == emailProviderSendGrid
+}
+
+// emailFromAddress retorna la dirección remitente de la plataforma
+func emailFromAddress() string {
+	return getEnv("EMAIL_FROM", getEnv("SMTP_USER", ""))
+}
+
+// resolveEmailProvider elige el proveedor del negocio o, si no tiene, el de la plataforma (EMAIL_PROVIDER)
+func resolveEmailProvider(ctx context.Context, businessProvider string) (emailProvider, error) {
+	name := businessProvider
+	if name == "" {
+		name = strings.ToLower(getEnv("EMAIL_PROVIDER", emailProviderSMTP))
+	}
+
+	httpClient := &http.Client{Timeout: defaultEmailProviderTimeout}
+
+	switch name {
+	case emailProviderSMTP:
+		// Sin SMTP_USER se envía sin autenticar (relay); el remitente sale de EMAIL_FROM
+		cfg := loadSMTPConfig()
+		if err := cfg.Validate(); err != nil {
+			return nil, fmt.Errorf("smtp provider not configured: %v", err)
+		}
+		return &smtpProvider{cfg: cfg}, nil
+
+	case emailProviderSES:
+		region := getEnv("SES_REGION", getEnv("AWS_REGION", "us-east-1"))
+		awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+		if err != nil {
+			return nil, fmt.Errorf("ses provider not configured: %v", err)
+		}
+		endpoint := getEnv("SES_ENDPOINT", fmt.Sprintf("https://email.%s.amazonaws.com", region))
+		return newSESProvider(endpoint, region, awsCfg.Credentials, httpClient), nil
+
+	case emailProviderSendGrid:
+		apiKey := getEnv("SENDGRID_API_KEY", "")
+		if apiKey == "" {
+			return nil, fmt.Errorf("sendgrid provider not configured")
+		}
+		endpoint := getEnv("SENDGRID_ENDPOINT", "https://api.sendgrid.com")
+		return newSendGridProvider(endpoint, apiKey, httpClient), nil
+	}
+
+	return nil, fmt.Errorf("unknown email provider: %s", name)
+}
+
+// smtpProvider entrega por SMTP; el ID es el Message-ID generado por nosotros
+type smtpProvider struct {
+	cfg smtpConfig
+}
+
+func (p *smtpProvider) Name() string {
+	return emailProviderSMTP
+}
+
+func (p *smtpProvider) Send(ctx context.Context, email *emailMessage) (string, error) {
+	if err := sendEmailSMTP(p.cfg, email); err != nil {
+		return "", err
+	}
+	return email.MessageID, nil
+}
+
+// sesProvider usa la API HTTP de SES v2 (SendEmail con contenido Raw), firmada con SigV4
+type sesProvider struct {
+	endpoint    string
+	region      string
+	credentials aws.CredentialsProvider
+	httpClient  *http.Client
+	signer      *v4.Signer
+}
+
+func newSESProvider(endpoint, region string, credentials aws.CredentialsProvider, httpClient *http.Client) *sesProvider {
+	return &sesProvider{
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		region:      region,
+		credentials: credentials,
+		httpClient:  httpClient,
+		signer:      v4.NewSigner(),
+	}
+}
+
+func (p *sesProvider) Name() string {
+	return emailProviderSES
+}
+
+type sesDestination struct {
+	ToAddresses  []string `json:"ToAddresses,omitempty"`
+	CcAddresses  []string `json:"CcAddresses,omitempty"`
+	BccAddresses []string `json:"BccAddresses,omitempty"`
+}
+
+type sesSendEmailRequest struct {
+	FromEmailAddress string         `json:"FromEmailAddress"`
+	Destination      sesDestination `json:"Destination"`
+	Content          struct {
+		Raw struct {
+			Data string `json:"Data"`
+		} `json:"Raw"`
+	} `json:"Content"`
+}
+
+func (p *sesProvider) Send(ctx context.Context, email *emailMessage) (string, error) {
+	raw, err := email.Bytes()
+	if err != nil {
+		return "", fmt.Errorf("failed to build message: %v", err)
+	}
+
+	// Con contenido Raw los BCC solo pueden ir en Destination, nunca en los headers
+	payload := sesSendEmailRequest{
+		FromEmailAddress: email.From.String(),
+		Destination: sesDestination{
+			ToAddresses:  email.To,
+			CcAddresses:  email.Cc,
+			BccAddresses: email.Bcc,
+		},
+	}
+	payload.Content.Raw.Data = base64.StdEncoding.EncodeToString(raw)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v2/email/outbound-emails", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	creds, err := p.credentials.Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("ses credentials: %v", err)
+	}
+
+	payloadHash := sha256.Sum256(body)
+	if err := p.signer.SignHTTP(ctx, creds, req, hex.EncodeToString(payloadHash[:]), "ses", p.region, time.Now()); err != nil {
+		return "", fmt.Errorf("ses sign request: %v", err)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("ses request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode != http.StatusOK {
+		var errBody struct {
+			Message string `json:"message"`
+			Type    string `json:"__type"`
+		}
+		json.Unmarshal(respBody, &errBody)
+
+		// El código viene como "MessageRejected:http://internal.amazon.com/..." o en __type
+		code := resp.Header.Get("X-Amzn-ErrorType")
+		if code == "" {
+			code = errBody.Type
+		}
+		if colon := strings.Index(code, ":"); colon != -1 {
+			code = code[:colon]
+		}
+
+		return "", &emailProviderError{
+			Provider:   emailProviderSES,
+			StatusCode: resp.StatusCode,
+			Code:       code,
+			Message:    errBody.Message,
+		}
+	}
+
+	var result struct {
+		MessageID string `json:"MessageId"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil || result.MessageID == "" {
+		return "", fmt.Errorf("ses invalid response: %s", string(respBody))
+	}
+
+	return result.MessageID, nil
+}
+
+// sendGridProvider usa la API JSON v3 de SendGrid (/v3/mail/send). La API no acepta el mensaje MIME, así que
+// la firma DKIM del dominio remitente (email.DKIM) no se aplica: SendGrid firma con su autenticación de dominio
+type sendGridProvider struct {
+	endpoint   string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func newSendGridProvider(endpoint, apiKey string, httpClient *http.Client) *sendGridProvider {
+	return &sendGridProvider{
+		endpoint:   strings.TrimRight(endpoint, "/"),
+		apiKey:     apiKey,
+		httpClient: httpClient,
+	}
+}
+
+func (p *sendGridProvider) Name() string {
+	return emailProviderSendGrid
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridPersonalization struct {
+	To  []sendGridAddress `json:"to"`
+	Cc  []sendGridAddress `json:"cc,omitempty"`
+	Bcc []sendGridAddress `json:"bcc,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+type sendGridMailRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	ReplyTo          *sendGridAddress          `json:"reply_to,omitempty"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+	Attachments      []sendGridAttachment      `json:"attachments,omitempty"`
+	Headers          map[string]string         `json:"headers,omitempty"`
+	CustomArgs       map[string]string         `json:"custom_args,omitempty"`
+}
+
+func sendGridAddresses(addresses []string) []sendGridAddress {
+	if len(addresses) == 0 {
+		return nil
+	}
+	result := make([]sendGridAddress, 0, len(addresses))
+	for _, address := range addresses {
+		result = append(result, sendGridAddress{Email: address})
+	}
+	return result
+}
+
+func (p *sendGridProvider) Send(ctx context.Context, email *emailMessage) (string, error) {
+	payload := sendGridMailRequest{
+		Personalizations: []sendGridPersonalization{{
+			To:  sendGridAddresses(email.To),
+			Cc:  sendGridAddresses(email.Cc),
+			Bcc: sendGridAddresses(email.Bcc),
+		}},
+		From:    sendGridAddress{Email: email.From.Address, Name: email.From.Name},
+		Subject: email.Subject,
+		// Permite correlacionar eventos de SendGrid con nuestro notification_id
+		CustomArgs: map[string]string{"notification_id": email.MessageID},
+	}
+
+	// SendGrid exige text/plain antes de text/html y rechaza partes vacías
+	if email.TextBody != "" || email.HTMLBody == "" {
+		payload.Content = append(payload.Content, sendGridContent{Type: "text/plain", Value: email.TextBody})
+	}
+	if email.HTMLBody != "" {
+		payload.Content = append(payload.Content, sendGridContent{Type: "text/html", Value: email.HTMLBody})
+	}
+	if email.ReplyTo != "" {
+		payload.ReplyTo = &sendGridAddress{Email: email.ReplyTo}
+	}
+	if len(email.ExtraHeaders) > 0 {
+		payload.Headers = make(map[string]string, len(email.ExtraHeaders))
+		for _, h := range email.ExtraHeaders {
+			payload.Headers[h.Key] = h.Value
+		}
+	}
+	for _, attachment := range email.Attachments {
+		disposition := "attachment"
+		if attachment.ContentID != "" {
+			disposition = "inline"
+		}
+		payload.Attachments = append(payload.Attachments, sendGridAttachment{
+			Content:     base64.StdEncoding.EncodeToString(attachment.Data),
+			Type:        attachment.ContentType,
+			Filename:    attachment.Filename,
+			Disposition: disposition,
+			ContentID:   attachment.ContentID,
+		})
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/v3/mail/send", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("sendgrid request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	// SendGrid responde 202 Accepted sin body y con el ID en X-Message-Id
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusOK {
+		var errBody struct {
+			Errors []struct {
+				Message string `json:"message"`
+				Field   string `json:"field"`
+			} `json:"errors"`
+		}
+		json.Unmarshal(respBody, &errBody)
+
+		providerErr := &emailProviderError{
+			Provider:   emailProviderSendGrid,
+			StatusCode: resp.StatusCode,
+			Code:       http.StatusText(resp.StatusCode),
+		}
+		if len(errBody.Errors) > 0 {
+			providerErr.Message = errBody.Errors[0].Message
+			if errBody.Errors[0].Field != "" {
+				providerErr.Code = errBody.Errors[0].Field
+			}
+		}
+		return "", providerErr
+	}
+
+	// Los eventos de SendGrid se identifican con este ID, no con nuestro Message-ID
+	messageID := resp.Header.Get("X-Message-Id")
+	if messageID == "" {
+		return "", fmt.Errorf("sendgrid invalid response: missing X-Message-Id")
+	}
+
+	return messageID, nil
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"sort"
+	"strings"
+)
+
+// maxEmailRecipients limita la suma de to + cc + bcc por mensaje
+const maxEmailRecipients = 50
+
+// EmailAddressList acepta en JSON tanto un string ("a@x.com") como un arreglo (["a@x.com", "b@x.com"]),
+// así los clientes que enviaban un único "to" siguen funcionando.
+type EmailAddressList []string
+
+func (l *EmailAddressList) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if single == "" {
+			*l = EmailAddressList{}
+		} else {
+			*l = EmailAddressList{single}
+		}
+		return nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return fmt.Errorf("email address list must be a string or an array of strings")
+	}
+	*l = list
+	return nil
+}
+
+// reservedEmailHeaders no pueden definirse como headers personalizados
+var reservedEmailHeaders = map[string]bool{
+	"from":                      true,
+	"to":                        true,
+	"cc":                        true,
+	"bcc":                       true,
+	"reply-to":                  true,
+	"subject":                   true,
+	"date":                      true,
+	"message-id":                true,
+	"mime-version":              true,
+	"content-type":              true,
+	"content-transfer-encoding": true,
+	"content-disposition":       true,
+	"content-id":                true,
+	"return-path":               true,
+	"received":                  true,
+	"sender":                    true,
+	"dkim-signature":            true,
+	"list-unsubscribe":          true, // Se generan a partir de la lista de supresión
+	"list-unsubscribe-post":     true,
+}
+
+// emailRecipients es el resultado de validar y normalizar los destinatarios de un email
+type emailRecipients struct {
+	To      []string
+	Cc      []string
+	Bcc     []string
+	ReplyTo string
+}
+
+// Count retorna el número de destinatarios únicos (unidades de cuota que consume el mensaje)
+func (r *emailRecipients) Count() int {
+	return len(r.To) + len(r.Cc) + len(r.Bcc)
+}
+
+// validateEmailAddress valida una dirección simple (sin nombre visible) y la retorna normalizada
+func validateEmailAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	parsed, err := mail.ParseAddress(address)
+	if err != nil || parsed.Address != address || !strings.Contains(address[strings.LastIndex(address, "@"):], ".") {
+		return "", fmt.Errorf("invalid email address: %s", address)
+	}
+	return parsed.Address, nil
+}
+
+// validateEmailRecipients valida cada destinatario y elimina duplicados entre to, cc y bcc.
+// Si una dirección aparece en varias listas se conserva la de mayor visibilidad (to > cc > bcc).
+func validateEmailRecipients(to, cc, bcc []string, replyTo string) (*emailRecipients, error) {
+	recipients := &emailRecipients{}
+	seen := make(map[string]bool)
+
+	addAll := func(addresses []string, target *[]string) error {
+		for _, address := range addresses {
+			normalized, err := validateEmailAddress(address)
+			if err != nil {
+				return err
+			}
+			key := strings.ToLower(normalized)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			*target = append(*target, normalized)
+		}
+		return nil
+	}
+
+	if err := addAll(to, &recipients.To); err != nil {
+		return nil, err
+	}
+	if err := addAll(cc, &recipients.Cc); err != nil {
+		return nil, err
+	}
+	if err := addAll(bcc, &recipients.Bcc); err != nil {
+		return nil, err
+	}
+
+	if len(recipients.To) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	if recipients.Count() > maxEmailRecipients {
+		return nil, fmt.Errorf("too many recipients")
+	}
+
+	if replyTo != "" {
+		normalized, err := validateEmailAddress(replyTo)
+		if err != nil {
+			return nil, err
+		}
+		recipients.ReplyTo = normalized
+	}
+
+	return recipients, nil
+}
+
+// validateCustomHeaders valida nombres y valores de headers personalizados y los retorna en orden estable
+func validateCustomHeaders(headers map[string]string) ([]mimeHeader, error) {
+	validated := make([]mimeHeader, 0, len(headers))
+
+	for name, value := range headers {
+		name = strings.TrimSpace(name)
+		if name == "" || !isHeaderFieldName(name) {
+			return nil, fmt.Errorf("invalid header: %s", name)
+		}
+		lower := strings.ToLower(name)
+		if reservedEmailHeaders[lower] {
+			return nil, fmt.Errorf("header not allowed: %s", name)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return nil, fmt.Errorf("invalid header: %s", name)
+		}
+		validated = append(validated, mimeHeader{Key: name, Value: encodeHeaderValue(value)})
+	}
+
+	// Orden alfabético para que el mensaje generado sea determinista
+	sort.Slice(validated, func(i, j int) bool {
+		return validated[i].Key < validated[j].Key
+	})
+
+	return validated, nil
+}
+
+// isHeaderFieldName valida el nombre de un header según RFC 5322 (ASCII imprimible sin ':')
+func isHeaderFieldName(name string) bool {
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c < 33 || c > 126 || c == ':' {
+			return false
+		}
+	}
+	return true
+}
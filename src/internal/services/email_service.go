@@ -2,28 +2,52 @@ package services
 
 import (
 	"context"
-	"crypto/tls"
 	"fmt"
-	"net/smtp"
+	"html"
 	"notify-backend/internal/db"
+	"notify-backend/internal/models"
 	"notify-backend/internal/repository"
 	"os"
-	"strings"
 	"time"
 )
 
 type SendEmailRequest struct {
-	To      string `json:"to"`
-	Subject string `json:"subject"`
-	Body    string `json:"body"`
-	HTML    bool   `json:"html"` // Si el body es HTML o texto plano
+	To      []string          `json:"to"`
+	Cc      []string          `json:"cc,omitempty"`
+	Bcc     []string          `json:"bcc,omitempty"` // Nunca se incluyen en los headers del mensaje
+	ReplyTo string            `json:"reply_to,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"` // Headers personalizados (ej: X-Campaign)
+	Subject string            `json:"subject"`
+	Body    string            `json:"body"`
+	HTML    bool              `json:"html"` // Si el body es HTML o texto plano
+
+	From     string `json:"from,omitempty"`      // Remitente de un dominio verificado (vacío = remitente por defecto)
+	FromName string `json:"from_name,omitempty"` // Nombre visible (vacío = el del dominio o el del negocio)
+
+	TemplateID  string `json:"template_id,omitempty"` // Agrupa las métricas de tracking (ej. "bienvenida")
+	TrackOpens  bool   `json:"track_opens"`           // Inserta un pixel de apertura (solo HTML)
+	TrackClicks bool   `json:"track_clicks"`          // Reescribe los enlaces para contar clics (solo HTML)
+
+	Attachments []EmailAttachment `json:"attachments,omitempty"` // Adjuntos e imágenes inline
+
+	ContactID string `json:"contact_id,omitempty"` // En lugar de to: se envía al email del contacto
+
+	SendAt      string `json:"send_at,omitempty"` // RFC3339: se programa en lugar de enviarse
+	scheduledID string // Envío programado que está enviando el worker (cuota ya reservada)
 }
 
 type SendEmailResponse struct {
 	Success           bool   `json:"success"`
-	NotificationID    string `json:"notification_id"`
+	NotificationID    string `json:"notification_id"`     // Message-ID del email
+	Provider          string `json:"provider"`            // smtp, ses, sendgrid
+	ProviderMessageID string `json:"provider_message_id"` // ID asignado por el proveedor
+	Recipients        int    `json:"recipients"`          // Destinatarios únicos (to + cc + bcc), cada uno consume una notificación
 	NotificationCount int    `json:"notification_count"`
 	NotificationLeft  int    `json:"notification_left"`
+
+	// Solo en envíos programados
+	Status string `json:"status,omitempty"` // scheduled
+	SendAt string `json:"send_at,omitempty"`
 }
 
 // getEnv obtiene una variable de entorno o devuelve un valor por defecto
@@ -35,100 +59,57 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-// sendEmailSMTP envía un email usando SMTP (Gmail)
-func sendEmailSMTP(host, port, username, password, to, subject, body string, isHTML bool, fromName string) error {
-	// Configurar autenticación
-	auth := smtp.PlainAuth("", username, password, host)
-
-	// Construir el mensaje con formato correcto
-	contentType := "text/plain"
-	if isHTML {
-		contentType = "text/html"
-	}
-
-	// Usar formato RFC 5322 correcto
-	from := username
-	if fromName != "" {
-		from = fmt.Sprintf("%s <%s>", fromName, username)
+// sendEmailSMTP serializa y envía un email ya construido con el transporte SMTP configurado
+func sendEmailSMTP(cfg smtpConfig, email *emailMessage) error {
+	// Serializar el mensaje MIME (headers ordenados, RFC 2047, Message-ID y Date)
+	msg, err := email.Bytes()
+	if err != nil {
+		return fmt.Errorf("failed to build message: %v", err)
 	}
 
-	// Construir headers del email
-	headers := make(map[string]string)
-	headers["From"] = from
-	headers["To"] = to
-	headers["Subject"] = subject
-	headers["MIME-Version"] = "1.0"
-	headers["Content-Type"] = fmt.Sprintf("%s; charset=UTF-8", contentType)
-	headers["Content-Transfer-Encoding"] = "quoted-printable"
+	return sendSMTP(cfg, email.From.Address, email.Recipients(), msg)
+}
 
-	// Construir el mensaje completo
-	message := ""
-	for key, value := range headers {
-		message += fmt.Sprintf("%s: %s\r\n", key, value)
+// buildTemplateEmailBody sustituye los parámetros en el cuerpo de una plantilla de email. En plantillas HTML los
+// valores se escapan: los envía el cliente y no deben insertar marcado ni scripts en el mensaje.
+func buildTemplateEmailBody(template *models.Template, params map[string]string) string {
+	if !template.HTML {
+		return buildSMSMessage(template.Description, params)
 	}
-	message += "\r\n" + body
 
-	msg := []byte(message)
-
-	// Para Gmail, necesitamos usar STARTTLS
-	if host == "smtp.gmail.com" {
-		return sendWithSTARTTLS(host, port, auth, username, []string{to}, msg)
+	escaped := make(map[string]string, len(params))
+	for key, value := range params {
+		escaped[key] = html.EscapeString(value)
 	}
-
-	// Para otros servidores SMTP estándar
-	addr := fmt.Sprintf("%s:%s", host, port)
-	return smtp.SendMail(addr, auth, username, []string{to}, msg)
+	return buildSMSMessage(template.Description, escaped)
 }
 
-// sendWithSTARTTLS envía email usando STARTTLS (requerido por Gmail)
-func sendWithSTARTTLS(host, port string, auth smtp.Auth, from string, to []string, msg []byte) error {
-	addr := fmt.Sprintf("%s:%s", host, port)
-
-	// Conectar al servidor
-	conn, err := smtp.Dial(addr)
+// sendEmailWithProvider envía el email por el proveedor y retorna su ID de mensaje.
+// Es el envío de SendEmailService y del envío por lotes.
+func sendEmailWithProvider(ctx context.Context, provider emailProvider, email *emailMessage) (string, error) {
+	providerMessageID, err := provider.Send(ctx, email)
 	if err != nil {
-		return fmt.Errorf("failed to connect: %v", err)
-	}
-	defer conn.Close()
-	if err = conn.Hello("localhost"); err != nil {
-		return fmt.Errorf("failed EHLO: %v", err)
-	}
-
-	// Iniciar TLS
-	fmt.Printf("   → Starting TLS...\n")
-	tlsConfig := &tls.Config{
-		ServerName: host,
-	}
-	if err = conn.StartTLS(tlsConfig); err != nil {
-		return fmt.Errorf("failed STARTTLS: %v", err)
-	}
-
-	if err = conn.Auth(auth); err != nil {
-		return fmt.Errorf("failed authentication: %v", err)
-	}
-
-	if err = conn.Mail(from); err != nil {
-		return fmt.Errorf("failed MAIL: %v", err)
+		fmt.Printf("❌ Failed to send email via %s: %v\n", provider.Name(), err)
+		return "", mapEmailProviderError(err)
 	}
+	return providerMessageID, nil
+}
 
-	for _, recipient := range to {
-		if err = conn.Rcpt(recipient); err != nil {
-			return fmt.Errorf("failed RCPT: %v", err)
-		}
+// mapEmailProviderError traduce errores del proveedor a los errores públicos de la API
+func mapEmailProviderError(err error) error {
+	providerErr, ok := err.(*emailProviderError)
+	if !ok {
+		return fmt.Errorf("failed to send notification")
 	}
 
-	writer, err := conn.Data()
-	if err != nil {
-		return fmt.Errorf("failed DATA: %v", err)
+	if providerErr.Throttled() {
+		return fmt.Errorf("email provider busy")
 	}
-	defer writer.Close()
-
-	if _, err = writer.Write(msg); err != nil {
-		return fmt.Errorf("failed to write message: %v", err)
+	if providerErr.Rejected() {
+		return fmt.Errorf("email rejected by provider")
 	}
-	fmt.Printf("   ✓ Message data sent\n")
 
-	return nil
+	return fmt.Errorf("failed to send notification")
 }
 
 func SendEmailService(apiKey string, req SendEmailRequest) (*SendEmailResponse, error) {
@@ -136,8 +117,17 @@ func SendEmailService(apiKey string, req SendEmailRequest) (*SendEmailResponse,
 	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
 	planRepo := repository.NewPlanRepository(client, "NotificationService")
 	usageRepo := repository.NewUsageRepository(client, "NotificationService")
+	fileRepo := repository.NewFileRepository(client, "NotificationService")
+	domainRepo := repository.NewSenderDomainRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+	suppressionRepo := repository.NewSuppressionRepository(client, "NotificationService")
 	ctx := context.TODO()
 
+	sendAt, err := parseSendAt(req.SendAt)
+	if err != nil {
+		return nil, err
+	}
+
 	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
 	if err != nil {
 		return nil, fmt.Errorf("authentication failed")
@@ -157,14 +147,38 @@ func SendEmailService(apiKey string, req SendEmailRequest) (*SendEmailResponse,
 		return nil, fmt.Errorf("service unavailable")
 	}
 
-	// Verificar límite de notificaciones
-	if usage.NotificationCount >= plan.NotificationLimit {
+	// Con contact_id se envía al email del contacto (si no retiró el consentimiento)
+	if req.ContactID != "" {
+		if len(req.To) > 0 {
+			return nil, fmt.Errorf("contact_id cannot be combined with to")
+		}
+		recipient, err := resolveContactRecipient(ctx, client, businessID, req.ContactID, NotificationTypeEmail, "", "", "", nil)
+		if err != nil {
+			return nil, err
+		}
+		req.To = []string{recipient.To}
+	}
+
+	// Validar destinatarios (to, cc, bcc) y reply-to
+	recipients, err := validateEmailRecipients(req.To, req.Cc, req.Bcc, req.ReplyTo)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rechazar destinatarios dados de baja antes de consumir cuota
+	allRecipients := append(append(append([]string{}, recipients.To...), recipients.Cc...), recipients.Bcc...)
+	if err := checkSuppressed(ctx, suppressionRepo, businessID, models.SuppressionChannelEmail, allRecipients); err != nil {
+		return nil, err
+	}
+
+	// Cada destinatario único consume una notificación del plan (un envío programado ya reservó su cuota)
+	if req.scheduledID == "" && usage.NotificationCount+recipients.Count() > plan.NotificationLimit {
 		return nil, fmt.Errorf("notification limit reached")
 	}
 
-	// Validar email del destinatario
-	if !strings.Contains(req.To, "@") || !strings.Contains(req.To, ".") {
-		return nil, fmt.Errorf("invalid email address")
+	customHeaders, err := validateCustomHeaders(req.Headers)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validaciones adicionales
@@ -174,39 +188,114 @@ func SendEmailService(apiKey string, req SendEmailRequest) (*SendEmailResponse,
 	if req.Body == "" {
 		return nil, fmt.Errorf("body is required")
 	}
+	if req.TemplateID != "" && !templateIDRegex.MatchString(req.TemplateID) {
+		return nil, fmt.Errorf("invalid template id")
+	}
+	if (req.TrackOpens || req.TrackClicks) && !req.HTML {
+		return nil, fmt.Errorf("tracking requires an html body")
+	}
 
-	// Enviar email por SMTP
-	var notificationID string
-	smtpHost := getEnv("SMTP_HOST", "")
-	smtpPort := getEnv("SMTP_PORT", "")
-	smtpUser := getEnv("SMTP_USER", "")
-	smtpPass := getEnv("SMTP_PASSWORD", "")
+	// Elegir proveedor: el configurado por el negocio o el de la plataforma
+	provider, err := resolveEmailProvider(ctx, business.EmailProvider)
+	if err != nil {
+		fmt.Printf("Email provider error: %v\n", err)
+		return nil, fmt.Errorf("email service not configured")
+	}
+
+	// Remitente: dominio verificado del negocio (firmado con DKIM) o el de la plataforma
+	from, signer, err := resolveEmailSender(ctx, domainRepo, business, req.From, req.FromName)
+	if err != nil {
+		return nil, err
+	}
+	if from.Address == "" {
+		return nil, fmt.Errorf("email service not configured")
+	}
 
+	// Construir el mensaje MIME con adjuntos e imágenes inline
+	email := newEmailMessage(from, recipients, req.Subject, req.Body, req.HTML)
+	email.ExtraHeaders = customHeaders
+	email.DKIM = signer
 
-	if smtpUser != "" && smtpPass != "" {
-		// Envío real por SMTP
-		err := sendEmailSMTP(smtpHost, smtpPort, smtpUser, smtpPass, req.To, req.Subject, req.Body, req.HTML, business.Name)
+	// Baja de un clic (RFC 8058): solo con un destinatario, ya que el enlace identifica a quien se da de baja
+	if recipients.Count() == 1 {
+		unsubscribeHeaders, err := listUnsubscribeHeaders(businessID, recipients.To[0])
 		if err != nil {
-			fmt.Printf("❌ Failed to send email: %v\n", err)
-			return nil, fmt.Errorf("failed to send notification")
+			fmt.Printf("List-Unsubscribe not added: %v\n", err)
+		} else {
+			email.ExtraHeaders = append(email.ExtraHeaders, unsubscribeHeaders...)
 		}
-		notificationID = fmt.Sprintf("EMAIL_%d", time.Now().UnixNano())
-		fmt.Printf("✅ Email sent successfully!\n")
-		fmt.Printf("   Message ID: %s\n", notificationID)
-	} else {
-		return nil, fmt.Errorf("email service not configured")
 	}
 
-	// Incrementar contador de uso
-	err = usageRepo.IncrementUsage(ctx, businessID, usage.SK)
+	email.Attachments, err = resolveEmailAttachments(ctx, fileRepo, businessID, req.Attachments, attachmentLimit(plan.AttachmentMaxBytes), email.HTMLBody)
 	if err != nil {
-		// Log interno para debugging
-		fmt.Printf("Failed to increment usage: %v\n", err)
-		// No fallar la request si el mensaje ya fue enviado
+		return nil, err
+	}
+
+	// Tracking: la versión en texto plano ya se generó con los enlaces originales
+	if req.TrackOpens || req.TrackClicks {
+		email.HTMLBody, err = applyEmailTracking(email.HTMLBody, email.MessageID, req.TrackOpens, req.TrackClicks)
+		if err != nil {
+			fmt.Printf("Email tracking error: %v\n", err)
+			return nil, fmt.Errorf("tracking not configured")
+		}
+	}
+
+	// Con send_at se reserva la cuota y el worker envía el email en esa fecha (el mensaje se vuelve a construir)
+	if !sendAt.IsZero() {
+		req.ContactID = ""
+		scheduled, err := scheduleNotification(ctx, client, businessID, plan, usage, NotificationTypeEmail, email.Recipients(), req.TemplateID, recipients.Count(), sendAt, req)
+		if err != nil {
+			return nil, err
+		}
+		return &SendEmailResponse{
+			Success:           true,
+			NotificationID:    scheduled.NotificationID,
+			Provider:          provider.Name(),
+			Recipients:        recipients.Count(),
+			NotificationCount: scheduled.NotificationCount,
+			NotificationLeft:  scheduled.NotificationLeft,
+			Status:            models.NotificationStatusScheduled,
+			SendAt:            scheduled.SendAt,
+		}, nil
+	}
+
+	// Envío a través del proveedor
+	providerMessageID, err := sendEmailWithProvider(ctx, provider, email)
+	if err != nil {
+		return nil, err
+	}
+	notificationID := email.MessageID
+	fmt.Printf("✅ Email sent successfully via %s!\n", provider.Name())
+	fmt.Printf("   Message ID: %s, Provider ID: %s\n", notificationID, providerMessageID)
+
+	recordNotification(ctx, notificationRepo, &models.Notification{
+		PK:                "NOTIFICATION#" + notificationID,
+		SK:                "METADATA",
+		NotificationID:    notificationID,
+		BusinessID:        businessID,
+		Channel:           "email",
+		Recipients:        email.Recipients(),
+		TemplateID:        req.TemplateID,
+		Status:            "sent",
+		Provider:          provider.Name(),
+		ProviderMessageID: providerMessageID,
+		TrackOpens:        req.TrackOpens,
+		TrackClicks:       req.TrackClicks,
+		CreatedAt:         time.Now().Format(time.RFC3339),
+	})
+
+	// Incrementar contador de uso (una unidad por destinatario)
+	if req.scheduledID == "" {
+		err = usageRepo.IncrementUsageBy(ctx, businessID, usage.SK, recipients.Count())
+		if err != nil {
+			// Log interno para debugging
+			fmt.Printf("Failed to increment usage: %v\n", err)
+			// No fallar la request si el mensaje ya fue enviado
+		}
 	}
 
 	// Calcular notificaciones restantes
-	newCount := usage.NotificationCount + 1
+	newCount := usage.NotificationCount + recipients.Count()
 	notificationLeft := plan.NotificationLimit - newCount
 	if notificationLeft < 0 {
 		notificationLeft = 0
@@ -215,6 +304,9 @@ func SendEmailService(apiKey string, req SendEmailRequest) (*SendEmailResponse,
 	return &SendEmailResponse{
 		Success:           true,
 		NotificationID:    notificationID,
+		Provider:          provider.Name(),
+		ProviderMessageID: providerMessageID,
+		Recipients:        recipients.Count(),
 		NotificationCount: newCount,
 		NotificationLeft:  notificationLeft,
 	}, nil
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Modos de seguridad del transporte SMTP (SMTP_SECURITY)
+const (
+	smtpSecurityNone                  = "none"                   // Sin cifrado (solo servidores locales/de prueba)
+	smtpSecuritySTARTTLS              = "starttls"               // STARTTLS obligatorio
+	smtpSecuritySTARTTLSOpportunistic = "starttls_opportunistic" // STARTTLS si el servidor lo anuncia
+	smtpSecurityTLS                   = "tls"                    // TLS implícito (puerto 465)
+)
+
+// Mecanismos de autenticación SMTP (SMTP_AUTH)
+const (
+	smtpAuthAuto    = "auto" // Elige según lo que anuncia el servidor: PLAIN > LOGIN > CRAM-MD5
+	smtpAuthPlain   = "plain"
+	smtpAuthLogin   = "login"
+	smtpAuthCRAMMD5 = "cram-md5"
+	smtpAuthNone    = "none"
+)
+
+const defaultSMTPTimeout = 10 * time.Second
+
+// smtpConfig es la configuración del transporte SMTP
+type smtpConfig struct {
+	Host          string
+	Port          string
+	Username      string
+	Password      string
+	Security      string
+	AuthMechanism string
+	Timeout       time.Duration // Aplica a la conexión y a toda la sesión SMTP
+	EHLOName      string
+}
+
+// loadSMTPConfig lee la configuración SMTP desde variables de entorno.
+// Por defecto usa TLS implícito en el puerto 465 y, en el resto, STARTTLS si el servidor lo anuncia (el comportamiento
+// de smtp.SendMail que se usaba antes); STARTTLS obligatorio se activa con SMTP_SECURITY=starttls.
+func loadSMTPConfig() smtpConfig {
+	cfg := smtpConfig{
+		Host:          getEnv("SMTP_HOST", ""),
+		Port:          getEnv("SMTP_PORT", "587"),
+		Username:      getEnv("SMTP_USER", ""),
+		Password:      getEnv("SMTP_PASSWORD", ""),
+		Security:      strings.ToLower(getEnv("SMTP_SECURITY", "")),
+		AuthMechanism: strings.ToLower(getEnv("SMTP_AUTH", smtpAuthAuto)),
+		Timeout:       defaultSMTPTimeout,
+		EHLOName:      getEnv("SMTP_EHLO_NAME", ""),
+	}
+
+	if cfg.Security == "" {
+		cfg.Security = smtpSecuritySTARTTLSOpportunistic
+		if cfg.Port == "465" {
+			cfg.Security = smtpSecurityTLS
+		}
+	}
+
+	if seconds, err := strconv.Atoi(getEnv("SMTP_TIMEOUT", "")); err == nil && seconds > 0 {
+		cfg.Timeout = time.Duration(seconds) * time.Second
+	}
+
+	// EHLO con el dominio del remitente en lugar de "localhost"
+	if cfg.EHLOName == "" {
+		cfg.EHLOName = "localhost"
+		if at := strings.LastIndex(cfg.Username, "@"); at != -1 && at < len(cfg.Username)-1 {
+			cfg.EHLOName = cfg.Username[at+1:]
+		}
+	}
+
+	return cfg
+}
+
+// Validate verifica que la configuración sea utilizable antes de abrir la conexión
+func (cfg smtpConfig) Validate() error {
+	if cfg.Host == "" {
+		return fmt.Errorf("SMTP_HOST is required")
+	}
+
+	switch cfg.Security {
+	case smtpSecurityNone, smtpSecuritySTARTTLS, smtpSecuritySTARTTLSOpportunistic, smtpSecurityTLS:
+	default:
+		return fmt.Errorf("invalid SMTP_SECURITY: %s", cfg.Security)
+	}
+
+	switch cfg.AuthMechanism {
+	case smtpAuthAuto, smtpAuthNone:
+	case smtpAuthPlain, smtpAuthLogin, smtpAuthCRAMMD5:
+		// auto sin credenciales envía sin autenticar (relay); un mecanismo explícito las necesita
+		if cfg.Username == "" {
+			return fmt.Errorf("SMTP_USER is required for SMTP_AUTH=%s", cfg.AuthMechanism)
+		}
+	default:
+		return fmt.Errorf("invalid SMTP_AUTH: %s", cfg.AuthMechanism)
+	}
+
+	return nil
+}
+
+// sendSMTP entrega un mensaje ya serializado aplicando la configuración de transporte
+func sendSMTP(cfg smtpConfig, from string, recipients []string, msg []byte) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(cfg.Host, cfg.Port)
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	tlsConfig := &tls.Config{ServerName: cfg.Host}
+
+	// Conectar al servidor (TLS implícito o TCP plano)
+	var conn net.Conn
+	var err error
+	if cfg.Security == smtpSecurityTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect: %v", err)
+	}
+
+	// El deadline cubre toda la sesión para que un servidor lento no agote el timeout de la Lambda
+	if err = conn.SetDeadline(time.Now().Add(cfg.Timeout)); err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to set deadline: %v", err)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to connect: %v", err)
+	}
+	defer client.Close()
+
+	if err = client.Hello(cfg.EHLOName); err != nil {
+		return fmt.Errorf("failed EHLO: %v", err)
+	}
+
+	// STARTTLS obligatorio u oportunista
+	if cfg.Security == smtpSecuritySTARTTLS || cfg.Security == smtpSecuritySTARTTLSOpportunistic {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			fmt.Printf("   → Starting TLS...\n")
+			if err = client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("failed STARTTLS: %v", err)
+			}
+		} else if cfg.Security == smtpSecuritySTARTTLS {
+			return fmt.Errorf("server does not support STARTTLS")
+		}
+	}
+
+	auth, err := selectSMTPAuth(client, cfg)
+	if err != nil {
+		return err
+	}
+	if auth != nil {
+		if err = client.Auth(auth); err != nil {
+			return fmt.Errorf("failed authentication: %v", err)
+		}
+	}
+
+	if err = client.Mail(from); err != nil {
+		return fmt.Errorf("failed MAIL: %v", err)
+	}
+
+	for _, recipient := range recipients {
+		if err = client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("failed RCPT: %v", err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed DATA: %v", err)
+	}
+
+	if _, err = writer.Write(msg); err != nil {
+		return fmt.Errorf("failed to write message: %v", err)
+	}
+
+	// Close envía el "." final; aquí el servidor confirma o rechaza el mensaje
+	if err = writer.Close(); err != nil {
+		return fmt.Errorf("message rejected: %v", err)
+	}
+	fmt.Printf("   ✓ Message data sent\n")
+
+	return client.Quit()
+}
+
+// selectSMTPAuth construye el mecanismo de autenticación configurado.
+// Retorna nil si no hay credenciales o la autenticación está desactivada.
+func selectSMTPAuth(client *smtp.Client, cfg smtpConfig) (smtp.Auth, error) {
+	if cfg.AuthMechanism == smtpAuthNone || cfg.Username == "" {
+		return nil, nil
+	}
+
+	mechanism := cfg.AuthMechanism
+	if mechanism == smtpAuthAuto {
+		ok, advertised := client.Extension("AUTH")
+		if !ok {
+			return nil, fmt.Errorf("server does not support AUTH")
+		}
+
+		advertised = strings.ToUpper(advertised)
+		switch {
+		case strings.Contains(advertised, "PLAIN"):
+			mechanism = smtpAuthPlain
+		case strings.Contains(advertised, "LOGIN"):
+			mechanism = smtpAuthLogin
+		case strings.Contains(advertised, "CRAM-MD5"):
+			mechanism = smtpAuthCRAMMD5
+		default:
+			return nil, fmt.Errorf("no supported AUTH mechanism: %s", advertised)
+		}
+	}
+
+	switch mechanism {
+	case smtpAuthPlain:
+		return smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host), nil
+	case smtpAuthLogin:
+		return &loginAuth{username: cfg.Username, password: cfg.Password, host: cfg.Host}, nil
+	case smtpAuthCRAMMD5:
+		return smtp.CRAMMD5Auth(cfg.Username, cfg.Password), nil
+	}
+
+	return nil, fmt.Errorf("invalid SMTP_AUTH: %s", mechanism)
+}
+
+// loginAuth implementa AUTH LOGIN (no incluido en net/smtp), usado por Office 365 y otros
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	// Igual que PlainAuth: no enviar credenciales sin cifrar salvo a localhost
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+
+	prompt := strings.ToLower(strings.TrimSpace(string(fromServer)))
+	switch {
+	case strings.HasPrefix(prompt, "username"):
+		return []byte(a.username), nil
+	case strings.HasPrefix(prompt, "password"):
+		return []byte(a.password), nil
+	}
+
+	return nil, fmt.Errorf("unexpected LOGIN challenge: %s", fromServer)
+}
+
+func isLocalhost(name string) bool {
+	return name == "localhost" || name == "127.0.0.1" || name == "::1"
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"notify-backend/internal/utils"
+)
+
+// Rutas públicas de tracking (sin API Key, autenticadas por el token firmado)
+const (
+	trackingOpenPath  = "/v1/t/o/"
+	trackingClickPath = "/v1/t/c/"
+)
+
+// templateIDRegex limita los IDs de plantilla usados para agrupar métricas
+var templateIDRegex = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// trackedLinkRegex captura el href de los enlaces http(s); mailto:, tel: y cid: no se reescriben
+var trackedLinkRegex = regexp.MustCompile(`(?i)(<a\b[^>]*?\bhref\s*=\s*)("https?://[^"]+"|'https?://[^']+')`)
+
+var bodyCloseRegex = regexp.MustCompile(`(?i)</body\s*>`)
+
+// trackingToken es el payload firmado de las URLs de tracking
+type trackingToken struct {
+	NotificationID string `json:"n"`
+	URL            string `json:"u,omitempty"` // Destino del clic (firmado, así el endpoint no es un open redirect)
+}
+
+// applyEmailTracking inserta el pixel de apertura y reescribe los enlaces del HTML
+// para que pasen por el endpoint de redirección
+func applyEmailTracking(htmlBody, notificationID string, trackOpens, trackClicks bool) (string, error) {
+	if trackClicks {
+		var rewriteErr error
+		htmlBody = trackedLinkRegex.ReplaceAllStringFunc(htmlBody, func(match string) string {
+			parts := trackedLinkRegex.FindStringSubmatch(match)
+			quote := parts[2][:1]
+			target := html.UnescapeString(parts[2][1 : len(parts[2])-1])
+
+			if _, err := url.ParseRequestURI(target); err != nil {
+				return match
+			}
+
+			trackingURL, err := buildTrackingURL(trackingClickPath, trackingToken{NotificationID: notificationID, URL: target})
+			if err != nil {
+				rewriteErr = err
+				return match
+			}
+			return parts[1] + quote + html.EscapeString(trackingURL) + quote
+		})
+		if rewriteErr != nil {
+			return "", rewriteErr
+		}
+	}
+
+	if trackOpens {
+		pixelURL, err := buildTrackingURL(trackingOpenPath, trackingToken{NotificationID: notificationID})
+		if err != nil {
+			return "", err
+		}
+		pixel := fmt.Sprintf(`<img src="%s" width="1" height="1" alt="" style="display:none;border:0">`, html.EscapeString(pixelURL))
+
+		// Antes de </body> si existe; si no, al final del documento
+		if loc := bodyCloseRegex.FindStringIndex(htmlBody); loc != nil {
+			htmlBody = htmlBody[:loc[0]] + pixel + htmlBody[loc[0]:]
+		} else {
+			htmlBody += pixel
+		}
+	}
+
+	return htmlBody, nil
+}
+
+// buildTrackingURL firma el token y arma la URL pública de tracking
+func buildTrackingURL(path string, token trackingToken) (string, error) {
+	signed, err := utils.SignToken(token)
+	if err != nil {
+		return "", err
+	}
+	return utils.PublicURL(path + signed)
+}
+
+// isTrackableURL evita redirigir a esquemas distintos de http(s) aunque el token sea válido
+func isTrackableURL(target string) bool {
+	lower := strings.ToLower(target)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/repository"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type UploadFileRequest struct {
+	Filename    string `json:"filename"`
+	Content     string `json:"content"` // Contenido en base64
+	ContentType string `json:"content_type"`
+}
+
+type UploadFileResponse struct {
+	FileID      string `json:"file_id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int    `json:"size"`
+}
+
+func UploadFileService(apiKey string, req UploadFileRequest) (*UploadFileResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	planRepo := repository.NewPlanRepository(client, "NotificationService")
+	fileRepo := repository.NewFileRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	businessID := business.PK[9:] // Remover "BUSINESS#"
+
+	plan, err := planRepo.GetByID(ctx, business.PlanID)
+	if err != nil {
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	filename := sanitizeFilename(req.Filename)
+	if filename == "" {
+		return nil, fmt.Errorf("filename is required")
+	}
+
+	content, err := base64.StdEncoding.DecodeString(req.Content)
+	if err != nil || len(content) == 0 {
+		return nil, fmt.Errorf("invalid file content")
+	}
+
+	// Un archivo nunca puede superar el límite de adjuntos del plan
+	if len(content) > attachmentLimit(plan.AttachmentMaxBytes) {
+		return nil, fmt.Errorf("file too large")
+	}
+
+	contentType := detectContentType(content, req.ContentType)
+	if isBlockedContentType(contentType) {
+		return nil, fmt.Errorf("attachment type not allowed")
+	}
+
+	file := &models.File{
+		PK:          business.PK,
+		SK:          "FILE#",
+		FileID:      uuid.New().String(),
+		BusinessID:  businessID,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        len(content),
+		CreatedAt:   time.Now().Format(time.RFC3339),
+	}
+	file.SK += file.FileID
+
+	if err := fileRepo.Create(ctx, file, content); err != nil {
+		fmt.Printf("Failed to store file: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	return &UploadFileResponse{
+		FileID:      file.FileID,
+		Filename:    file.Filename,
+		ContentType: file.ContentType,
+		Size:        file.Size,
+	}, nil
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
+	"notify-backend/internal/repository"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	twilioClient "github.com/twilio/twilio-go/client"
+)
+
+// InboundTwilioPath es la ruta pública configurada como webhook de mensajes entrantes en Twilio
+const InboundTwilioPath = "/v1/inbound/twilio"
+
+// Palabras clave de opt-out / opt-in / ayuda (inglés y español)
+var (
+	stopKeywords  = map[string]bool{"STOP": true, "STOPALL": true, "UNSUBSCRIBE": true, "CANCEL": true, "END": true, "QUIT": true, "BAJA": true}
+	startKeywords = map[string]bool{"START": true, "UNSTOP": true, "YES": true, "ALTA": true}
+	helpKeywords  = map[string]bool{"HELP": true, "INFO": true, "AYUDA": true}
+)
+
+type InboundMessageResponse struct {
+	MessageSID string   `json:"message_sid"`
+	Channel    string   `json:"channel"`
+	From       string   `json:"from"`
+	To         string   `json:"to"`
+	Body       string   `json:"body"`
+	MediaURLs  []string `json:"media_urls,omitempty"`
+	Keyword    string   `json:"keyword,omitempty"`
+	ReceivedAt string   `json:"received_at"`
+}
+
+type ListInboundMessagesResponse struct {
+	Messages   []InboundMessageResponse `json:"messages"`
+	NextCursor string                   `json:"next_cursor,omitempty"`
+}
+
+func newInboundMessageResponse(message *models.InboundMessage) InboundMessageResponse {
+	return InboundMessageResponse{
+		MessageSID: message.MessageSID,
+		Channel:    message.Channel,
+		From:       message.From,
+		To:         message.To,
+		Body:       message.Body,
+		MediaURLs:  message.MediaURLs,
+		Keyword:    message.Keyword,
+		ReceivedAt: message.ReceivedAt,
+	}
+}
+
+// detectKeyword retorna STOP, START o HELP si el mensaje completo es una palabra clave
+func detectKeyword(body string) string {
+	word := strings.ToUpper(strings.Trim(strings.TrimSpace(body), ".!¡"))
+	switch {
+	case stopKeywords[word]:
+		return "STOP"
+	case startKeywords[word]:
+		return "START"
+	case helpKeywords[word]:
+		return "HELP"
+	}
+	return ""
+}
+
+// recordOutboundConversation asigna la conversación al negocio después de un envío por SMS o WhatsApp.
+// Los errores solo se registran: el mensaje ya fue enviado.
+func recordOutboundConversation(ctx context.Context, repo *repository.ConversationRepository, channel, phone, businessID string) {
+	if err := repo.RecordOutbound(ctx, channel, phone, businessID, time.Now().Format(time.RFC3339)); err != nil {
+		fmt.Printf("Failed to record conversation: %v\n", err)
+	}
+}
+
+// HandleTwilioInboundService procesa el webhook de mensajes entrantes de Twilio.
+// Retorna el texto de la respuesta automática (vacío si no hay que responder).
+func HandleTwilioInboundService(requestURL string, params map[string]string, signature string) (string, error) {
+	authToken := os.Getenv("TWILIO_AUTH_TOKEN")
+	if authToken == "" {
+		return "", fmt.Errorf("service unavailable")
+	}
+
+	validator := twilioClient.NewRequestValidator(authToken)
+	if !validator.Validate(requestURL, params, signature) {
+		return "", fmt.Errorf("invalid signature")
+	}
+
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	conversationRepo := repository.NewConversationRepository(client, "NotificationService")
+	inboundRepo := repository.NewInboundMessageRepository(client, "NotificationService")
+	suppressionRepo := repository.NewSuppressionRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	channel := "sms"
+	from := params["From"]
+	if strings.HasPrefix(from, "whatsapp:") {
+		channel = "whatsapp"
+		from = strings.TrimPrefix(from, "whatsapp:")
+	}
+	sender, err := phone.Parse(from, "")
+	if err != nil {
+		fmt.Printf("Inbound message with invalid sender: %s\n", params["From"])
+		return "", nil
+	}
+	from = sender.E164
+
+	now := time.Now().UTC().Format(time.RFC3339Nano)
+
+	// El negocio dueño es el último que le escribió al remitente por este canal
+	conversation, err := conversationRepo.RecordInbound(ctx, channel, from, now)
+	if err != nil {
+		fmt.Printf("Inbound message from %s without conversation: %v\n", from, err)
+		return "", nil
+	}
+
+	business, err := businessRepo.GetByPK(ctx, "BUSINESS#"+conversation.BusinessID)
+	if err != nil {
+		fmt.Printf("Inbound message for unknown business %s: %v\n", conversation.BusinessID, err)
+		return "", nil
+	}
+
+	message := &models.InboundMessage{
+		PK:         business.PK,
+		MessageSID: params["MessageSid"],
+		BusinessID: conversation.BusinessID,
+		Channel:    channel,
+		From:       from,
+		To:         strings.TrimPrefix(params["To"], "whatsapp:"),
+		Body:       params["Body"],
+		Keyword:    detectKeyword(params["Body"]),
+		ReceivedAt: now,
+	}
+
+	numMedia, _ := strconv.Atoi(params["NumMedia"])
+	for i := 0; i < numMedia; i++ {
+		if mediaURL := params[fmt.Sprintf("MediaUrl%d", i)]; mediaURL != "" {
+			message.MediaURLs = append(message.MediaURLs, mediaURL)
+		}
+	}
+
+	// Con un recordatorio esperando confirmación, YES y CANCEL responden a la cita en lugar de ser palabras clave
+	appointmentReply := findAppointmentReply(ctx, client, message, params["ButtonPayload"], params["OriginalRepliedMessageSid"])
+	if appointmentReply != nil {
+		message.Keyword = ""
+	}
+
+	reply := ""
+	switch message.Keyword {
+	case "STOP":
+		err = suppressionRepo.Put(ctx, &models.Suppression{
+			PK:         business.PK,
+			BusinessID: conversation.BusinessID,
+			Channel:    models.SuppressionChannelPhone,
+			Value:      from,
+			Reason:     SuppressionReasonStopKeyword,
+			Source:     "inbound:" + channel,
+			CreatedAt:  now,
+		})
+		reply = fmt.Sprintf("%s: ya no recibirás más mensajes. Responde START para volver a recibirlos.", business.Name)
+	case "START":
+		err = suppressionRepo.Delete(ctx, conversation.BusinessID, models.SuppressionChannelPhone, from)
+		if err != nil && err.Error() == "suppression not found" {
+			err = nil
+		}
+		reply = fmt.Sprintf("%s: volverás a recibir mensajes. Responde STOP para darte de baja.", business.Name)
+	case "HELP":
+		reply = fmt.Sprintf("%s: responde STOP para no recibir más mensajes o START para volver a recibirlos.", business.Name)
+	}
+	if err != nil {
+		fmt.Printf("Failed to update suppression for %s: %v\n", from, err)
+		return "", fmt.Errorf("service unavailable")
+	}
+
+	// Las palabras clave son idempotentes y se aplican antes de guardar: un reintento tras un error las repite,
+	// pero las respuestas a citas y el webhook solo se procesan la primera vez que se guarda el SID
+	if err := inboundRepo.Create(ctx, message); err != nil {
+		if err.Error() == "inbound message exists" {
+			return "", nil // Reintento de Twilio: el mensaje ya se procesó y se respondió
+		}
+		// Twilio reintenta si respondemos error; el mensaje se guarda en el reintento
+		fmt.Printf("Failed to store inbound message: %v\n", err)
+		return "", fmt.Errorf("service unavailable")
+	}
+
+	// Respuestas a recordatorios de citas (1 = confirmar, 2 = cancelar o botones de respuesta rápida)
+	if appointmentReply != nil {
+		reply = handleAppointmentReply(ctx, client, business, message, appointmentReply)
+	}
+
+	deliverWebhook(business, "message.inbound", newInboundMessageResponse(message))
+
+	return reply, nil
+}
+
+func ListInboundMessagesService(apiKey string, limit int32, cursor string) (*ListInboundMessagesResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	inboundRepo := repository.NewInboundMessageRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	messages, nextCursor, err := inboundRepo.ListPage(ctx, business.PK[9:], limit, cursor)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return nil, err
+		}
+		fmt.Printf("Failed to list inbound messages: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := &ListInboundMessagesResponse{
+		Messages:   make([]InboundMessageResponse, 0, len(messages)),
+		NextCursor: nextCursor,
+	}
+	for _, message := range messages {
+		resp.Messages = append(resp.Messages, newInboundMessageResponse(message))
+	}
+
+	return resp, nil
+}
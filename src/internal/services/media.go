@@ -0,0 +1,218 @@
+package services
+
+import (
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"notify-backend/internal/models"
+)
+
+const mediaFetchTimeout = 5 * time.Second
+
+// Límites de Twilio para adjuntos por URL
+const (
+	maxWhatsAppMediaCount = 1
+	maxMMSMediaCount      = 10
+	maxMMSTotalBytes      = 5 * 1024 * 1024
+)
+
+// whatsAppMediaRule es la categoría (para comparar con el header de la plantilla) y el tamaño máximo de un tipo
+type whatsAppMediaRule struct {
+	Category string // image, audio, video, document
+	MaxBytes int64
+}
+
+var whatsAppMediaTypes = map[string]whatsAppMediaRule{
+	"image/jpeg":      {Category: "image", MaxBytes: 5 * 1024 * 1024},
+	"image/png":       {Category: "image", MaxBytes: 5 * 1024 * 1024},
+	"audio/ogg":       {Category: "audio", MaxBytes: 16 * 1024 * 1024},
+	"audio/mpeg":      {Category: "audio", MaxBytes: 16 * 1024 * 1024},
+	"audio/mp4":       {Category: "audio", MaxBytes: 16 * 1024 * 1024},
+	"audio/aac":       {Category: "audio", MaxBytes: 16 * 1024 * 1024},
+	"audio/amr":       {Category: "audio", MaxBytes: 16 * 1024 * 1024},
+	"video/mp4":       {Category: "video", MaxBytes: 16 * 1024 * 1024},
+	"video/3gpp":      {Category: "video", MaxBytes: 16 * 1024 * 1024},
+	"application/pdf": {Category: "document", MaxBytes: 16 * 1024 * 1024},
+}
+
+// mmsMediaTypes son los tipos aceptados por Twilio en MMS; el límite es por mensaje (maxMMSTotalBytes)
+var mmsMediaTypes = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"audio/mpeg":      true,
+	"audio/mp4":       true,
+	"audio/ogg":       true,
+	"video/mp4":       true,
+	"video/3gpp":      true,
+	"application/pdf": true,
+	"text/vcard":      true,
+	"text/calendar":   true,
+}
+
+// isPublicIP indica si la IP es una dirección pública de internet
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() ||
+		sharedAddressSpace.Contains(ip))
+}
+
+// sharedAddressSpace es el rango de NAT de operadores (RFC 6598), no enrutable en internet
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// mediaHTTPClient consulta URLs del cliente desde la Lambda: la IP se valida al conectar (después de resolver
+// el DNS, también en las redirecciones) para no alcanzar la red interna ni el servicio de metadatos.
+var mediaHTTPClient = &http.Client{
+	Timeout: mediaFetchTimeout,
+	Transport: &http.Transport{
+		Proxy: nil,
+		DialContext: (&net.Dialer{
+			Timeout: mediaFetchTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+					return fmt.Errorf("media host not allowed: %s", host)
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: mediaFetchTimeout,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if req.URL.Scheme != "https" || len(via) >= 5 {
+			return fmt.Errorf("media redirect not allowed")
+		}
+		return nil
+	},
+}
+
+// validMediaURL indica si la URL del adjunto es https con host (Twilio la descarga desde internet)
+func validMediaURL(mediaURL string) bool {
+	parsed, err := url.Parse(mediaURL)
+	return err == nil && parsed.Scheme == "https" && parsed.Hostname() != "" && parsed.User == nil
+}
+
+// headMediaURL consulta el adjunto con HEAD, igual que lo descargará Twilio.
+// El tamaño es obligatorio: sin Content-Length no se pueden aplicar los límites.
+func headMediaURL(mediaURL string) (*models.NotificationMedia, error) {
+	resp, err := mediaHTTPClient.Head(mediaURL)
+	if err != nil {
+		fmt.Printf("Media HEAD %s failed: %v\n", mediaURL, err)
+		return nil, fmt.Errorf("media not reachable")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		fmt.Printf("Media HEAD %s returned status %d\n", mediaURL, resp.StatusCode)
+		return nil, fmt.Errorf("media not reachable")
+	}
+
+	contentType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("unsupported media type")
+	}
+
+	if resp.ContentLength <= 0 {
+		return nil, fmt.Errorf("media size unknown")
+	}
+
+	return &models.NotificationMedia{
+		URL:         mediaURL,
+		ContentType: strings.ToLower(contentType),
+		Size:        resp.ContentLength,
+	}, nil
+}
+
+// inspectMedia valida las URLs y obtiene tipo y tamaño de cada adjunto
+func inspectMedia(mediaURLs []string, maxCount int) ([]models.NotificationMedia, error) {
+	if len(mediaURLs) > maxCount {
+		return nil, fmt.Errorf("too many media urls")
+	}
+
+	media := make([]models.NotificationMedia, 0, len(mediaURLs))
+	for _, mediaURL := range mediaURLs {
+		if !validMediaURL(mediaURL) {
+			return nil, fmt.Errorf("invalid media url")
+		}
+
+		info, err := headMediaURL(mediaURL)
+		if err != nil {
+			return nil, err
+		}
+		media = append(media, *info)
+	}
+
+	return media, nil
+}
+
+// validateWhatsAppMedia valida el adjunto de un mensaje de WhatsApp.
+// mediaHeader es el header declarado por la plantilla (vacío en mensajes de sesión o plantillas sin header).
+func validateWhatsAppMedia(mediaURLs []string, mediaHeader string, isTemplate bool) ([]models.NotificationMedia, error) {
+	if isTemplate {
+		if mediaHeader == "" && len(mediaURLs) > 0 {
+			return nil, fmt.Errorf("template does not accept media")
+		}
+		if mediaHeader != "" && len(mediaURLs) == 0 {
+			return nil, fmt.Errorf("template requires media")
+		}
+	}
+
+	media, err := inspectMedia(mediaURLs, maxWhatsAppMediaCount)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range media {
+		rule, ok := whatsAppMediaTypes[item.ContentType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported media type")
+		}
+		if item.Size > rule.MaxBytes {
+			return nil, fmt.Errorf("media too large")
+		}
+		if mediaHeader != "" && rule.Category != mediaHeader {
+			return nil, fmt.Errorf("media does not match template header")
+		}
+	}
+
+	return media, nil
+}
+
+// validateMMSMedia valida los adjuntos de un SMS, que se envía como MMS
+func validateMMSMedia(mediaURLs []string) ([]models.NotificationMedia, error) {
+	media, err := inspectMedia(mediaURLs, maxMMSMediaCount)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, item := range media {
+		if !mmsMediaTypes[item.ContentType] {
+			return nil, fmt.Errorf("unsupported media type")
+		}
+		total += item.Size
+	}
+	if total > maxMMSTotalBytes {
+		return nil, fmt.Errorf("media too large")
+	}
+
+	return media, nil
+}
+
+// mediaURLsOf retorna las URLs de los adjuntos validados, en el orden recibido
+func mediaURLsOf(media []models.NotificationMedia) []string {
+	urls := make([]string, 0, len(media))
+	for _, item := range media {
+		urls = append(urls, item.URL)
+	}
+	return urls
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Límites de las cadenas de canales de respaldo
+const (
+	maxChainChannels       = 3
+	defaultFallbackTimeout = 300  // segundos
+	maxFallbackTimeout     = 3600 // los reportes de no entrega de Twilio llegan en minutos
+)
+
+// chainAbortErrors afectan a todos los canales: no tiene sentido intentar el siguiente
+var chainAbortErrors = map[string]bool{
+	"authentication failed":      true,
+	"notification limit reached": true,
+	"service unavailable":        true,
+}
+
+// NotificationAttemptResponse es un intento de envío de una cadena
+type NotificationAttemptResponse struct {
+	Step           int    `json:"step"` // Posición del canal en la cadena (desde 1)
+	Type           string `json:"type"`
+	To             string `json:"to"`
+	NotificationID string `json:"notification_id"`
+	Status         string `json:"status"` // sent, failed, delivered, read, undelivered
+	Error          string `json:"error,omitempty"`
+	At             string `json:"at"`
+}
+
+// NotificationChainResponse es el estado de una cadena de canales de respaldo
+type NotificationChainResponse struct {
+	ChainID         string                        `json:"chain_id"`
+	Status          string                        `json:"status"` // pending, sent, delivered, undelivered, failed
+	FallbackTimeout int                           `json:"fallback_timeout"`
+	DeadlineAt      string                        `json:"deadline_at,omitempty"`
+	Attempts        []NotificationAttemptResponse `json:"attempts"`
+	CreatedAt       string                        `json:"created_at"`
+	UpdatedAt       string                        `json:"updated_at"`
+}
+
+func newNotificationAttemptResponses(attempts []models.NotificationChainAttempt) []NotificationAttemptResponse {
+	result := make([]NotificationAttemptResponse, 0, len(attempts))
+	for _, attempt := range attempts {
+		result = append(result, NotificationAttemptResponse{
+			Step:           attempt.Step + 1,
+			Type:           attempt.Type,
+			To:             attempt.To,
+			NotificationID: attempt.NotificationID,
+			Status:         attempt.Status,
+			Error:          attempt.Error,
+			At:             attempt.At,
+		})
+	}
+	return result
+}
+
+func newNotificationChainResponse(chain *models.NotificationChain) *NotificationChainResponse {
+	resp := &NotificationChainResponse{
+		ChainID:         chain.ChainID,
+		Status:          chain.Status,
+		FallbackTimeout: chain.TimeoutSeconds,
+		DeadlineAt:      chain.DeadlineAt,
+		Attempts:        newNotificationAttemptResponses(chain.Attempts),
+		CreatedAt:       chain.CreatedAt,
+		UpdatedAt:       chain.UpdatedAt,
+	}
+
+	// Sin reporte de no entrega antes del límite, el envío se da por bueno
+	if chain.Status == models.ChainStatusPending && chain.DeadlineAt < time.Now().UTC().Format(time.RFC3339) {
+		resp.Status = models.ChainStatusSent
+		resp.DeadlineAt = ""
+	}
+
+	return resp
+}
+
+// chainStepRequest convierte un paso guardado en una petición de un solo canal
+func chainStepRequest(step models.NotificationChainStep) SendNotificationRequest {
+	// Los servicios de cada canal agregan parámetros (ej: empresa): no modificar los del paso guardado
+	parameters := make(map[string]string, len(step.Parameters))
+	for key, value := range step.Parameters {
+		parameters[key] = value
+	}
+
+	return SendNotificationRequest{
+		Type:       step.Type,
+		To:         step.To,
+		ContactID:  step.ContactID,
+		TemplateID: step.TemplateID,
+		Parameters: parameters,
+		Message:    step.Message,
+		Subject:    step.Subject,
+
+		Category:       step.Category,
+		DeliveryWindow: step.DeliveryWindow,
+		inChain:        true,
+	}
+}
+
+// chainStepRecipient identifica el destinatario de un paso en los intentos y en el log
+func chainStepRecipient(step models.NotificationChainStep) string {
+	if step.To == "" && step.ContactID != "" {
+		return "contact:" + step.ContactID
+	}
+	return step.To
+}
+
+// sendNotificationChain envía por el primer canal disponible de la cadena y la registra
+// para continuar con el siguiente si el proveedor reporta no entrega
+func sendNotificationChain(apiKey string, req SendNotificationRequest) (*SendNotificationResponse, error) {
+	if len(req.Channels) > maxChainChannels {
+		return nil, fmt.Errorf("too many channels")
+	}
+	if req.FallbackTimeout == 0 {
+		req.FallbackTimeout = defaultFallbackTimeout
+	}
+	if req.FallbackTimeout < 0 || req.FallbackTimeout > maxFallbackTimeout {
+		return nil, fmt.Errorf("invalid fallback timeout")
+	}
+
+	steps := make([]models.NotificationChainStep, 0, len(req.Channels))
+	for _, channel := range req.Channels {
+		if len(channel.Channels) > 0 {
+			return nil, fmt.Errorf("invalid channel chain")
+		}
+		if channel.Type != NotificationTypeSMS && channel.Type != NotificationTypeWhatsApp && channel.Type != NotificationTypeEmail {
+			return nil, fmt.Errorf("invalid notification type")
+		}
+		// Los canales sin destinatario usan el contact_id de la cadena
+		if channel.To == "" && channel.ContactID == "" {
+			channel.ContactID = req.ContactID
+		}
+		if channel.To == "" && channel.ContactID == "" {
+			return nil, fmt.Errorf("to or contact_id is required")
+		}
+		if channel.Category == "" {
+			channel.Category = req.Category
+		}
+		if channel.DeliveryWindow == nil {
+			channel.DeliveryWindow = req.DeliveryWindow
+		}
+		if _, err := normalizeDeliveryCategory(channel.Category); err != nil {
+			return nil, err
+		}
+		if _, err := normalizeDeliveryWindow(channel.DeliveryWindow); err != nil {
+			return nil, err
+		}
+		steps = append(steps, models.NotificationChainStep{
+			Type:       channel.Type,
+			To:         channel.To,
+			ContactID:  channel.ContactID,
+			TemplateID: channel.TemplateID,
+			Parameters: channel.Parameters,
+			Message:    channel.Message,
+			Subject:    channel.Subject,
+
+			Category:       channel.Category,
+			DeliveryWindow: channel.DeliveryWindow,
+		})
+	}
+
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	chainRepo := repository.NewNotificationChainRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	chainID := uuid.New().String()
+	chain := &models.NotificationChain{
+		PK:             "CHAIN#" + chainID,
+		SK:             "METADATA",
+		ChainID:        chainID,
+		BusinessID:     business.PK[9:], // Remover "BUSINESS#"
+		Steps:          steps,
+		Attempts:       []models.NotificationChainAttempt{},
+		Status:         models.ChainStatusPending,
+		TimeoutSeconds: req.FallbackTimeout,
+		CreatedAt:      now,
+	}
+
+	result, sendErr := runNotificationChain(ctx, notificationRepo, apiKey, chain)
+
+	// La cadena se guarda antes de asociar la notificación: un callback no puede llegar a una cadena inexistente
+	if err := chainRepo.Create(ctx, chain); err != nil {
+		fmt.Printf("Failed to record notification chain %s: %v\n", chainID, err)
+	} else if result != nil {
+		linkChainNotification(ctx, notificationRepo, result.NotificationID, chainID)
+	}
+
+	if sendErr != nil {
+		return nil, sendErr
+	}
+
+	result.ChainID = chainID
+	result.Attempts = newNotificationAttemptResponses(chain.Attempts)
+	return result, nil
+}
+
+// runNotificationChain intenta los canales desde chain.CurrentStep hasta que uno envíe.
+// Los intentos fallidos quedan en el log de notificaciones con estado failed y no consumen cuota.
+func runNotificationChain(ctx context.Context, notificationRepo *repository.NotificationRepository, apiKey string, chain *models.NotificationChain) (*SendNotificationResponse, error) {
+	failures := []string{}
+
+	for ; chain.CurrentStep < len(chain.Steps); chain.CurrentStep++ {
+		step := chain.Steps[chain.CurrentStep]
+		now := time.Now().UTC()
+		chain.UpdatedAt = now.Format(time.RFC3339)
+
+		attempt := models.NotificationChainAttempt{
+			Step: chain.CurrentStep,
+			Type: step.Type,
+			To:   chainStepRecipient(step),
+			At:   now.Format(time.RFC3339),
+		}
+
+		result, err := dispatchNotification(apiKey, chainStepRequest(step))
+		if err != nil {
+			attempt.Status = "failed"
+			attempt.Error = err.Error()
+			attempt.NotificationID = recordFailedAttempt(ctx, notificationRepo, chain, step, err)
+			chain.Attempts = append(chain.Attempts, attempt)
+			failures = append(failures, step.Type+": "+err.Error())
+
+			if chainAbortErrors[err.Error()] {
+				chain.Status = models.ChainStatusFailed
+				chain.DeadlineAt = ""
+				return nil, err
+			}
+			continue
+		}
+
+		attempt.Status = "sent"
+		attempt.NotificationID = result.NotificationID
+		chain.Attempts = append(chain.Attempts, attempt)
+
+		// El email no tiene reportes de entrega: no hay forma de saber si hay que seguir
+		if chain.CurrentStep == len(chain.Steps)-1 || step.Type == NotificationTypeEmail {
+			chain.Status = models.ChainStatusSent
+			chain.DeadlineAt = ""
+		} else {
+			chain.Status = models.ChainStatusPending
+			chain.DeadlineAt = now.Add(time.Duration(chain.TimeoutSeconds) * time.Second).Format(time.RFC3339)
+		}
+		return result, nil
+	}
+
+	chain.Status = models.ChainStatusFailed
+	chain.DeadlineAt = ""
+	return nil, fmt.Errorf("all channels failed: %s", strings.Join(failures, "; "))
+}
+
+// linkChainNotification asocia a la cadena el intento recién enviado. Si el callback de Twilio llegó antes
+// de la asociación, el estado ya está en la notificación pero no movió la cadena: se aplica ahora.
+// Un callback simultáneo no envía dos veces el paso siguiente (la cadena se guarda con condición).
+func linkChainNotification(ctx context.Context, notificationRepo *repository.NotificationRepository, notificationID, chainID string) {
+	notification, err := notificationRepo.SetChain(ctx, notificationID, chainID)
+	if err != nil {
+		fmt.Printf("Failed to link notification %s to chain %s: %v\n", notificationID, chainID, err)
+		return
+	}
+
+	switch notification.Status {
+	case "delivered", "read", "undelivered", "failed":
+		advanceNotificationChain(ctx, notification)
+	}
+}
+
+// recordFailedAttempt registra en el log un intento que no llegó al proveedor y retorna su ID
+func recordFailedAttempt(ctx context.Context, repo *repository.NotificationRepository, chain *models.NotificationChain, step models.NotificationChainStep, sendErr error) string {
+	notificationID := fmt.Sprintf("%s-%d", chain.ChainID, len(chain.Attempts)+1)
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	err := repo.Create(ctx, &models.Notification{
+		PK:              "NOTIFICATION#" + notificationID,
+		SK:              "METADATA",
+		NotificationID:  notificationID,
+		BusinessID:      chain.BusinessID,
+		Channel:         step.Type,
+		Recipients:      []string{chainStepRecipient(step)},
+		TemplateID:      step.TemplateID,
+		Status:          "failed",
+		Error:           sendErr.Error(),
+		StatusUpdatedAt: now,
+		ChainID:         chain.ChainID,
+		CreatedAt:       now,
+	})
+	if err != nil {
+		fmt.Printf("Failed to record failed attempt %s: %v\n", notificationID, err)
+	}
+
+	return notificationID
+}
+
+// advanceNotificationChain aplica el estado de entrega de un intento a su cadena.
+// Si el proveedor reporta no entrega antes del límite, envía por el siguiente canal.
+func advanceNotificationChain(ctx context.Context, notification *models.Notification) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	chainRepo := repository.NewNotificationChainRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+
+	chain, err := chainRepo.GetByID(ctx, notification.ChainID)
+	if err != nil {
+		fmt.Printf("Chain %s not found for notification %s: %v\n", notification.ChainID, notification.NotificationID, err)
+		return
+	}
+
+	// Solo el último intento enviado de una cadena pendiente puede moverla
+	last := len(chain.Attempts) - 1
+	if chain.Status != models.ChainStatusPending || last < 0 || chain.Attempts[last].NotificationID != notification.NotificationID {
+		return
+	}
+
+	expectedStep := chain.CurrentStep
+	now := time.Now().UTC().Format(time.RFC3339)
+	chain.UpdatedAt = now
+	chain.Attempts[last].Status = notification.Status
+	chain.Attempts[last].Error = notification.Error
+
+	switch notification.Status {
+	case "delivered", "read":
+		chain.Status = models.ChainStatusDelivered
+		chain.DeadlineAt = ""
+	case "undelivered", "failed":
+		if chain.DeadlineAt < now {
+			// El reporte llegó después del límite: la cadena ya se había dado por enviada
+			chain.Status = models.ChainStatusUndelivered
+			chain.DeadlineAt = ""
+			break
+		}
+
+		// Reservar el siguiente paso antes de enviar: un callback repetido no envía dos veces
+		chain.CurrentStep++
+		chain.DeadlineAt = ""
+		if err := chainRepo.Save(ctx, chain, expectedStep, models.ChainStatusPending); err != nil {
+			if err.Error() != "chain modified" {
+				fmt.Printf("Failed to update chain %s: %v\n", chain.ChainID, err)
+			}
+			return
+		}
+		expectedStep = chain.CurrentStep
+
+		business, err := businessRepo.GetByPK(ctx, "BUSINESS#"+chain.BusinessID)
+		if err != nil {
+			fmt.Printf("Business %s not found for chain %s: %v\n", chain.BusinessID, chain.ChainID, err)
+			chain.Status = models.ChainStatusFailed
+			break
+		}
+
+		result, err := runNotificationChain(ctx, notificationRepo, business.APIKey, chain)
+		if err != nil {
+			fmt.Printf("Chain %s fallback failed: %v\n", chain.ChainID, err)
+			// Sin más canales que enviaran, el resultado es la no entrega del último intento enviado
+			if chain.Status == models.ChainStatusFailed && !chainAbortErrors[err.Error()] {
+				chain.Status = models.ChainStatusUndelivered
+			}
+			break
+		}
+
+		if err := chainRepo.Save(ctx, chain, expectedStep, models.ChainStatusPending); err != nil {
+			fmt.Printf("Failed to update chain %s: %v\n", chain.ChainID, err)
+			return
+		}
+		linkChainNotification(ctx, notificationRepo, result.NotificationID, chain.ChainID)
+		return
+	default:
+		return
+	}
+
+	if err := chainRepo.Save(ctx, chain, expectedStep, models.ChainStatusPending); err != nil && err.Error() != "chain modified" {
+		fmt.Printf("Failed to update chain %s: %v\n", chain.ChainID, err)
+	}
+}
+
+// GetNotificationChainService obtiene el estado de una cadena y todos sus intentos
+func GetNotificationChainService(apiKey, chainID string) (*NotificationChainResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	chainRepo := repository.NewNotificationChainRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	chain, err := chainRepo.GetByID(ctx, chainID)
+	// Una cadena de otro negocio se reporta como inexistente
+	if err != nil || chain.BusinessID != business.PK[9:] {
+		return nil, fmt.Errorf("chain not found")
+	}
+
+	return newNotificationChainResponse(chain), nil
+}
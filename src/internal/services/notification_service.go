@@ -4,76 +4,238 @@ import (
 	"context"
 	"fmt"
 	"notify-backend/internal/db"
+	"notify-backend/internal/models"
 	"notify-backend/internal/repository"
-	"time"
 )
 
+// Canales del endpoint unificado /v1/notifications/send
+const (
+	NotificationTypeWhatsApp = "whatsapp"
+	NotificationTypeSMS      = "sms"
+	NotificationTypeEmail    = "email"
+)
+
+// SendNotificationRequest es el payload común a todos los canales: destinatario, plantilla y parámetros.
+// Message permite enviar texto libre donde el canal lo admite (sesión de WhatsApp, email con Subject).
+// Con Channels se envía una cadena de canales de respaldo en lugar de un solo canal.
 type SendNotificationRequest struct {
-	To      string `json:"to"`
-	Message string `json:"message"`
-	Type    string `json:"type"` // whatsapp, sms, email
+	Type       string            `json:"type"`       // whatsapp, sms, email
+	To         string            `json:"to"`         // Teléfono (E.164 o nacional) o email
+	ContactID  string            `json:"contact_id"` // En lugar de to; en una cadena, el contacto de los canales sin destinatario
+	TemplateID string            `json:"template_id"`
+	Parameters map[string]string `json:"parameters"`
+	Message    string            `json:"message"`
+	Subject    string            `json:"subject"` // Solo email sin plantilla
+	SendAt     string            `json:"send_at"` // RFC3339: se programa en lugar de enviarse (no admite channels)
+
+	// Horario de envío de SMS y WhatsApp; en una cadena, los de la cadena aplican a los canales que no los indican
+	Category       string                 `json:"category"`
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+	inChain        bool                   // Paso de una cadena: fuera del horario falla en lugar de aplazarse
+
+	Channels        []SendNotificationRequest `json:"channels"`         // Canales en orden, cada uno con su destinatario
+	FallbackTimeout int                       `json:"fallback_timeout"` // Segundos de espera de un reporte de no entrega por canal
 }
 
 type SendNotificationResponse struct {
 	Success           bool   `json:"success"`
+	Type              string `json:"type"`
 	NotificationID    string `json:"notification_id"`
+	TemplateUsed      string `json:"template_used,omitempty"`
 	NotificationCount int    `json:"notification_count"`
 	NotificationLeft  int    `json:"notification_left"`
+
+	// Solo en envíos programados
+	Status   string `json:"status,omitempty"` // scheduled
+	SendAt   string `json:"send_at,omitempty"`
+	Deferred bool   `json:"deferred,omitempty"` // Aplazado por el horario de envío
+
+	// Solo en envíos con canales de respaldo
+	ChainID  string                        `json:"chain_id,omitempty"`
+	Attempts []NotificationAttemptResponse `json:"attempts,omitempty"`
 }
 
+// SendNotificationService envía la notificación por el canal indicado usando su implementación
+// (SendSMSService, SendWhatsAppService, SendEmailService); los errores del canal se retornan sin cambios
 func SendNotificationService(apiKey string, req SendNotificationRequest) (*SendNotificationResponse, error) {
+	if len(req.Channels) > 0 {
+		if req.Type != "" || req.To != "" {
+			return nil, fmt.Errorf("channels cannot be combined with type")
+		}
+		if req.SendAt != "" {
+			return nil, fmt.Errorf("send_at cannot be combined with channels")
+		}
+		return sendNotificationChain(apiKey, req)
+	}
+
+	return dispatchNotification(apiKey, req)
+}
+
+// dispatchNotification envía por un solo canal
+func dispatchNotification(apiKey string, req SendNotificationRequest) (*SendNotificationResponse, error) {
+	if req.To == "" && req.ContactID == "" {
+		return nil, fmt.Errorf("to or contact_id is required")
+	}
+	if req.TemplateID == "" && req.Message == "" {
+		return nil, fmt.Errorf("template_id or message is required")
+	}
+	if req.TemplateID != "" && req.Message != "" {
+		return nil, fmt.Errorf("template_id cannot be combined with message")
+	}
+	if req.Parameters == nil {
+		req.Parameters = map[string]string{}
+	}
+
+	switch req.Type {
+	case NotificationTypeSMS:
+		if req.TemplateID == "" {
+			return nil, fmt.Errorf("template_id is required for sms")
+		}
+		result, err := SendSMSService(apiKey, SendSMSRequest{
+			To:         req.To,
+			ContactID:  req.ContactID,
+			TemplateID: req.TemplateID,
+			Parameters: req.Parameters,
+			SendAt:     req.SendAt,
+
+			Category:       req.Category,
+			DeliveryWindow: req.DeliveryWindow,
+			inChain:        req.inChain,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &SendNotificationResponse{
+			Success:           true,
+			Type:              req.Type,
+			NotificationID:    result.NotificationID,
+			TemplateUsed:      result.TemplateUsed,
+			NotificationCount: result.NotificationCount,
+			NotificationLeft:  result.NotificationLeft,
+			Status:            result.Status,
+			SendAt:            result.SendAt,
+			Deferred:          result.Deferred,
+		}, nil
+
+	case NotificationTypeWhatsApp:
+		result, err := SendWhatsAppService(apiKey, SendWhatsAppRequest{
+			To:         req.To,
+			ContactID:  req.ContactID,
+			TemplateID: req.TemplateID,
+			Parameters: req.Parameters,
+			Body:       req.Message,
+			SendAt:     req.SendAt,
+
+			Category:       req.Category,
+			DeliveryWindow: req.DeliveryWindow,
+			inChain:        req.inChain,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &SendNotificationResponse{
+			Success:           true,
+			Type:              req.Type,
+			NotificationID:    result.NotificationID,
+			TemplateUsed:      result.TemplateUsed,
+			NotificationCount: result.NotificationCount,
+			NotificationLeft:  result.NotificationLeft,
+			Status:            result.Status,
+			SendAt:            result.SendAt,
+			Deferred:          result.Deferred,
+		}, nil
+
+	case NotificationTypeEmail:
+		emailReq, templateUsed, err := buildTemplateEmail(apiKey, req)
+		if err != nil {
+			return nil, err
+		}
+		emailReq.SendAt = req.SendAt
+		result, err := SendEmailService(apiKey, *emailReq)
+		if err != nil {
+			return nil, err
+		}
+		return &SendNotificationResponse{
+			Success:           true,
+			Type:              req.Type,
+			NotificationID:    result.NotificationID,
+			TemplateUsed:      templateUsed,
+			NotificationCount: result.NotificationCount,
+			NotificationLeft:  result.NotificationLeft,
+			Status:            result.Status,
+			SendAt:            result.SendAt,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("invalid notification type")
+}
+
+// buildTemplateEmail arma el email desde una plantilla de tipo email (asunto y cuerpo con $parametros)
+// o desde Subject y Message. Retorna también el nombre de la plantilla usada.
+func buildTemplateEmail(apiKey string, req SendNotificationRequest) (*SendEmailRequest, string, error) {
+	if req.TemplateID == "" {
+		if req.Subject == "" {
+			return nil, "", fmt.Errorf("subject is required")
+		}
+		if req.ContactID != "" {
+			if req.To != "" {
+				return nil, "", fmt.Errorf("contact_id cannot be combined with to")
+			}
+			return &SendEmailRequest{ContactID: req.ContactID, Subject: req.Subject, Body: req.Message}, "", nil
+		}
+		return &SendEmailRequest{To: []string{req.To}, Subject: req.Subject, Body: req.Message}, "", nil
+	}
+
 	client, _ := db.NewDynamoClient()
 	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
-	planRepo := repository.NewPlanRepository(client, "NotificationService")
-	usageRepo := repository.NewUsageRepository(client, "NotificationService")
+	templateRepo := repository.NewTemplateRepository(client, "NotificationService")
 	ctx := context.TODO()
 
-	// Buscar negocio por API Key
+	// El nombre de la empresa se agrega a los parámetros, igual que en SMS
 	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
 	if err != nil {
-		return nil, fmt.Errorf("invalid API key")
+		return nil, "", fmt.Errorf("authentication failed")
 	}
 
-	businessID := business.PK[9:] // Remover "BUSINESS#"
-
-	// Obtener plan
-	plan, err := planRepo.GetByID(ctx, business.PlanID)
-	if err != nil {
-		return nil, fmt.Errorf("plan not found")
+	// Con contact_id se usan el email del contacto, la variante de idioma de la plantilla y sus atributos
+	if req.ContactID != "" {
+		recipient, err := resolveContactRecipient(ctx, client, business.PK[9:], req.ContactID, NotificationTypeEmail, "", req.To, req.TemplateID, req.Parameters)
+		if err != nil {
+			return nil, "", err
+		}
+		req.To, req.TemplateID, req.Parameters = recipient.To, recipient.TemplateID, recipient.Parameters
 	}
 
-	// Verificar o crear período de uso
-	usage, err := usageRepo.CheckAndCreateNewPeriod(ctx, businessID, business.PlanID, plan.PeriodDays)
+	template, err := templateRepo.GetByID(ctx, req.TemplateID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to check usage: %v", err)
+		return nil, "", fmt.Errorf("invalid template")
 	}
-
-	// Verificar límite de notificaciones
-	if usage.NotificationCount >= plan.NotificationLimit {
-		return nil, fmt.Errorf("notification limit reached. Please upgrade your plan")
+	if template.Type != NotificationTypeEmail {
+		return nil, "", fmt.Errorf("invalid template type")
+	}
+	if !template.Active {
+		return nil, "", fmt.Errorf("template not available")
 	}
 
-	// TODO: Aquí iría la lógica real para enviar la notificación
-	// Por ahora solo simularemos el envío
-	notificationID := fmt.Sprintf("NOTIF_%d", time.Now().UnixNano())
-
-	// Incrementar contador de uso
-	err = usageRepo.IncrementUsage(ctx, businessID, usage.SK)
-	if err != nil {
-		return nil, fmt.Errorf("failed to increment usage: %v", err)
+	validation := templateRepo.ValidateTemplateParameters(template, req.Parameters)
+	if !validation.Valid && len(validation.MissingParams) > 0 {
+		return nil, "", fmt.Errorf("missing required parameters")
 	}
 
-	// Calcular notificaciones restantes
-	newCount := usage.NotificationCount + 1
-	notificationLeft := plan.NotificationLimit - newCount
-	if notificationLeft < 0 {
-		notificationLeft = 0
+	if req.Parameters == nil {
+		req.Parameters = map[string]string{}
+	}
+	req.Parameters["empresa"] = business.Name
+	subject := template.Subject
+	if subject == "" {
+		subject = template.Name
 	}
 
-	return &SendNotificationResponse{
-		Success:           true,
-		NotificationID:    notificationID,
-		NotificationCount: newCount,
-		NotificationLeft:  notificationLeft,
-	}, nil
+	return &SendEmailRequest{
+		To:         []string{req.To},
+		Subject:    buildSMSMessage(subject, req.Parameters),
+		Body:       buildTemplateEmailBody(template, req.Parameters),
+		HTML:       template.HTML,
+		TemplateID: template.TemplateID,
+	}, template.Name, nil
 }
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/phone"
+	"notify-backend/internal/repository"
+	"strings"
+)
+
+type PhoneLookupResponse struct {
+	Input          string `json:"input"`
+	E164           string `json:"e164"`
+	Country        string `json:"country,omitempty"`
+	CallingCode    string `json:"calling_code,omitempty"`
+	NationalNumber string `json:"national_number,omitempty"`
+	LineType       string `json:"line_type"` // mobile, fixed_line, unknown
+}
+
+// PhoneLookupService normaliza un teléfono y detecta país y tipo de línea.
+// Si no se indica country se usa el país por defecto del negocio.
+func PhoneLookupService(apiKey, number, country string) (*PhoneLookupResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	country = strings.ToUpper(strings.TrimSpace(country))
+	if country == "" {
+		country = business.DefaultCountry
+	} else if !phone.IsSupportedCountry(country) {
+		return nil, fmt.Errorf("invalid country")
+	}
+
+	parsed, err := phone.Parse(number, country)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PhoneLookupResponse{
+		Input:          number,
+		E164:           parsed.E164,
+		Country:        parsed.Country,
+		CallingCode:    parsed.CallingCode,
+		NationalNumber: parsed.NationalNumber,
+		LineType:       parsed.LineType,
+	}, nil
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/repository"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/uuid"
+)
+
+// maxScheduleAhead es la anticipación máxima de un envío programado
+const maxScheduleAhead = 30 * 24 * time.Hour
+
+// scheduledClaimLease es cuánto reserva un worker un envío programado que tomó (el worker corre hasta 2 minutos)
+const scheduledClaimLease = 10 * time.Minute
+
+// maxScheduledRequestBytes limita la petición guardada (el item de DynamoDB admite 400KB)
+const maxScheduledRequestBytes = 350 * 1024
+
+// ScheduledNotificationRequest cambia la fecha de un envío programado
+type ScheduledNotificationRequest struct {
+	SendAt string `json:"send_at"`
+}
+
+// scheduledNotification es el resultado de programar un envío
+type scheduledNotification struct {
+	NotificationID    string
+	SendAt            string
+	NotificationCount int
+	NotificationLeft  int
+}
+
+// parseSendAt valida send_at (RFC3339, futuro y como máximo 30 días después); vacío es un envío inmediato
+func parseSendAt(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	sendAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid send_at")
+	}
+
+	now := time.Now()
+	if !sendAt.After(now) || sendAt.After(now.Add(maxScheduleAhead)) {
+		return time.Time{}, fmt.Errorf("invalid send_at")
+	}
+
+	return sendAt.UTC(), nil
+}
+
+// scheduleNotification reserva la cuota y guarda la petición ya validada del canal para que el worker
+// la envíe en sendAt. La petición debe tener el destinatario resuelto (sin contact_id).
+func scheduleNotification(ctx context.Context, client *dynamodb.Client, businessID string, plan *models.Plan, usage *models.Usage,
+	channel string, recipients []string, templateID string, units int, sendAt time.Time, request interface{}) (*scheduledNotification, error) {
+	usageRepo := repository.NewUsageRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		fmt.Printf("Failed to encode scheduled request: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	if len(payload) > maxScheduledRequestBytes {
+		return nil, fmt.Errorf("scheduled request too large")
+	}
+
+	// La cuota se reserva al programar: el envío no puede fallar después por falta de cuota
+	count, err := usageRepo.ReserveUsage(ctx, businessID, usage.SK, units, plan.NotificationLimit)
+	if err != nil {
+		if err.Error() == "notification limit reached" {
+			return nil, err
+		}
+		fmt.Printf("Failed to reserve usage: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	notificationID := uuid.New().String()
+	notification := &models.Notification{
+		PK:               "NOTIFICATION#" + notificationID,
+		SK:               "METADATA",
+		NotificationID:   notificationID,
+		BusinessID:       businessID,
+		Channel:          channel,
+		Recipients:       recipients,
+		TemplateID:       templateID,
+		Status:           models.NotificationStatusScheduled,
+		CreatedAt:        time.Now().Format(time.RFC3339),
+		SendAt:           sendAt.Format(time.RFC3339),
+		ScheduledRequest: string(payload),
+		ReservedUnits:    units,
+		UsageSK:          usage.SK,
+		GSI2PK:           models.ScheduledQueueKey,
+		GSI2SK:           sendAt.Format(time.RFC3339) + "#" + notificationID,
+	}
+	if err := notificationRepo.Create(ctx, notification); err != nil {
+		fmt.Printf("Failed to create scheduled notification: %v\n", err)
+		if err := usageRepo.ReleaseUsage(ctx, businessID, usage.SK, units); err != nil {
+			fmt.Printf("Failed to release usage: %v\n", err)
+		}
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	fmt.Printf("Notification scheduled - ID: %s, Channel: %s, SendAt: %s\n", notificationID, channel, notification.SendAt)
+
+	notificationLeft := plan.NotificationLimit - count
+	if notificationLeft < 0 {
+		notificationLeft = 0
+	}
+
+	return &scheduledNotification{
+		NotificationID:    notificationID,
+		SendAt:            notification.SendAt,
+		NotificationCount: count,
+		NotificationLeft:  notificationLeft,
+	}, nil
+}
+
+// getScheduledNotification obtiene una notificación del negocio autenticado por la API Key
+func getScheduledNotification(ctx context.Context, client *dynamodb.Client, apiKey, notificationID string) (*models.Notification, error) {
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	notification, err := notificationRepo.GetByID(ctx, notificationID)
+	// Una notificación de otro negocio se reporta como inexistente
+	if err != nil || notification.BusinessID != business.PK[9:] {
+		return nil, fmt.Errorf("notification not found")
+	}
+
+	if notification.Status != models.NotificationStatusScheduled {
+		return nil, fmt.Errorf("notification not scheduled")
+	}
+
+	return notification, nil
+}
+
+// CancelScheduledNotificationService cancela un envío programado antes de que se envíe y libera su cuota
+func CancelScheduledNotificationService(apiKey, notificationID string) (*NotificationResponse, error) {
+	client, _ := db.NewDynamoClient()
+	usageRepo := repository.NewUsageRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	if _, err := getScheduledNotification(ctx, client, apiKey, notificationID); err != nil {
+		return nil, err
+	}
+
+	// Condicionado al estado: si el worker ya lo tomó, no se cancela ni se libera la cuota
+	notification, err := notificationRepo.CancelScheduled(ctx, notificationID, time.Now().Format(time.RFC3339))
+	if err != nil {
+		if err.Error() == "notification not scheduled" {
+			return nil, err
+		}
+		fmt.Printf("Failed to cancel scheduled notification: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	if err := usageRepo.ReleaseUsage(ctx, notification.BusinessID, notification.UsageSK, notification.ReservedUnits); err != nil {
+		fmt.Printf("Failed to release usage: %v\n", err)
+	}
+
+	return newNotificationResponse(notification), nil
+}
+
+// RescheduleNotificationService cambia la fecha de un envío programado antes de que se envíe
+func RescheduleNotificationService(apiKey, notificationID string, req ScheduledNotificationRequest) (*NotificationResponse, error) {
+	client, _ := db.NewDynamoClient()
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	sendAt, err := parseSendAt(req.SendAt)
+	if err != nil {
+		return nil, err
+	}
+	if sendAt.IsZero() {
+		return nil, fmt.Errorf("invalid send_at")
+	}
+
+	if _, err := getScheduledNotification(ctx, client, apiKey, notificationID); err != nil {
+		return nil, err
+	}
+
+	notification, err := notificationRepo.RescheduleScheduled(ctx, notificationID, sendAt.Format(time.RFC3339), time.Now().Format(time.RFC3339))
+	if err != nil {
+		if err.Error() == "notification not scheduled" {
+			return nil, err
+		}
+		fmt.Printf("Failed to reschedule notification: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	return newNotificationResponse(notification), nil
+}
+
+// ProcessScheduledNotificationsService envía los envíos programados cuyo sendAt ya llegó.
+// La ejecuta el worker cada minuto; deja de tomar envíos cuando queda menos de reserve de tiempo.
+func ProcessScheduledNotificationsService(ctx context.Context, reserve time.Duration) error {
+	client, _ := db.NewDynamoClient()
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
+
+	ids, err := notificationRepo.ListScheduledDue(ctx, time.Now().UTC().Format(time.RFC3339), 100)
+	if err != nil {
+		fmt.Printf("Failed to list scheduled notifications: %v\n", err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	for _, id := range ids {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < reserve {
+			break
+		}
+		if err := dispatchScheduledNotification(ctx, client, notificationRepo, id); err != nil {
+			fmt.Printf("Failed to dispatch scheduled notification %s: %v\n", id, err)
+		}
+	}
+
+	return nil
+}
+
+// dispatchScheduledNotification envía un envío programado con la implementación de su canal.
+// La cuota ya está reservada: si el envío falla se libera.
+func dispatchScheduledNotification(ctx context.Context, client *dynamodb.Client, notificationRepo *repository.NotificationRepository, notificationID string) error {
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	usageRepo := repository.NewUsageRepository(client, "NotificationService")
+
+	notification, err := notificationRepo.GetByID(ctx, notificationID)
+	if err != nil {
+		return err
+	}
+	if notification.Status == models.NotificationStatusDispatching {
+		return failStaleScheduledClaim(ctx, notificationRepo, usageRepo, notification)
+	}
+	if notification.Status != models.NotificationStatusScheduled {
+		return nil // El índice es eventualmente consistente: ya se envió o se canceló
+	}
+	if sendAt, err := time.Parse(time.RFC3339, notification.SendAt); err == nil && sendAt.After(time.Now()) {
+		return nil // Se reprogramó
+	}
+
+	// Solo un worker lo toma; una cancelación o reprogramación posterior ya no aplica
+	now := time.Now().UTC()
+	leaseUntil := now.Add(scheduledClaimLease).Format(time.RFC3339)
+	if err := notificationRepo.ClaimScheduled(ctx, notificationID, notification.SendAt, now.Format(time.RFC3339), leaseUntil); err != nil {
+		if err.Error() == "notification not scheduled" {
+			return nil
+		}
+		return err
+	}
+
+	dispatchedID, err := sendScheduledRequest(ctx, businessRepo, notification)
+	if err != nil {
+		if releaseErr := usageRepo.ReleaseUsage(ctx, notification.BusinessID, notification.UsageSK, notification.ReservedUnits); releaseErr != nil {
+			fmt.Printf("Failed to release usage: %v\n", releaseErr)
+		}
+		return notificationRepo.CompleteScheduled(ctx, notificationID, "failed", "", err.Error(), time.Now().Format(time.RFC3339))
+	}
+
+	fmt.Printf("Scheduled notification sent - ID: %s, Dispatched: %s\n", notificationID, dispatchedID)
+	if notification.AppointmentID != "" {
+		recordAppointmentReminderSent(ctx, client, notification, dispatchedID)
+	}
+	return notificationRepo.CompleteScheduled(ctx, notificationID, "sent", dispatchedID, "", time.Now().Format(time.RFC3339))
+}
+
+// failStaleScheduledClaim libera la cuota de un envío tomado por un worker que se interrumpió antes de completarlo.
+// No se reintenta: el envío pudo llegar al proveedor y repetirlo lo duplicaría.
+func failStaleScheduledClaim(ctx context.Context, notificationRepo *repository.NotificationRepository, usageRepo *repository.UsageRepository, notification *models.Notification) error {
+	leaseUntil, _, _ := strings.Cut(notification.GSI2SK, "#")
+	if expiresAt, err := time.Parse(time.RFC3339, leaseUntil); err != nil || expiresAt.After(time.Now()) {
+		return nil // Otro worker lo está enviando
+	}
+
+	err := notificationRepo.FailStaleClaim(ctx, notification.NotificationID, notification.GSI2SK, "dispatch interrupted", time.Now().Format(time.RFC3339))
+	if err != nil {
+		if err.Error() == "notification not dispatching" {
+			return nil
+		}
+		return err
+	}
+
+	if err := usageRepo.ReleaseUsage(ctx, notification.BusinessID, notification.UsageSK, notification.ReservedUnits); err != nil {
+		fmt.Printf("Failed to release usage: %v\n", err)
+	}
+	fmt.Printf("Scheduled notification %s interrupted while dispatching: usage released\n", notification.NotificationID)
+	return nil
+}
+
+// sendScheduledRequest envía la petición guardada como el negocio que la programó y retorna el ID de la notificación enviada
+func sendScheduledRequest(ctx context.Context, businessRepo *repository.BusinessRepository, notification *models.Notification) (string, error) {
+	business, err := businessRepo.GetByPK(ctx, "BUSINESS#"+notification.BusinessID)
+	if err != nil {
+		return "", fmt.Errorf("authentication failed")
+	}
+
+	payload := []byte(notification.ScheduledRequest)
+	switch notification.Channel {
+	case NotificationTypeSMS:
+		var req SendSMSRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return "", fmt.Errorf("invalid scheduled request")
+		}
+		req.SendAt, req.scheduledID = "", notification.NotificationID
+		result, err := SendSMSService(business.APIKey, req)
+		if err != nil {
+			return "", err
+		}
+		return result.NotificationID, nil
+
+	case NotificationTypeWhatsApp:
+		var req SendWhatsAppRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return "", fmt.Errorf("invalid scheduled request")
+		}
+		req.SendAt, req.scheduledID = "", notification.NotificationID
+		result, err := SendWhatsAppService(business.APIKey, req)
+		if err != nil {
+			return "", err
+		}
+		return result.NotificationID, nil
+
+	case NotificationTypeEmail:
+		var req SendEmailRequest
+		if err := json.Unmarshal(payload, &req); err != nil {
+			return "", fmt.Errorf("invalid scheduled request")
+		}
+		req.SendAt, req.scheduledID = "", notification.NotificationID
+		result, err := SendEmailService(business.APIKey, req)
+		if err != nil {
+			return "", err
+		}
+		return result.NotificationID, nil
+	}
+
+	return "", fmt.Errorf("invalid notification type")
+}
@@ -0,0 +1,345 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/repository"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Prefijos de los registros DNS que el negocio debe publicar
+const (
+	domainVerificationHost   = "_notify-verification"
+	domainVerificationPrefix = "notify-verification="
+)
+
+var domainNameRegex = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z]{2,63}$`)
+
+// lookupTXT resuelve registros TXT; es una variable para poder reemplazarla en entornos locales
+var lookupTXT = net.DefaultResolver.LookupTXT
+
+type RegisterSenderDomainRequest struct {
+	Domain      string `json:"domain"`
+	FromAddress string `json:"from_address"` // Debe pertenecer al dominio
+	FromName    string `json:"from_name,omitempty"`
+}
+
+// DNSRecord es un registro que el negocio debe publicar en su DNS
+type DNSRecord struct {
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Verified bool   `json:"verified"`
+}
+
+type SenderDomainResponse struct {
+	Domain      string      `json:"domain"`
+	FromAddress string      `json:"from_address"`
+	FromName    string      `json:"from_name,omitempty"`
+	Verified    bool        `json:"verified"`
+	VerifiedAt  string      `json:"verified_at,omitempty"`
+	DKIMEnabled bool        `json:"dkim_enabled"`
+	IsDefault   bool        `json:"is_default"`
+	DNSRecords  []DNSRecord `json:"dns_records"`
+	CreatedAt   string      `json:"created_at"`
+}
+
+func newSenderDomainResponse(domain *models.SenderDomain, defaultDomain string) SenderDomainResponse {
+	return SenderDomainResponse{
+		Domain:      domain.Domain,
+		FromAddress: domain.FromAddress,
+		FromName:    domain.FromName,
+		Verified:    domain.Verified,
+		VerifiedAt:  domain.VerifiedAt,
+		DKIMEnabled: domain.DKIMEnabled,
+		IsDefault:   domain.Domain == defaultDomain,
+		DNSRecords: []DNSRecord{
+			{
+				Type:     "TXT",
+				Name:     domainVerificationHost + "." + domain.Domain,
+				Value:    domainVerificationPrefix + domain.VerificationToken,
+				Verified: domain.Verified,
+			},
+			{
+				Type:     "TXT",
+				Name:     domain.DKIMSelector + "._domainkey." + domain.Domain,
+				Value:    dkimDNSRecord(domain.DKIMPublicKey),
+				Verified: domain.DKIMEnabled,
+			},
+		},
+		CreatedAt: domain.CreatedAt,
+	}
+}
+
+// normalizeDomain valida un nombre de dominio y lo retorna en minúsculas
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(domain)), ".")
+	if len(domain) > 253 || !domainNameRegex.MatchString(domain) {
+		return "", fmt.Errorf("invalid domain")
+	}
+	return domain, nil
+}
+
+// addressDomain retorna el dominio de una dirección de email en minúsculas
+func addressDomain(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at == -1 {
+		return ""
+	}
+	return strings.ToLower(address[at+1:])
+}
+
+// dnsTXTContains verifica si el registro TXT contiene el valor esperado.
+// Un nombre inexistente es una respuesta definitiva (false, nil); cualquier otro fallo del resolver
+// (timeout, SERVFAIL) se retorna como error para no confundirlo con un registro retirado.
+// Con DNS_VERIFICATION_STUB=true no consulta el DNS y lo da por publicado (desarrollo local).
+func dnsTXTContains(ctx context.Context, name, expected string) (bool, error) {
+	if getEnv("DNS_VERIFICATION_STUB", "") == "true" {
+		fmt.Printf("DNS verification stubbed for %s\n", name)
+		return true, nil
+	}
+
+	records, err := lookupTXT(ctx, name)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return false, nil
+		}
+		return false, err
+	}
+
+	for _, record := range records {
+		// Los proveedores DNS pueden partir valores largos en varios strings; se comparan sin espacios
+		if strings.ReplaceAll(record, " ", "") == strings.ReplaceAll(expected, " ", "") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func RegisterSenderDomainService(apiKey string, req RegisterSenderDomainRequest) (*SenderDomainResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	domainRepo := repository.NewSenderDomainRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	businessID := business.PK[9:] // Remover "BUSINESS#"
+
+	domain, err := normalizeDomain(req.Domain)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAddress, err := validateEmailAddress(req.FromAddress)
+	if err != nil || addressDomain(fromAddress) != domain {
+		return nil, fmt.Errorf("from address must belong to the domain")
+	}
+
+	if strings.ContainsAny(req.FromName, "\r\n") {
+		return nil, fmt.Errorf("invalid from name")
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	privateKey, publicKey, err := generateDKIMKey()
+	if err != nil {
+		fmt.Printf("Failed to generate DKIM key: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	now := time.Now()
+	senderDomain := &models.SenderDomain{
+		PK:                business.PK,
+		SK:                "DOMAIN#" + domain,
+		Domain:            domain,
+		BusinessID:        businessID,
+		FromAddress:       fromAddress,
+		FromName:          strings.TrimSpace(req.FromName),
+		VerificationToken: hex.EncodeToString(token),
+		DKIMSelector:      "notify" + now.Format("200601"), // Selector con fecha para poder rotar llaves
+		DKIMPrivateKey:    privateKey,
+		DKIMPublicKey:     publicKey,
+		CreatedAt:         now.Format(time.RFC3339),
+	}
+
+	if err := domainRepo.Create(ctx, senderDomain); err != nil {
+		if err.Error() == "domain already registered" {
+			return nil, err
+		}
+		fmt.Printf("Failed to register domain: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := newSenderDomainResponse(senderDomain, business.DefaultSenderDomain)
+	return &resp, nil
+}
+
+func ListSenderDomainsService(apiKey string) ([]SenderDomainResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	domainRepo := repository.NewSenderDomainRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	domains, err := domainRepo.ListByBusiness(ctx, business.PK[9:])
+	if err != nil {
+		fmt.Printf("Failed to list domains: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := make([]SenderDomainResponse, 0, len(domains))
+	for _, domain := range domains {
+		resp = append(resp, newSenderDomainResponse(domain, business.DefaultSenderDomain))
+	}
+
+	return resp, nil
+}
+
+// VerifySenderDomainService consulta los registros TXT de verificación y DKIM.
+// Se puede llamar de nuevo para activar DKIM si el registro se publicó después.
+func VerifySenderDomainService(apiKey, domainName string) (*SenderDomainResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	domainRepo := repository.NewSenderDomainRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	domainName, err = normalizeDomain(domainName)
+	if err != nil {
+		return nil, err
+	}
+
+	senderDomain, err := domainRepo.GetByDomain(ctx, business.PK[9:], domainName)
+	if err != nil {
+		return nil, fmt.Errorf("domain not found")
+	}
+
+	// Un fallo del resolver no prueba que el registro se haya retirado: no se toca el estado del dominio
+	tokenFound, err := dnsTXTContains(ctx, domainVerificationHost+"."+senderDomain.Domain, domainVerificationPrefix+senderDomain.VerificationToken)
+	if err != nil {
+		fmt.Printf("DNS lookup failed for %s: %v\n", senderDomain.Domain, err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	dkimFound, err := dnsTXTContains(ctx, senderDomain.DKIMSelector+"._domainkey."+senderDomain.Domain, dkimDNSRecord(senderDomain.DKIMPublicKey))
+	if err != nil {
+		fmt.Printf("DNS lookup failed for %s: %v\n", senderDomain.Domain, err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	if tokenFound && !senderDomain.Verified {
+		senderDomain.VerifiedAt = now
+	}
+	senderDomain.Verified = tokenFound
+	// Sin dominio verificado no se firma aunque el registro DKIM exista
+	senderDomain.DKIMEnabled = tokenFound && dkimFound
+	senderDomain.UpdatedAt = now
+
+	if err := domainRepo.UpdateVerification(ctx, senderDomain); err != nil {
+		fmt.Printf("Failed to update domain verification: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	// El primer dominio verificado pasa a ser el remitente por defecto del negocio
+	if senderDomain.Verified && business.DefaultSenderDomain == "" {
+		settings := map[string]interface{}{"defaultSenderDomain": senderDomain.Domain}
+		if err := businessRepo.UpdateSettings(ctx, business.PK, settings, now); err != nil {
+			fmt.Printf("Failed to set default sender domain: %v\n", err)
+		} else {
+			business.DefaultSenderDomain = senderDomain.Domain
+		}
+	}
+
+	if !senderDomain.Verified {
+		return nil, fmt.Errorf("domain verification failed")
+	}
+
+	resp := newSenderDomainResponse(senderDomain, business.DefaultSenderDomain)
+	return &resp, nil
+}
+
+// resolveEmailSender decide el remitente de un email:
+//   - from explícito: su dominio debe estar verificado por el negocio
+//   - sin from: el dominio por defecto del negocio, si está verificado
+//   - en otro caso: la dirección de la plataforma con el nombre del negocio
+//
+// Retorna el firmador DKIM si el dominio lo tiene activo.
+func resolveEmailSender(ctx context.Context, domainRepo *repository.SenderDomainRepository, business *models.Business, from, fromName string) (mail.Address, *dkimSigner, error) {
+	businessID := business.PK[9:]
+
+	domainName := business.DefaultSenderDomain
+	if from != "" {
+		normalized, err := validateEmailAddress(from)
+		if err != nil {
+			return mail.Address{}, nil, err
+		}
+		from = normalized
+		domainName = addressDomain(from)
+	}
+
+	if domainName == "" {
+		return mail.Address{Name: firstNonEmpty(fromName, business.Name), Address: emailFromAddress()}, nil, nil
+	}
+
+	senderDomain, err := domainRepo.GetByDomain(ctx, businessID, domainName)
+	if err != nil || !senderDomain.Verified {
+		if from != "" {
+			return mail.Address{}, nil, fmt.Errorf("sender not verified")
+		}
+		// El dominio por defecto dejó de ser válido: usar el remitente de la plataforma
+		fmt.Printf("Default sender domain %s unavailable, using platform sender\n", domainName)
+		return mail.Address{Name: firstNonEmpty(fromName, business.Name), Address: emailFromAddress()}, nil, nil
+	}
+
+	if from == "" {
+		from = senderDomain.FromAddress
+	}
+	address := mail.Address{Name: firstNonEmpty(fromName, senderDomain.FromName, business.Name), Address: from}
+
+	if !senderDomain.DKIMEnabled {
+		return address, nil, nil
+	}
+
+	signer, err := newDKIMSigner(senderDomain.Domain, senderDomain.DKIMSelector, senderDomain.DKIMPrivateKey)
+	if err != nil {
+		fmt.Printf("DKIM signer error for %s: %v\n", senderDomain.Domain, err)
+		return address, nil, nil
+	}
+
+	return address, signer, nil
+}
+
+// firstNonEmpty retorna el primer valor no vacío
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Codificaciones de un SMS
+const (
+	SMSEncodingGSM7 = "GSM-7"
+	SMSEncodingUCS2 = "UCS-2"
+)
+
+// Capacidad de un segmento: un SMS de varias partes reserva espacio para el header de concatenación (UDH)
+const (
+	gsm7SingleSegment = 160 // septetos
+	gsm7MultiSegment  = 153
+	ucs2SingleSegment = 70 // unidades UTF-16
+	ucs2MultiSegment  = 67
+)
+
+// maxSMSCharacters es el límite de Twilio para el cuerpo de un mensaje
+const maxSMSCharacters = 1600
+
+// gsm7Basic es el alfabeto básico GSM 03.38 (un septeto por carácter)
+const gsm7Basic = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?" +
+	"¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension son los caracteres de la tabla de extensión (escape + carácter = dos septetos)
+const gsm7Extension = "\f^{}\\[~]|€"
+
+// smsTransliterations reemplaza caracteres frecuentes en español que fuerzan UCS-2 por su equivalente GSM-7
+var smsTransliterations = strings.NewReplacer(
+	"á", "a", "í", "i", "ó", "o", "ú", "u",
+	"Á", "A", "Í", "I", "Ó", "O", "Ú", "U",
+	"â", "a", "ê", "e", "î", "i", "ô", "o", "û", "u",
+	"ã", "a", "õ", "o", "ç", "Ç", "ë", "e", "ï", "i",
+	"“", "\"", "”", "\"", "„", "\"", "‘", "'", "’", "'", "´", "'",
+	"–", "-", "—", "-", "…", "...", " ", " ", "ª", "a", "º", "o",
+)
+
+// SMSSegmentation describe cómo se envía un SMS
+type SMSSegmentation struct {
+	Encoding   string // GSM-7 o UCS-2
+	Characters int    // Caracteres del mensaje
+	Segments   int    // Partes facturadas por el operador
+}
+
+// gsm7Septets retorna cuántos septetos ocupa un carácter en GSM-7 (0 si no existe en el alfabeto)
+func gsm7Septets(r rune) int {
+	if strings.ContainsRune(gsm7Basic, r) {
+		return 1
+	}
+	if strings.ContainsRune(gsm7Extension, r) {
+		return 2
+	}
+	return 0
+}
+
+// isGSM7 indica si todo el mensaje se puede codificar en GSM-7
+func isGSM7(message string) bool {
+	for _, r := range message {
+		if gsm7Septets(r) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// segmentSMS calcula la codificación y el número de segmentos de un mensaje.
+// Un carácter nunca se parte entre segmentos: ni el escape de la tabla de extensión
+// ni los pares sustitutos de UTF-16 (emojis).
+func segmentSMS(message string) SMSSegmentation {
+	result := SMSSegmentation{
+		Encoding:   SMSEncodingGSM7,
+		Characters: utf8.RuneCountInString(message),
+	}
+	if message == "" {
+		return result
+	}
+
+	units := make([]int, 0, result.Characters)
+	if isGSM7(message) {
+		for _, r := range message {
+			units = append(units, gsm7Septets(r))
+		}
+		result.Segments = countSegments(units, gsm7SingleSegment, gsm7MultiSegment)
+		return result
+	}
+
+	result.Encoding = SMSEncodingUCS2
+	for _, r := range message {
+		units = append(units, len(utf16.Encode([]rune{r})))
+	}
+	result.Segments = countSegments(units, ucs2SingleSegment, ucs2MultiSegment)
+	return result
+}
+
+// countSegments reparte los caracteres (con su tamaño en unidades) en segmentos
+func countSegments(units []int, single, multi int) int {
+	total := 0
+	for _, size := range units {
+		total += size
+	}
+	if total <= single {
+		return 1
+	}
+
+	segments, used := 1, 0
+	for _, size := range units {
+		if used+size > multi {
+			segments++
+			used = 0
+		}
+		used += size
+	}
+	return segments
+}
+
+// transliterateSMS reemplaza tildes y signos tipográficos para que el mensaje quepa en GSM-7.
+// Si aun así quedan caracteres fuera del alfabeto (ej: emojis), retorna el mensaje original.
+func transliterateSMS(message string) (string, bool) {
+	if isGSM7(message) {
+		return message, false
+	}
+
+	transliterated := smsTransliterations.Replace(message)
+	if !isGSM7(transliterated) {
+		return message, false
+	}
+
+	return transliterated, true
+}
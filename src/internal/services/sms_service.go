@@ -4,26 +4,52 @@ import (
 	"context"
 	"fmt"
 	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
 	"notify-backend/internal/repository"
-	"notify-backend/internal/utils"
 	"regexp"
 	"strings"
+	"time"
 
+	"github.com/twilio/twilio-go"
 	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
 )
 
 type SendSMSRequest struct {
 	To         string            `json:"to"`
+	ContactID  string            `json:"contact_id"` // En lugar de to
 	TemplateID string            `json:"template_id"`
 	Parameters map[string]string `json:"parameters"`
+	MediaURLs  []string          `json:"media_urls"` // Adjuntos: el SMS se envía como MMS
+
+	// Reemplazar tildes y signos tipográficos para enviar en GSM-7 en lugar de UCS-2
+	Transliterate bool `json:"transliterate"`
+
+	// Horario de envío (delivery_window reemplaza el del negocio); otp y transactional no lo respetan
+	Category       string                 `json:"category"` // marketing (por defecto), transactional, otp
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+	timezone       string                 // Zona horaria del contacto o de la cita
+	inChain        bool                   // Paso de una cadena de canales: fuera del horario no se aplaza
+
+	SendAt      string `json:"send_at"` // RFC3339: se programa en lugar de enviarse
+	scheduledID string // Envío programado que está enviando el worker (cuota ya reservada)
 }
 
 type SendSMSResponse struct {
 	Success           bool   `json:"success"`
 	NotificationID    string `json:"notification_id"`
 	TemplateUsed      string `json:"template_used"`
+	Encoding          string `json:"encoding"`                 // GSM-7, UCS-2
+	Characters        int    `json:"characters"`               // Caracteres del mensaje enviado
+	Segments          int    `json:"segments"`                 // Partes en que el operador divide el mensaje
+	Transliterated    bool   `json:"transliterated,omitempty"` // Se reemplazaron caracteres para usar GSM-7
 	NotificationCount int    `json:"notification_count"`
 	NotificationLeft  int    `json:"notification_left"`
+
+	// Solo en envíos programados
+	Status   string `json:"status,omitempty"` // scheduled
+	SendAt   string `json:"send_at,omitempty"`
+	Deferred bool   `json:"deferred,omitempty"` // Aplazado por el horario de envío
 }
 
 // buildSMSMessage construye el mensaje SMS a partir del template y parámetros
@@ -42,20 +68,51 @@ func validateVerificationCode(code string) bool {
 	return matched
 }
 
+// sendTwilioSMS envía el SMS (MMS si hay adjuntos) por Twilio con el callback de estado y retorna el SID.
+// Es el envío de SendSMSService y del envío por lotes.
+func sendTwilioSMS(twilioClient *twilio.RestClient, from, to, body string, media []models.NotificationMedia) (string, error) {
+	params := &twilioApi.CreateMessageParams{}
+	params.SetTo(to)
+	params.SetFrom(from)
+	params.SetBody(body)
+	setTwilioStatusCallback(params)
+	if len(media) > 0 {
+		params.SetMediaUrl(mediaURLsOf(media))
+	}
+
+	twilioMessage, err := twilioClient.Api.CreateMessage(params)
+	if err != nil {
+		// Log interno del error real para debugging
+		fmt.Printf("Twilio SMS error: %v\n", err)
+		return "", fmt.Errorf("failed to send notification")
+	}
+
+	return *twilioMessage.Sid, nil
+}
+
 func SendSMSService(apiKey string, req SendSMSRequest) (*SendSMSResponse, error) {
 	client, _ := db.NewDynamoClient()
 	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
 	planRepo := repository.NewPlanRepository(client, "NotificationService")
 	usageRepo := repository.NewUsageRepository(client, "NotificationService")
 	templateRepo := repository.NewTemplateRepository(client, "NotificationService")
+	suppressionRepo := repository.NewSuppressionRepository(client, "NotificationService")
+	conversationRepo := repository.NewConversationRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
 	ctx := context.TODO()
 
-	// Validar formato del número de teléfono
-	formattedPhone := utils.FormatPhoneNumber(req.To)
-	if !utils.ValidatePhoneNumber(formattedPhone) {
-		return nil, fmt.Errorf("invalid phone number format")
+	sendAt, err := parseSendAt(req.SendAt)
+	if err != nil {
+		return nil, err
+	}
+	category, err := normalizeDeliveryCategory(req.Category)
+	if err != nil {
+		return nil, err
+	}
+	window, err := normalizeDeliveryWindow(req.DeliveryWindow)
+	if err != nil {
+		return nil, err
 	}
-	req.To = formattedPhone
 
 	// Buscar negocio por API Key
 	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
@@ -65,20 +122,52 @@ func SendSMSService(apiKey string, req SendSMSRequest) (*SendSMSResponse, error)
 
 	businessID := business.PK[9:] // Remover "BUSINESS#"
 
+	// Con contact_id se usan el teléfono del contacto, la variante de idioma de la plantilla y sus atributos
+	if req.ContactID != "" {
+		recipient, err := resolveContactRecipient(ctx, client, businessID, req.ContactID, NotificationTypeSMS, req.Category, req.To, req.TemplateID, req.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		req.To, req.TemplateID, req.Parameters = recipient.To, recipient.TemplateID, recipient.Parameters
+		if req.timezone == "" {
+			req.timezone = recipient.Timezone
+		}
+	}
+
+	// Normalizar el teléfono a E.164 (los números nacionales usan el país por defecto del negocio)
+	number, err := phone.Parse(req.To, business.DefaultCountry)
+	if err != nil {
+		return nil, err
+	}
+	if number.LineType == phone.LineTypeFixedLine {
+		return nil, fmt.Errorf("phone number cannot receive sms")
+	}
+	req.To = number.E164
+
+	// Rechazar destinatarios dados de baja antes de consumir cuota
+	if err := checkSuppressed(ctx, suppressionRepo, businessID, models.SuppressionChannelPhone, []string{req.To}); err != nil {
+		return nil, err
+	}
+
 	// Obtener plan
 	plan, err := planRepo.GetByID(ctx, business.PlanID)
 	if err != nil {
 		return nil, fmt.Errorf("service unavailable")
 	}
 
+	// Bloquear países de destino no permitidos antes de cualquier llamada al proveedor
+	if err := checkDestinationCountry(business, plan, models.CountryPolicyChannelSMS, number); err != nil {
+		return nil, err
+	}
+
 	// Verificar o crear período de uso
 	usage, err := usageRepo.CheckAndCreateNewPeriod(ctx, businessID, business.PlanID, plan.PeriodDays)
 	if err != nil {
 		return nil, fmt.Errorf("service unavailable")
 	}
 
-	// Verificar límite de notificaciones
-	if usage.NotificationCount >= plan.NotificationLimit {
+	// Verificar límite de notificaciones (un envío programado ya reservó su cuota)
+	if req.scheduledID == "" && usage.NotificationCount >= plan.NotificationLimit {
 		return nil, fmt.Errorf("notification limit reached")
 	}
 
@@ -104,12 +193,13 @@ func SendSMSService(apiKey string, req SendSMSRequest) (*SendSMSResponse, error)
 		}
 	}
 
-	// Validaciones específicas para template de verificación
+	// Validaciones específicas para template de verificación (siempre es un código: no espera al horario de envío)
 	if template.TemplateID == "sms_verification_code" {
 		code, hasCode := req.Parameters["codigo"]
 		if !hasCode || !validateVerificationCode(code) {
 			return nil, fmt.Errorf("invalid verification code format")
 		}
+		category = DeliveryCategoryOTP
 	}
 
 	// Construir mensaje desde template
@@ -117,11 +207,32 @@ func SendSMSService(apiKey string, req SendSMSRequest) (*SendSMSResponse, error)
 	req.Parameters["empresa"] = business.Name
 	message := buildSMSMessage(template.Description, req.Parameters)
 
-	// Validar longitud del mensaje final
-	if len(message) > 1600 {
+	transliterated := false
+	if req.Transliterate {
+		message, transliterated = transliterateSMS(message)
+	}
+
+	// Validar longitud del mensaje final (en caracteres, no bytes)
+	segmentation := segmentSMS(message)
+	if segmentation.Characters > maxSMSCharacters {
 		return nil, fmt.Errorf("message too long")
 	}
 
+	// Validar tipo y tamaño de los adjuntos (MMS)
+	media, err := validateMMSMedia(req.MediaURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unidades de cuota: por segmento si el plan lo indica; un MMS se cobra como un solo mensaje
+	billedUnits := 1
+	if plan.SMSChargePerSegment && len(media) == 0 {
+		billedUnits = segmentation.Segments
+	}
+	if req.scheduledID == "" && usage.NotificationCount+billedUnits > plan.NotificationLimit {
+		return nil, fmt.Errorf("notification limit reached")
+	}
+
 	// Obtener cliente de Twilio
 	twilioClient := GetTwilioClient()
 	twilioPhoneNumber := GetTwilioPhoneNumber()
@@ -131,32 +242,77 @@ func SendSMSService(apiKey string, req SendSMSRequest) (*SendSMSResponse, error)
 		return nil, fmt.Errorf("service temporarily unavailable")
 	}
 
-	// Enviar SMS a través de Twilio
-	params := &twilioApi.CreateMessageParams{}
-	params.SetTo(req.To)
-	params.SetFrom(twilioPhoneNumber)
-	params.SetBody(message)
+	// Fuera del horario de envío del destinatario el SMS se programa para el siguiente horario permitido.
+	// El worker no lo vuelve a aplicar: la fecha programada ya lo respeta.
+	deferred := false
+	if req.scheduledID == "" {
+		sendAt, deferred, err = scheduleInDeliveryWindow(business, window, category, req.timezone, number, sendAt, req.inChain)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	twilioMessage, err := twilioClient.Api.CreateMessage(params)
+	// Con send_at se reserva la cuota y el worker envía el SMS en esa fecha
+	if !sendAt.IsZero() {
+		req.ContactID = ""
+		scheduled, err := scheduleNotification(ctx, client, businessID, plan, usage, NotificationTypeSMS, []string{req.To}, template.TemplateID, billedUnits, sendAt, req)
+		if err != nil {
+			return nil, err
+		}
+		return &SendSMSResponse{
+			Success:           true,
+			NotificationID:    scheduled.NotificationID,
+			TemplateUsed:      template.Name,
+			Encoding:          segmentation.Encoding,
+			Characters:        segmentation.Characters,
+			Segments:          segmentation.Segments,
+			Transliterated:    transliterated,
+			NotificationCount: scheduled.NotificationCount,
+			NotificationLeft:  scheduled.NotificationLeft,
+			Status:            models.NotificationStatusScheduled,
+			SendAt:            scheduled.SendAt,
+			Deferred:          deferred,
+		}, nil
+	}
+
+	// Enviar SMS a través de Twilio
+	notificationID, err := sendTwilioSMS(twilioClient, twilioPhoneNumber, req.To, message, media)
 	if err != nil {
-		// Log interno del error real para debugging
-		fmt.Printf("Twilio SMS error: %v\n", err)
-		return nil, fmt.Errorf("failed to send notification")
+		return nil, err
 	}
+	fmt.Printf("SMS sent - MessageSID: %s, To: %s, Template: %s, Encoding: %s, Segments: %d\n",
+		notificationID, req.To, template.TemplateID, segmentation.Encoding, segmentation.Segments)
 
-	notificationID := *twilioMessage.Sid
-	fmt.Printf("SMS sent - MessageSID: %s, To: %s, Template: %s\n", notificationID, req.To, template.TemplateID)
+	// Las respuestas del destinatario se asignan a este negocio
+	recordOutboundConversation(ctx, conversationRepo, "sms", req.To, businessID)
+
+	recordNotification(ctx, notificationRepo, &models.Notification{
+		PK:                "NOTIFICATION#" + notificationID,
+		SK:                "METADATA",
+		NotificationID:    notificationID,
+		BusinessID:        businessID,
+		Channel:           "sms",
+		Recipients:        []string{req.To},
+		TemplateID:        template.TemplateID,
+		Status:            "sent",
+		Provider:          "twilio",
+		ProviderMessageID: notificationID,
+		Media:             media,
+		CreatedAt:         time.Now().Format(time.RFC3339),
+	})
 
 	// Incrementar contador de uso
-	err = usageRepo.IncrementUsage(ctx, businessID, usage.SK)
-	if err != nil {
-		// Log interno para debugging
-		fmt.Printf("Failed to increment usage: %v\n", err)
-		// No fallar la request si el mensaje ya fue enviado
+	if req.scheduledID == "" {
+		err = usageRepo.IncrementUsageBy(ctx, businessID, usage.SK, billedUnits)
+		if err != nil {
+			// Log interno para debugging
+			fmt.Printf("Failed to increment usage: %v\n", err)
+			// No fallar la request si el mensaje ya fue enviado
+		}
 	}
 
 	// Calcular notificaciones restantes
-	newCount := usage.NotificationCount + 1
+	newCount := usage.NotificationCount + billedUnits
 	notificationLeft := plan.NotificationLimit - newCount
 	if notificationLeft < 0 {
 		notificationLeft = 0
@@ -166,6 +322,10 @@ func SendSMSService(apiKey string, req SendSMSRequest) (*SendSMSResponse, error)
 		Success:           true,
 		NotificationID:    notificationID,
 		TemplateUsed:      template.Name,
+		Encoding:          segmentation.Encoding,
+		Characters:        segmentation.Characters,
+		Segments:          segmentation.Segments,
+		Transliterated:    transliterated,
 		NotificationCount: newCount,
 		NotificationLeft:  notificationLeft,
 	}, nil
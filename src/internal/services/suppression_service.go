@@ -0,0 +1,277 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
+	"notify-backend/internal/repository"
+	"notify-backend/internal/utils"
+	"strings"
+	"time"
+)
+
+// Razones de una supresión
+const (
+	SuppressionReasonUnsubscribe = "unsubscribe"
+	SuppressionReasonManual      = "manual"
+	SuppressionReasonStopKeyword = "stop_keyword"
+	SuppressionReasonConsent     = "consent_withdrawn"
+)
+
+const unsubscribePath = "/v1/u/"
+
+var validSuppressionReasons = map[string]bool{
+	SuppressionReasonUnsubscribe: true,
+	SuppressionReasonManual:      true,
+	SuppressionReasonStopKeyword: true,
+	SuppressionReasonConsent:     true,
+	"bounce":                     true,
+	"complaint":                  true,
+}
+
+type AddSuppressionRequest struct {
+	Channel string `json:"channel"` // email, phone
+	Value   string `json:"value"`
+	Reason  string `json:"reason,omitempty"` // Default: manual
+}
+
+type SuppressionResponse struct {
+	Channel   string `json:"channel"`
+	Value     string `json:"value"`
+	Reason    string `json:"reason"`
+	Source    string `json:"source,omitempty"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ListSuppressionsResponse struct {
+	Suppressions []SuppressionResponse `json:"suppressions"`
+	NextCursor   string                `json:"next_cursor,omitempty"`
+}
+
+// unsubscribeToken es el payload firmado de los enlaces de baja
+type unsubscribeToken struct {
+	BusinessID string `json:"b"`
+	Channel    string `json:"c"`
+	Value      string `json:"v"`
+}
+
+// normalizeSuppressionValue valida y normaliza un destinatario según el canal de supresión.
+// defaultCountry se usa para teléfonos en formato nacional.
+func normalizeSuppressionValue(channel, value, defaultCountry string) (string, error) {
+	switch channel {
+	case models.SuppressionChannelEmail:
+		normalized, err := validateEmailAddress(value)
+		if err != nil {
+			return "", err
+		}
+		return strings.ToLower(normalized), nil
+	case models.SuppressionChannelPhone:
+		number, err := phone.Parse(value, defaultCountry)
+		if err != nil {
+			return "", err
+		}
+		return number.E164, nil
+	}
+	return "", fmt.Errorf("invalid channel")
+}
+
+// checkSuppressed rechaza el envío si algún destinatario está en la lista de supresión del negocio.
+// Se llama antes de consumir cuota o llamar al proveedor.
+func checkSuppressed(ctx context.Context, repo *repository.SuppressionRepository, businessID, channel string, recipients []string) error {
+	values := make([]string, 0, len(recipients))
+	for _, recipient := range recipients {
+		if channel == models.SuppressionChannelEmail {
+			recipient = strings.ToLower(recipient)
+		}
+		values = append(values, recipient)
+	}
+
+	suppressed, err := repo.FindSuppressed(ctx, businessID, channel, values)
+	if err != nil {
+		fmt.Printf("Failed to check suppressions: %v\n", err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	if len(suppressed) > 0 {
+		return fmt.Errorf("recipient suppressed: %s", strings.Join(suppressed, ", "))
+	}
+
+	return nil
+}
+
+// listUnsubscribeHeaders genera List-Unsubscribe y List-Unsubscribe-Post (RFC 8058) para un destinatario
+func listUnsubscribeHeaders(businessID, email string) ([]mimeHeader, error) {
+	signed, err := utils.SignToken(unsubscribeToken{
+		BusinessID: businessID,
+		Channel:    models.SuppressionChannelEmail,
+		Value:      strings.ToLower(email),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	unsubscribeURL, err := utils.PublicURL(unsubscribePath + signed)
+	if err != nil {
+		return nil, err
+	}
+
+	return []mimeHeader{
+		{Key: "List-Unsubscribe", Value: "<" + unsubscribeURL + ">"},
+		{Key: "List-Unsubscribe-Post", Value: "List-Unsubscribe=One-Click"},
+	}, nil
+}
+
+// ValidateUnsubscribeTokenService valida el token de un enlace de baja y retorna el destinatario
+func ValidateUnsubscribeTokenService(token string) (string, error) {
+	var payload unsubscribeToken
+	if err := utils.VerifyToken(token, &payload); err != nil || payload.BusinessID == "" || payload.Value == "" {
+		return "", fmt.Errorf("invalid token")
+	}
+	return payload.Value, nil
+}
+
+// UnsubscribeService procesa una baja de un clic (POST del enlace de List-Unsubscribe o de la página de baja)
+func UnsubscribeService(token string) error {
+	var payload unsubscribeToken
+	if err := utils.VerifyToken(token, &payload); err != nil || payload.BusinessID == "" || payload.Value == "" {
+		return fmt.Errorf("invalid token")
+	}
+
+	client, _ := db.NewDynamoClient()
+	repo := repository.NewSuppressionRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	err := repo.Put(ctx, &models.Suppression{
+		PK:         "BUSINESS#" + payload.BusinessID,
+		BusinessID: payload.BusinessID,
+		Channel:    payload.Channel,
+		Value:      payload.Value,
+		Reason:     SuppressionReasonUnsubscribe,
+		Source:     "list-unsubscribe",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+	})
+	if err != nil {
+		fmt.Printf("Failed to store unsubscribe: %v\n", err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	return nil
+}
+
+func AddSuppressionService(apiKey string, req AddSuppressionRequest) (*SuppressionResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	repo := repository.NewSuppressionRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	value, err := normalizeSuppressionValue(req.Channel, req.Value, business.DefaultCountry)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = SuppressionReasonManual
+	}
+	if !validSuppressionReasons[reason] {
+		return nil, fmt.Errorf("invalid reason")
+	}
+
+	suppression := &models.Suppression{
+		PK:         business.PK,
+		BusinessID: business.PK[9:], // Remover "BUSINESS#"
+		Channel:    req.Channel,
+		Value:      value,
+		Reason:     reason,
+		Source:     "api",
+		CreatedAt:  time.Now().Format(time.RFC3339),
+	}
+
+	if err := repo.Put(ctx, suppression); err != nil {
+		fmt.Printf("Failed to add suppression: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	return &SuppressionResponse{
+		Channel:   suppression.Channel,
+		Value:     suppression.Value,
+		Reason:    suppression.Reason,
+		Source:    suppression.Source,
+		CreatedAt: suppression.CreatedAt,
+	}, nil
+}
+
+func RemoveSuppressionService(apiKey, channel, value string) error {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	repo := repository.NewSuppressionRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return fmt.Errorf("authentication failed")
+	}
+
+	value, err = normalizeSuppressionValue(channel, value, business.DefaultCountry)
+	if err != nil {
+		return err
+	}
+
+	if err := repo.Delete(ctx, business.PK[9:], channel, value); err != nil {
+		if err.Error() == "suppression not found" {
+			return err
+		}
+		fmt.Printf("Failed to remove suppression: %v\n", err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	return nil
+}
+
+func ListSuppressionsService(apiKey, channel string, limit int32, cursor string) (*ListSuppressionsResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	repo := repository.NewSuppressionRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	if channel != "" && channel != models.SuppressionChannelEmail && channel != models.SuppressionChannelPhone {
+		return nil, fmt.Errorf("invalid channel")
+	}
+
+	suppressions, nextCursor, err := repo.ListPage(ctx, business.PK[9:], channel, limit, cursor)
+	if err != nil {
+		if err.Error() == "invalid cursor" {
+			return nil, err
+		}
+		fmt.Printf("Failed to list suppressions: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	resp := &ListSuppressionsResponse{
+		Suppressions: make([]SuppressionResponse, 0, len(suppressions)),
+		NextCursor:   nextCursor,
+	}
+	for _, suppression := range suppressions {
+		resp.Suppressions = append(resp.Suppressions, SuppressionResponse{
+			Channel:   suppression.Channel,
+			Value:     suppression.Value,
+			Reason:    suppression.Reason,
+			Source:    suppression.Source,
+			CreatedAt: suppression.CreatedAt,
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,255 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/repository"
+	"notify-backend/internal/utils"
+	"time"
+)
+
+type NotificationResponse struct {
+	NotificationID    string          `json:"notification_id"`
+	Channel           string          `json:"channel"`
+	Recipients        []string        `json:"recipients"`
+	TemplateID        string          `json:"template_id,omitempty"`
+	Status            string          `json:"status"`
+	Provider          string          `json:"provider"`
+	ProviderMessageID string          `json:"provider_message_id,omitempty"`
+	CreatedAt         string          `json:"created_at"`
+	Media             []MediaResponse `json:"media,omitempty"`
+	Tracking          *TrackingStats  `json:"tracking,omitempty"`
+
+	Error           string `json:"error,omitempty"`             // Motivo del fallo o de la no entrega
+	StatusUpdatedAt string `json:"status_updated_at,omitempty"` // Último reporte de entrega del proveedor
+	ChainID         string `json:"chain_id,omitempty"`          // Cadena de canales de respaldo del intento
+
+	// Envíos programados
+	SendAt                   string `json:"send_at,omitempty"`
+	DispatchedNotificationID string `json:"dispatched_notification_id,omitempty"` // Notificación enviada en send_at
+}
+
+// MediaResponse es un adjunto enviado por URL (SMS/MMS y WhatsApp)
+type MediaResponse struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size,omitempty"`
+}
+
+// TrackingStats son las métricas de apertura y clics de una notificación
+type TrackingStats struct {
+	TrackOpens     bool           `json:"track_opens"`
+	TrackClicks    bool           `json:"track_clicks"`
+	Opens          int            `json:"opens"`
+	Clicks         int            `json:"clicks"`
+	LinkClicks     map[string]int `json:"link_clicks"`
+	FirstOpenedAt  string         `json:"first_opened_at,omitempty"`
+	LastOpenedAt   string         `json:"last_opened_at,omitempty"`
+	FirstClickedAt string         `json:"first_clicked_at,omitempty"`
+	LastClickedAt  string         `json:"last_clicked_at,omitempty"`
+}
+
+type TemplateStatsResponse struct {
+	TemplateID   string  `json:"template_id"`
+	Sent         int     `json:"sent"`          // Emails con pixel de apertura
+	ClickTracked int     `json:"click_tracked"` // Emails con enlaces de tracking
+	Opens        int     `json:"opens"`
+	UniqueOpens  int     `json:"unique_opens"`
+	Clicks       int     `json:"clicks"`
+	UniqueClicks int     `json:"unique_clicks"`
+	OpenRate     float64 `json:"open_rate"`  // unique_opens / sent
+	ClickRate    float64 `json:"click_rate"` // unique_clicks / click_tracked
+	UpdatedAt    string  `json:"updated_at,omitempty"`
+}
+
+func newNotificationResponse(notification *models.Notification) *NotificationResponse {
+	resp := &NotificationResponse{
+		NotificationID:    notification.NotificationID,
+		Channel:           notification.Channel,
+		Recipients:        notification.Recipients,
+		TemplateID:        notification.TemplateID,
+		Status:            notification.Status,
+		Provider:          notification.Provider,
+		ProviderMessageID: notification.ProviderMessageID,
+		CreatedAt:         notification.CreatedAt,
+		Error:             notification.Error,
+		StatusUpdatedAt:   notification.StatusUpdatedAt,
+		ChainID:           notification.ChainID,
+
+		SendAt:                   notification.SendAt,
+		DispatchedNotificationID: notification.DispatchedNotificationID,
+	}
+
+	for _, media := range notification.Media {
+		resp.Media = append(resp.Media, MediaResponse{
+			URL:         media.URL,
+			ContentType: media.ContentType,
+			Size:        media.Size,
+		})
+	}
+
+	if notification.TrackOpens || notification.TrackClicks {
+		resp.Tracking = &TrackingStats{
+			TrackOpens:     notification.TrackOpens,
+			TrackClicks:    notification.TrackClicks,
+			Opens:          notification.Opens,
+			Clicks:         notification.Clicks,
+			LinkClicks:     notification.LinkClicks,
+			FirstOpenedAt:  notification.FirstOpenedAt,
+			LastOpenedAt:   notification.LastOpenedAt,
+			FirstClickedAt: notification.FirstClickedAt,
+			LastClickedAt:  notification.LastClickedAt,
+		}
+	}
+
+	return resp
+}
+
+// recordNotification guarda el registro de una notificación enviada y suma el envío a las métricas de su plantilla.
+// Las métricas solo cuentan emails con tracking: un SMS, un WhatsApp o un email sin pixel nunca registran
+// aperturas y bajarían la tasa. Los errores solo se registran: el mensaje ya fue enviado.
+func recordNotification(ctx context.Context, repo *repository.NotificationRepository, notification *models.Notification) {
+	if err := repo.Create(ctx, notification); err != nil {
+		fmt.Printf("Failed to record notification %s: %v\n", notification.NotificationID, err)
+		return
+	}
+
+	counters := map[string]int{}
+	if notification.TrackOpens {
+		counters["sent"] = 1
+	}
+	if notification.TrackClicks {
+		counters["clickTracked"] = 1
+	}
+
+	if notification.TemplateID != "" && len(counters) > 0 {
+		if err := repo.IncrementTemplateStats(ctx, notification.BusinessID, notification.TemplateID, counters, notification.CreatedAt); err != nil {
+			fmt.Printf("Failed to update template stats: %v\n", err)
+		}
+	}
+}
+
+// RecordOpenService registra la apertura indicada por el token del pixel
+func RecordOpenService(token string) error {
+	var payload trackingToken
+	if err := utils.VerifyToken(token, &payload); err != nil || payload.NotificationID == "" {
+		return fmt.Errorf("invalid token")
+	}
+
+	client, _ := db.NewDynamoClient()
+	repo := repository.NewNotificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+	now := time.Now().Format(time.RFC3339)
+
+	notification, err := repo.RecordOpen(ctx, payload.NotificationID, now)
+	if err != nil {
+		fmt.Printf("Failed to record open for %s: %v\n", payload.NotificationID, err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	if notification.TemplateID != "" {
+		counters := map[string]int{"opens": 1}
+		if notification.Opens == 1 {
+			counters["uniqueOpens"] = 1
+		}
+		if err := repo.IncrementTemplateStats(ctx, notification.BusinessID, notification.TemplateID, counters, now); err != nil {
+			fmt.Printf("Failed to update template stats: %v\n", err)
+		}
+	}
+
+	return nil
+}
+
+// RecordClickService registra el clic y retorna la URL de destino.
+// El destino viene firmado en el token, así la redirección funciona aunque falle el registro.
+func RecordClickService(token string) (string, error) {
+	var payload trackingToken
+	if err := utils.VerifyToken(token, &payload); err != nil || payload.NotificationID == "" || !isTrackableURL(payload.URL) {
+		return "", fmt.Errorf("invalid token")
+	}
+
+	client, _ := db.NewDynamoClient()
+	repo := repository.NewNotificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+	now := time.Now().Format(time.RFC3339)
+
+	notification, err := repo.RecordClick(ctx, payload.NotificationID, payload.URL, now)
+	if err != nil {
+		fmt.Printf("Failed to record click for %s: %v\n", payload.NotificationID, err)
+		return payload.URL, nil
+	}
+
+	if notification.TemplateID != "" {
+		counters := map[string]int{"clicks": 1}
+		if notification.Clicks == 1 {
+			counters["uniqueClicks"] = 1
+		}
+		if err := repo.IncrementTemplateStats(ctx, notification.BusinessID, notification.TemplateID, counters, now); err != nil {
+			fmt.Printf("Failed to update template stats: %v\n", err)
+		}
+	}
+
+	return payload.URL, nil
+}
+
+func GetNotificationService(apiKey, notificationID string) (*NotificationResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	repo := repository.NewNotificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	notification, err := repo.GetByID(ctx, notificationID)
+	// Una notificación de otro negocio se reporta como inexistente
+	if err != nil || notification.BusinessID != business.PK[9:] {
+		return nil, fmt.Errorf("notification not found")
+	}
+
+	return newNotificationResponse(notification), nil
+}
+
+func GetTemplateStatsService(apiKey, templateID string) (*TemplateStatsResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	repo := repository.NewNotificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	if !templateIDRegex.MatchString(templateID) {
+		return nil, fmt.Errorf("invalid template id")
+	}
+
+	stats, err := repo.GetTemplateStats(ctx, business.PK[9:], templateID)
+	if err != nil {
+		return nil, fmt.Errorf("template stats not found")
+	}
+
+	resp := &TemplateStatsResponse{
+		TemplateID:   stats.TemplateID,
+		Sent:         stats.Sent,
+		ClickTracked: stats.ClickTracked,
+		Opens:        stats.Opens,
+		UniqueOpens:  stats.UniqueOpens,
+		Clicks:       stats.Clicks,
+		UniqueClicks: stats.UniqueClicks,
+		UpdatedAt:    stats.UpdatedAt,
+	}
+	if stats.Sent > 0 {
+		resp.OpenRate = float64(stats.UniqueOpens) / float64(stats.Sent)
+	}
+	if stats.ClickTracked > 0 {
+		resp.ClickRate = float64(stats.UniqueClicks) / float64(stats.ClickTracked)
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,427 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"fmt"
+	"math/big"
+	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/repository"
+	"notify-backend/internal/utils"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Límites de las verificaciones (OTP)
+const (
+	defaultVerificationCodeLength = 6
+	defaultVerificationTTL        = 10 * time.Minute
+	minVerificationTTL            = time.Minute
+	maxVerificationTTL            = time.Hour
+	maxVerificationAttempts       = 5 // Códigos incorrectos antes del bloqueo
+	verificationLockout           = 15 * time.Minute
+	verificationResendInterval    = 30 * time.Second // Entre envíos al mismo destinatario
+	verificationSendWindow        = time.Hour
+	maxVerificationSends          = 5 // Envíos al mismo destinatario por verificationSendWindow
+)
+
+// verificationTemplate es la plantilla por defecto de un canal y el parámetro que recibe el código
+type verificationTemplate struct {
+	TemplateID string
+	CodeParam  string
+}
+
+var verificationTemplates = map[string]verificationTemplate{
+	NotificationTypeSMS:      {TemplateID: "sms_verification_code", CodeParam: "codigo"},
+	NotificationTypeWhatsApp: {TemplateID: "whatsapp-verification-code", CodeParam: "code"},
+}
+
+// StartVerificationRequest genera y envía un código de verificación
+type StartVerificationRequest struct {
+	To         string            `json:"to"`
+	Channel    string            `json:"channel"`     // sms (por defecto), whatsapp, email
+	TemplateID string            `json:"template_id"` // sms y whatsapp; el código va en el parámetro codigo (sms) o code (whatsapp)
+	Parameters map[string]string `json:"parameters"`  // Otros parámetros de la plantilla
+	CodeLength int               `json:"code_length"` // Dígitos: 4 a 6 (por defecto 6)
+	TTL        int               `json:"ttl"`         // Segundos de validez: 60 a 3600 (por defecto 600)
+}
+
+type StartVerificationResponse struct {
+	VerificationID    string `json:"verification_id"`
+	Channel           string `json:"channel"`
+	To                string `json:"to"`
+	Status            string `json:"status"`
+	ExpiresAt         string `json:"expires_at"`
+	NotificationID    string `json:"notification_id"`
+	ResendAvailableAt string `json:"resend_available_at"`
+	SendsLeft         int    `json:"sends_left"` // Reenvíos disponibles en la hora en curso
+}
+
+// CheckVerificationRequest valida el código recibido por el destinatario
+type CheckVerificationRequest struct {
+	To   string `json:"to"`
+	Code string `json:"code"`
+}
+
+type CheckVerificationResponse struct {
+	VerificationID string `json:"verification_id"`
+	Channel        string `json:"channel"`
+	To             string `json:"to"`
+	Valid          bool   `json:"valid"`
+	Status         string `json:"status"` // approved, pending
+	AttemptsLeft   int    `json:"attempts_left"`
+}
+
+// generateVerificationCode genera un código numérico aleatorio de length dígitos
+func generateVerificationCode(length int) (string, error) {
+	digits := make([]byte, length)
+	for i := range digits {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", err
+		}
+		digits[i] = byte('0' + n.Int64())
+	}
+	return string(digits), nil
+}
+
+// hashVerificationCode retorna el HMAC del código con la sal de la verificación (llave SIGNING_SECRET)
+func hashVerificationCode(salt, code string) (string, error) {
+	return utils.HashSecret(salt + ":" + code)
+}
+
+// verificationRecipient normaliza el destinatario: email o teléfono en E.164
+func verificationRecipient(to, defaultCountry string) (string, string, error) {
+	to = strings.TrimSpace(to)
+	if strings.Contains(to, "@") {
+		normalized, err := normalizeSuppressionValue(models.SuppressionChannelEmail, to, defaultCountry)
+		return normalized, models.SuppressionChannelEmail, err
+	}
+	normalized, err := normalizeSuppressionValue(models.SuppressionChannelPhone, to, defaultCountry)
+	return normalized, models.SuppressionChannelPhone, err
+}
+
+// verificationExpired indica si el código ya venció
+func verificationExpired(verification *models.Verification, now time.Time) bool {
+	expiresAt, err := time.Parse(time.RFC3339, verification.ExpiresAt)
+	return err != nil || !now.Before(expiresAt)
+}
+
+// afterTime indica si el instante guardado (RFC3339) es posterior a now; vacío o inválido es falso
+func afterTime(value string, now time.Time) bool {
+	at, err := time.Parse(time.RFC3339, value)
+	return err == nil && at.After(now)
+}
+
+func StartVerificationService(apiKey string, req StartVerificationRequest) (*StartVerificationResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	verificationRepo := repository.NewVerificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	if req.Channel == "" {
+		req.Channel = NotificationTypeSMS
+	}
+	if req.Channel != NotificationTypeSMS && req.Channel != NotificationTypeWhatsApp && req.Channel != NotificationTypeEmail {
+		return nil, fmt.Errorf("invalid channel")
+	}
+	if strings.TrimSpace(req.To) == "" {
+		return nil, fmt.Errorf("to is required")
+	}
+	if req.CodeLength == 0 {
+		req.CodeLength = defaultVerificationCodeLength
+	}
+	if req.CodeLength < 4 || req.CodeLength > 6 {
+		return nil, fmt.Errorf("invalid code_length")
+	}
+	ttl := defaultVerificationTTL
+	if req.TTL != 0 {
+		ttl = time.Duration(req.TTL) * time.Second
+		if ttl < minVerificationTTL || ttl > maxVerificationTTL {
+			return nil, fmt.Errorf("invalid ttl")
+		}
+	}
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+	businessID := business.PK[9:] // Remover "BUSINESS#"
+
+	to, kind, err := verificationRecipient(req.To, business.DefaultCountry)
+	if err != nil {
+		return nil, err
+	}
+	if (kind == models.SuppressionChannelEmail) != (req.Channel == NotificationTypeEmail) {
+		return nil, fmt.Errorf("to does not match channel")
+	}
+
+	now := time.Now().UTC()
+	previous, err := verificationRepo.Get(ctx, businessID, to)
+	if err != nil {
+		if err.Error() != "verification not found" {
+			fmt.Printf("Failed to get verification: %v\n", err)
+			return nil, fmt.Errorf("service unavailable")
+		}
+		previous = nil
+	}
+
+	// 1. Bloqueo por intentos y límite de reenvíos del destinatario
+	version := 0
+	if previous != nil {
+		version = previous.Version
+		if afterTime(previous.LockedUntil, now) {
+			return nil, fmt.Errorf("verification locked")
+		}
+		if lastSent, err := time.Parse(time.RFC3339, previous.LastSentAt); err == nil && now.Before(lastSent.Add(verificationResendInterval)) {
+			return nil, fmt.Errorf("resend too soon")
+		}
+	}
+
+	// Un código vigente se reemplaza conservando sus intentos; si no, es una verificación nueva
+	verification := previous
+	if previous == nil || previous.Status != models.VerificationStatusPending || verificationExpired(previous, now) {
+		verification = &models.Verification{
+			PK:             "BUSINESS#" + businessID,
+			SK:             "VERIFICATION#" + to,
+			VerificationID: uuid.New().String(),
+			BusinessID:     businessID,
+			To:             to,
+			CreatedAt:      now.Format(time.RFC3339),
+		}
+		if previous != nil {
+			verification.SendCount = previous.SendCount
+			verification.SendWindowStart = previous.SendWindowStart
+			verification.LastSentAt = previous.LastSentAt
+		}
+	}
+
+	if windowStart, err := time.Parse(time.RFC3339, verification.SendWindowStart); err != nil || !now.Before(windowStart.Add(verificationSendWindow)) {
+		verification.SendWindowStart = now.Format(time.RFC3339)
+		verification.SendCount = 0
+	}
+	if verification.SendCount >= maxVerificationSends {
+		return nil, fmt.Errorf("too many verification requests")
+	}
+
+	// 2. Nuevo código: solo se guarda su hash
+	code, err := generateVerificationCode(req.CodeLength)
+	if err != nil {
+		return nil, fmt.Errorf("service unavailable")
+	}
+	salt, err := generateWebhookSecret()
+	if err != nil {
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	codeHash, err := hashVerificationCode(salt, code)
+	if err != nil {
+		fmt.Printf("Failed to hash verification code: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	// Un código vigente que se reenvía sigue siendo válido si el nuevo no se puede enviar
+	replaced := *verification
+	lastSentAt := verification.LastSentAt
+	verification.Channel = req.Channel
+	verification.Status = models.VerificationStatusPending
+	verification.CodeSalt = salt
+	verification.CodeHash = codeHash
+	verification.ExpiresAt = now.Add(ttl).Format(time.RFC3339)
+	verification.NotificationID = ""
+	verification.SendCount++
+	verification.LastSentAt = now.Format(time.RFC3339)
+	verification.UpdatedAt = now.Format(time.RFC3339)
+
+	// Se guarda antes de enviar: dos solicitudes simultáneas no envían dos códigos
+	if err := verificationRepo.Save(ctx, verification, version); err != nil {
+		if err.Error() == "verification modified" {
+			return nil, fmt.Errorf("resend too soon")
+		}
+		fmt.Printf("Failed to save verification: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	// 3. Enviar el código; si falla, el envío no cuenta para el límite y el código se descarta
+	// (si reemplazaba a uno vigente, ese vuelve a ser el válido)
+	notificationID, err := sendVerificationCode(apiKey, business, req, to, code, ttl)
+	if err != nil {
+		if verification == previous {
+			verification.Channel = replaced.Channel
+			verification.CodeSalt = replaced.CodeSalt
+			verification.CodeHash = replaced.CodeHash
+			verification.ExpiresAt = replaced.ExpiresAt
+			verification.NotificationID = replaced.NotificationID
+		} else {
+			verification.Status = models.VerificationStatusFailed
+		}
+		verification.SendCount--
+		verification.LastSentAt = lastSentAt
+		if saveErr := verificationRepo.Save(ctx, verification, verification.Version); saveErr != nil {
+			fmt.Printf("Failed to discard verification %s: %v\n", verification.VerificationID, saveErr)
+		}
+		return nil, err
+	}
+
+	verification.NotificationID = notificationID
+	if err := verificationRepo.Save(ctx, verification, verification.Version); err != nil {
+		fmt.Printf("Failed to record verification notification %s: %v\n", notificationID, err)
+	}
+
+	return &StartVerificationResponse{
+		VerificationID:    verification.VerificationID,
+		Channel:           verification.Channel,
+		To:                verification.To,
+		Status:            verification.Status,
+		ExpiresAt:         verification.ExpiresAt,
+		NotificationID:    notificationID,
+		ResendAvailableAt: now.Add(verificationResendInterval).Format(time.RFC3339),
+		SendsLeft:         maxVerificationSends - verification.SendCount,
+	}, nil
+}
+
+// sendVerificationCode envía el código con el servicio del canal (categoría otp: no espera al horario de envío)
+func sendVerificationCode(apiKey string, business *models.Business, req StartVerificationRequest, to, code string, ttl time.Duration) (string, error) {
+	if req.Channel == NotificationTypeEmail {
+		minutes := int(ttl / time.Minute)
+		result, err := SendEmailService(apiKey, SendEmailRequest{
+			To:         []string{to},
+			Subject:    fmt.Sprintf("Tu código de verificación de %s", business.Name),
+			Body:       fmt.Sprintf("Tu código de verificación para %s es: %s\n\nVence en %d minutos. Si no lo solicitaste, ignora este mensaje.", business.Name, code, minutes),
+			TemplateID: "verification_code",
+		})
+		if err != nil {
+			return "", err
+		}
+		return result.NotificationID, nil
+	}
+
+	template := verificationTemplates[req.Channel]
+	if req.TemplateID != "" {
+		template.TemplateID = req.TemplateID
+	}
+	params := make(map[string]string, len(req.Parameters)+1)
+	for key, value := range req.Parameters {
+		params[key] = value
+	}
+	params[template.CodeParam] = code
+
+	if req.Channel == NotificationTypeSMS {
+		result, err := SendSMSService(apiKey, SendSMSRequest{
+			To:         to,
+			TemplateID: template.TemplateID,
+			Parameters: params,
+			Category:   DeliveryCategoryOTP,
+		})
+		if err != nil {
+			return "", err
+		}
+		return result.NotificationID, nil
+	}
+
+	result, err := SendWhatsAppService(apiKey, SendWhatsAppRequest{
+		To:         to,
+		TemplateID: template.TemplateID,
+		Parameters: params,
+		Category:   DeliveryCategoryOTP,
+	})
+	if err != nil {
+		return "", err
+	}
+	return result.NotificationID, nil
+}
+
+func CheckVerificationService(apiKey string, req CheckVerificationRequest) (*CheckVerificationResponse, error) {
+	client, _ := db.NewDynamoClient()
+	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
+	verificationRepo := repository.NewVerificationRepository(client, "NotificationService")
+	ctx := context.TODO()
+
+	code := strings.TrimSpace(req.Code)
+	if strings.TrimSpace(req.To) == "" {
+		return nil, fmt.Errorf("to is required")
+	}
+	if code == "" {
+		return nil, fmt.Errorf("code is required")
+	}
+
+	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
+	if err != nil {
+		return nil, fmt.Errorf("authentication failed")
+	}
+
+	to, _, err := verificationRecipient(req.To, business.DefaultCountry)
+	if err != nil {
+		return nil, err
+	}
+
+	verification, err := verificationRepo.Get(ctx, business.PK[9:], to)
+	if err != nil {
+		if err.Error() == "verification not found" {
+			return nil, err
+		}
+		fmt.Printf("Failed to get verification: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	now := time.Now().UTC()
+	if afterTime(verification.LockedUntil, now) {
+		return nil, fmt.Errorf("too many attempts")
+	}
+	// Un código aprobado no se puede volver a usar
+	if verification.Status != models.VerificationStatusPending {
+		return nil, fmt.Errorf("verification not found")
+	}
+	if verificationExpired(verification, now) {
+		return nil, fmt.Errorf("verification expired")
+	}
+
+	codeHash, err := hashVerificationCode(verification.CodeSalt, code)
+	if err != nil {
+		fmt.Printf("Failed to hash verification code: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+	valid := subtle.ConstantTimeCompare([]byte(codeHash), []byte(verification.CodeHash)) == 1
+	if valid {
+		verification.Status = models.VerificationStatusApproved
+		verification.ApprovedAt = now.Format(time.RFC3339)
+	} else {
+		verification.Attempts++
+		if verification.Attempts >= maxVerificationAttempts {
+			verification.Status = models.VerificationStatusLocked
+			verification.LockedUntil = now.Add(verificationLockout).Format(time.RFC3339)
+		}
+	}
+	verification.UpdatedAt = now.Format(time.RFC3339)
+
+	// Un intento que no se pudo registrar no revela si el código era correcto
+	if err := verificationRepo.Save(ctx, verification, verification.Version); err != nil {
+		if err.Error() == "verification modified" {
+			return nil, err
+		}
+		fmt.Printf("Failed to save verification: %v\n", err)
+		return nil, fmt.Errorf("service unavailable")
+	}
+
+	if verification.Status == models.VerificationStatusLocked {
+		return nil, fmt.Errorf("too many attempts")
+	}
+
+	attemptsLeft := maxVerificationAttempts - verification.Attempts
+	if valid {
+		attemptsLeft = 0
+	}
+
+	return &CheckVerificationResponse{
+		VerificationID: verification.VerificationID,
+		Channel:        verification.Channel,
+		To:             verification.To,
+		Valid:          valid,
+		Status:         verification.Status,
+		AttemptsLeft:   attemptsLeft,
+	}, nil
+}
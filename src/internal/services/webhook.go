@@ -0,0 +1,98 @@
+package services
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"notify-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const webhookTimeout = 5 * time.Second
+
+// webhookEvent es el cuerpo enviado al webhook del negocio
+type webhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"` // message.inbound, ...
+	CreatedAt string      `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// generateWebhookSecret genera el secreto con el que se firman los eventos del negocio
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
+
+// validateWebhookURL exige una URL https absoluta (http solo para localhost en desarrollo)
+func validateWebhookURL(raw string) error {
+	parsed, err := url.ParseRequestURI(raw)
+	if err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid webhook url")
+	}
+	if parsed.Scheme != "https" && !(parsed.Scheme == "http" && isLocalhost(parsed.Hostname())) {
+		return fmt.Errorf("invalid webhook url")
+	}
+	return nil
+}
+
+// deliverWebhook envía un evento al webhook del negocio, si tiene uno configurado.
+// Firma: X-Notify-Signature = sha256=hex(HMAC-SHA256(secret, "{timestamp}.{body}")).
+// La entrega es de un solo intento; los errores solo se registran.
+func deliverWebhook(business *models.Business, eventType string, data interface{}) {
+	if business.WebhookURL == "" || business.WebhookSecret == "" {
+		return
+	}
+
+	now := time.Now()
+	body, err := json.Marshal(webhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: now.Format(time.RFC3339),
+		Data:      data,
+	})
+	if err != nil {
+		fmt.Printf("Failed to encode webhook event: %v\n", err)
+		return
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	mac := hmac.New(sha256.New, []byte(business.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	httpReq, err := http.NewRequest(http.MethodPost, business.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		fmt.Printf("Invalid webhook request: %v\n", err)
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-Notify-Event", eventType)
+	httpReq.Header.Set("X-Notify-Timestamp", timestamp)
+	httpReq.Header.Set("X-Notify-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	httpClient := &http.Client{Timeout: webhookTimeout}
+	resp, err := httpClient.Do(httpReq)
+	if err != nil {
+		fmt.Printf("Webhook %s delivery failed: %v\n", eventType, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		fmt.Printf("Webhook %s rejected with status %d\n", eventType, resp.StatusCode)
+	}
+}
@@ -5,24 +5,53 @@ import (
 	"encoding/json"
 	"fmt"
 	"notify-backend/internal/db"
+	"notify-backend/internal/models"
+	"notify-backend/internal/phone"
 	"notify-backend/internal/repository"
-	"notify-backend/internal/utils"
+	"time"
+	"unicode/utf8"
 
+	"github.com/twilio/twilio-go"
 	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
 )
 
+// whatsappSessionWindow es el tiempo desde el último mensaje del destinatario
+// durante el cual WhatsApp permite mensajes libres (sin plantilla aprobada)
+const whatsappSessionWindow = 24 * time.Hour
+
+// maxWhatsAppBodyLength es el límite de caracteres de un mensaje de texto de WhatsApp
+const maxWhatsAppBodyLength = 4096
+
 type SendWhatsAppRequest struct {
 	To         string            `json:"to"`
+	ContactID  string            `json:"contact_id"` // En lugar de to
 	TemplateID string            `json:"template_id"`
 	Parameters map[string]string `json:"parameters"`
+	Body       string            `json:"body"`       // Mensaje de sesión (sin plantilla)
+	MediaURLs  []string          `json:"media_urls"` // Adjunto (mensaje de sesión o header multimedia de la plantilla)
+
+	// Horario de envío (delivery_window reemplaza el del negocio); otp y transactional no lo respetan
+	Category       string                 `json:"category"` // marketing (por defecto), transactional, otp
+	DeliveryWindow *models.DeliveryWindow `json:"delivery_window"`
+	timezone       string                 // Zona horaria del contacto o de la cita
+	inChain        bool                   // Paso de una cadena de canales: fuera del horario no se aplaza
+
+	SendAt      string `json:"send_at"` // RFC3339: se programa en lugar de enviarse
+	scheduledID string // Envío programado que está enviando el worker (cuota ya reservada)
 }
 
 type SendWhatsAppResponse struct {
 	Success           bool   `json:"success"`
 	NotificationID    string `json:"notification_id"`
-	TemplateUsed      string `json:"template_used"`
+	MessageType       string `json:"message_type"` // template, session
+	TemplateUsed      string `json:"template_used,omitempty"`
 	NotificationCount int    `json:"notification_count"`
 	NotificationLeft  int    `json:"notification_left"`
+
+	// Solo en envíos programados
+	Status   string `json:"status,omitempty"` // scheduled
+	SendAt   string `json:"send_at,omitempty"`
+	Deferred bool   `json:"deferred,omitempty"` // Aplazado por el horario de envío
 }
 
 // buildTwilioContentVariables convierte parámetros nombrados a formato JSON de Twilio
@@ -41,20 +70,115 @@ func buildTwilioContentVariables(paramOrder []string, paramValues map[string]str
 	return string(jsonBytes)
 }
 
+// whatsAppMediaVariable es el nombre interno de la variable del header multimedia de una plantilla
+const whatsAppMediaVariable = "_media"
+
+// setWhatsAppTemplate prepara el envío de una plantilla aprobada (Content API de Twilio).
+// Con ContentSid Twilio no usa MediaUrl: el adjunto de una plantilla con header multimedia va en la
+// variable siguiente a sus parámetros ({{n+1}}), que es la URL del media en la plantilla de Twilio.
+func setWhatsAppTemplate(params *twilioApi.CreateMessageParams, template *models.Template, parameters map[string]string, mediaURL string) {
+	order, values := template.Parameters, parameters
+	if mediaURL != "" {
+		order = append(append([]string{}, template.Parameters...), whatsAppMediaVariable)
+		values = make(map[string]string, len(parameters)+1)
+		for key, value := range parameters {
+			values[key] = value
+		}
+		values[whatsAppMediaVariable] = mediaURL
+	}
+
+	// Convertir parámetros nombrados a formato Twilio
+	// Twilio espera variables en formato: {"1":"valor1","2":"valor2","3":"valor3",...}
+	params.SetContentSid(template.ExternalID)
+	params.SetContentVariables(buildTwilioContentVariables(order, values))
+}
+
+// sendTwilioWhatsApp envía el mensaje (plantilla o sesión) desde el número de WhatsApp de la plataforma
+// con el callback de estado y retorna el SID. Es el envío de SendWhatsAppService y del envío por lotes.
+func sendTwilioWhatsApp(twilioClient *twilio.RestClient, from string, params *twilioApi.CreateMessageParams) (string, error) {
+	params.SetFrom("whatsapp:" + from)
+	setTwilioStatusCallback(params)
+
+	message, err := twilioClient.Api.CreateMessage(params)
+	if err != nil {
+		// Log interno del error real para debugging
+		fmt.Printf("Twilio WhatsApp error: %v\n", err)
+		return "", fmt.Errorf("failed to send notification")
+	}
+
+	return *message.Sid, nil
+}
+
+// checkWhatsAppSession verifica que el destinatario le haya escrito al negocio en las últimas 24 horas
+func checkWhatsAppSession(ctx context.Context, repo *repository.ConversationRepository, businessID, phone string) error {
+	conversation, err := repo.Get(ctx, "whatsapp", phone)
+	if err != nil {
+		if err.Error() == "conversation not found" {
+			return fmt.Errorf("whatsapp session window closed")
+		}
+		fmt.Printf("Failed to get conversation: %v\n", err)
+		return fmt.Errorf("service unavailable")
+	}
+
+	// Las respuestas se asignan al último negocio que escribió; la sesión es solo suya
+	if conversation.BusinessID != businessID || conversation.LastInboundAt == "" {
+		return fmt.Errorf("whatsapp session window closed")
+	}
+
+	lastInbound, err := time.Parse(time.RFC3339Nano, conversation.LastInboundAt)
+	if err != nil || time.Since(lastInbound) > whatsappSessionWindow {
+		return fmt.Errorf("whatsapp session window closed")
+	}
+
+	return nil
+}
+
+// validateWhatsAppSessionMessage valida el texto de un mensaje de sesión (el adjunto se valida con validateWhatsAppMedia)
+func validateWhatsAppSessionMessage(req SendWhatsAppRequest) error {
+	if req.Body == "" && len(req.MediaURLs) == 0 {
+		return fmt.Errorf("body or media_urls is required")
+	}
+
+	if utf8.RuneCountInString(req.Body) > maxWhatsAppBodyLength {
+		return fmt.Errorf("message too long")
+	}
+
+	return nil
+}
+
 func SendWhatsAppService(apiKey string, req SendWhatsAppRequest) (*SendWhatsAppResponse, error) {
 	client, _ := db.NewDynamoClient()
 	businessRepo := repository.NewBusinessRepository(client, "NotificationService")
 	planRepo := repository.NewPlanRepository(client, "NotificationService")
 	usageRepo := repository.NewUsageRepository(client, "NotificationService")
 	templateRepo := repository.NewTemplateRepository(client, "NotificationService")
+	suppressionRepo := repository.NewSuppressionRepository(client, "NotificationService")
+	conversationRepo := repository.NewConversationRepository(client, "NotificationService")
+	notificationRepo := repository.NewNotificationRepository(client, "NotificationService")
 	ctx := context.TODO()
 
-	// Validar formato del número de teléfono
-	formattedPhone := utils.FormatPhoneNumber(req.To)
-	if !utils.ValidatePhoneNumber(formattedPhone) {
-		return nil, fmt.Errorf("invalid phone number format")
+	sendAt, err := parseSendAt(req.SendAt)
+	if err != nil {
+		return nil, err
+	}
+	category, err := normalizeDeliveryCategory(req.Category)
+	if err != nil {
+		return nil, err
+	}
+	window, err := normalizeDeliveryWindow(req.DeliveryWindow)
+	if err != nil {
+		return nil, err
+	}
+
+	// Con plantilla se envía un mensaje aprobado; sin plantilla, un mensaje de sesión
+	isSession := req.TemplateID == ""
+	if isSession {
+		if err := validateWhatsAppSessionMessage(req); err != nil {
+			return nil, err
+		}
+	} else if req.Body != "" {
+		return nil, fmt.Errorf("template_id cannot be combined with body")
 	}
-	req.To = formattedPhone
 
 	// Buscar negocio por API Key
 	business, err := businessRepo.GetByAPIKey(ctx, apiKey)
@@ -64,48 +188,110 @@ func SendWhatsAppService(apiKey string, req SendWhatsAppRequest) (*SendWhatsAppR
 
 	businessID := business.PK[9:] // Remover "BUSINESS#"
 
+	// Con contact_id se usan el número de WhatsApp del contacto, la variante de idioma de la plantilla y sus atributos
+	if req.ContactID != "" {
+		recipient, err := resolveContactRecipient(ctx, client, businessID, req.ContactID, NotificationTypeWhatsApp, req.Category, req.To, req.TemplateID, req.Parameters)
+		if err != nil {
+			return nil, err
+		}
+		req.To, req.TemplateID, req.Parameters = recipient.To, recipient.TemplateID, recipient.Parameters
+		if req.timezone == "" {
+			req.timezone = recipient.Timezone
+		}
+	}
+
+	// Normalizar el teléfono a E.164 (los números nacionales usan el país por defecto del negocio)
+	number, err := phone.Parse(req.To, business.DefaultCountry)
+	if err != nil {
+		return nil, err
+	}
+	req.To = number.E164
+
+	// Rechazar destinatarios dados de baja antes de consumir cuota
+	if err := checkSuppressed(ctx, suppressionRepo, businessID, models.SuppressionChannelPhone, []string{req.To}); err != nil {
+		return nil, err
+	}
+
+	// Fuera de la ventana de 24 horas solo se pueden enviar plantillas aprobadas
+	if isSession {
+		if err := checkWhatsAppSession(ctx, conversationRepo, businessID, req.To); err != nil {
+			return nil, err
+		}
+	}
+
 	// Obtener plan
 	plan, err := planRepo.GetByID(ctx, business.PlanID)
 	if err != nil {
 		return nil, fmt.Errorf("service unavailable")
 	}
 
+	// Bloquear países de destino no permitidos antes de cualquier llamada al proveedor
+	if err := checkDestinationCountry(business, plan, models.CountryPolicyChannelWhatsApp, number); err != nil {
+		return nil, err
+	}
+
 	// Verificar o crear período de uso
 	usage, err := usageRepo.CheckAndCreateNewPeriod(ctx, businessID, business.PlanID, plan.PeriodDays)
 	if err != nil {
 		return nil, fmt.Errorf("service unavailable")
 	}
 
-	// Verificar límite de notificaciones
-	if usage.NotificationCount >= plan.NotificationLimit {
+	// Verificar límite de notificaciones (un envío programado ya reservó su cuota)
+	if req.scheduledID == "" && usage.NotificationCount >= plan.NotificationLimit {
 		return nil, fmt.Errorf("notification limit reached")
 	}
 
-	// Validar que la plantilla existe y es de tipo whatsapp
-	template, err := templateRepo.GetByID(ctx, req.TemplateID)
-	if err != nil {
-		return nil, fmt.Errorf("template not found")
-	}
+	params := &twilioApi.CreateMessageParams{}
+	params.SetTo("whatsapp:" + req.To)
 
-	if template.Type != "whatsapp" {
-		return nil, fmt.Errorf("invalid template")
-	}
+	messageType := "session"
+	templateID, templateName, mediaHeader := "", "", ""
+	var template *models.Template
+	if isSession {
+		if req.Body != "" {
+			params.SetBody(req.Body)
+		}
+	} else {
+		// Validar que la plantilla existe y es de tipo whatsapp
+		template, err = templateRepo.GetByID(ctx, req.TemplateID)
+		if err != nil {
+			return nil, fmt.Errorf("template not found")
+		}
 
-	if !template.Active {
-		return nil, fmt.Errorf("template not available")
-	}
+		if template.Type != "whatsapp" {
+			return nil, fmt.Errorf("invalid template")
+		}
+
+		if !template.Active {
+			return nil, fmt.Errorf("template not available")
+		}
 
-	// Validar parámetros de la plantilla
-	validation := templateRepo.ValidateTemplateParameters(template, req.Parameters)
-	if !validation.Valid {
-		if len(validation.MissingParams) > 0 {
-			return nil, fmt.Errorf("invalid template parameters")
+		// Validar parámetros de la plantilla
+		validation := templateRepo.ValidateTemplateParameters(template, req.Parameters)
+		if !validation.Valid {
+			if len(validation.MissingParams) > 0 {
+				return nil, fmt.Errorf("invalid template parameters")
+			}
 		}
+
+		messageType = "template"
+		templateID, templateName, mediaHeader = template.TemplateID, template.Name, template.MediaHeader
 	}
 
-	// Convertir parámetros nombrados a formato Twilio
-	// Twilio espera variables en formato: {"1":"valor1","2":"valor2","3":"valor3",...}
-	contentVariables := buildTwilioContentVariables(template.Parameters, req.Parameters)
+	// Validar tipo y tamaño del adjunto; en plantillas con header multimedia es obligatorio
+	media, err := validateWhatsAppMedia(req.MediaURLs, mediaHeader, !isSession)
+	if err != nil {
+		return nil, err
+	}
+	if template != nil {
+		mediaURL := ""
+		if len(media) > 0 {
+			mediaURL = media[0].URL
+		}
+		setWhatsAppTemplate(params, template, req.Parameters, mediaURL)
+	} else if len(media) > 0 {
+		params.SetMediaUrl(mediaURLsOf(media))
+	}
 
 	// Obtener cliente de Twilio
 	twilioClient := GetTwilioClient()
@@ -116,30 +302,71 @@ func SendWhatsAppService(apiKey string, req SendWhatsAppRequest) (*SendWhatsAppR
 		return nil, fmt.Errorf("service temporarily unavailable")
 	}
 
-	// Enviar mensaje a través de Twilio WhatsApp
-	params := &twilioApi.CreateMessageParams{}
-	params.SetTo("whatsapp:" + req.To)
-	params.SetFrom("whatsapp:" + twilioWhatsAppNumber)
-	params.SetContentSid(template.ExternalID)
-	params.SetContentVariables(contentVariables)
+	// Fuera del horario de envío del destinatario el mensaje se programa para el siguiente horario permitido.
+	// El worker no lo vuelve a aplicar: la fecha programada ya lo respeta.
+	deferred := false
+	if req.scheduledID == "" {
+		sendAt, deferred, err = scheduleInDeliveryWindow(business, window, category, req.timezone, number, sendAt, req.inChain)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	message, err := twilioClient.Api.CreateMessage(params)
+	// Con send_at se reserva la cuota y el worker envía el mensaje en esa fecha.
+	// Un mensaje de sesión se vuelve a validar al enviarse: la ventana de 24 horas puede haberse cerrado.
+	if !sendAt.IsZero() {
+		req.ContactID = ""
+		scheduled, err := scheduleNotification(ctx, client, businessID, plan, usage, NotificationTypeWhatsApp, []string{req.To}, templateID, 1, sendAt, req)
+		if err != nil {
+			return nil, err
+		}
+		return &SendWhatsAppResponse{
+			Success:           true,
+			NotificationID:    scheduled.NotificationID,
+			MessageType:       messageType,
+			TemplateUsed:      templateName,
+			NotificationCount: scheduled.NotificationCount,
+			NotificationLeft:  scheduled.NotificationLeft,
+			Status:            models.NotificationStatusScheduled,
+			SendAt:            scheduled.SendAt,
+			Deferred:          deferred,
+		}, nil
+	}
+
+	// Enviar mensaje a través de Twilio WhatsApp
+	notificationID, err := sendTwilioWhatsApp(twilioClient, twilioWhatsAppNumber, params)
 	if err != nil {
-		// Log interno del error real para debugging
-		fmt.Printf("Twilio WhatsApp error: %v\n", err)
-		return nil, fmt.Errorf("failed to send notification")
+		return nil, err
 	}
+	fmt.Printf("WhatsApp sent - MessageSID: %s, To: %s, Type: %s, Template: %s\n",
+		notificationID, req.To, messageType, templateID)
+
+	// Las respuestas del destinatario se asignan a este negocio
+	recordOutboundConversation(ctx, conversationRepo, "whatsapp", req.To, businessID)
 
-	notificationID := *message.Sid
-	fmt.Printf("WhatsApp sent - MessageSID: %s, To: %s, Template: %s\n",
-		notificationID, req.To, template.TemplateID)
+	recordNotification(ctx, notificationRepo, &models.Notification{
+		PK:                "NOTIFICATION#" + notificationID,
+		SK:                "METADATA",
+		NotificationID:    notificationID,
+		BusinessID:        businessID,
+		Channel:           "whatsapp",
+		Recipients:        []string{req.To},
+		TemplateID:        templateID,
+		Status:            "sent",
+		Provider:          "twilio",
+		ProviderMessageID: notificationID,
+		Media:             media,
+		CreatedAt:         time.Now().Format(time.RFC3339),
+	})
 
 	// Incrementar contador de uso
-	err = usageRepo.IncrementUsage(ctx, businessID, usage.SK)
-	if err != nil {
-		// Log interno para debugging
-		fmt.Printf("Failed to increment usage: %v\n", err)
-		// No fallar la request si el mensaje ya fue enviado
+	if req.scheduledID == "" {
+		err = usageRepo.IncrementUsage(ctx, businessID, usage.SK)
+		if err != nil {
+			// Log interno para debugging
+			fmt.Printf("Failed to increment usage: %v\n", err)
+			// No fallar la request si el mensaje ya fue enviado
+		}
 	}
 
 	// Calcular notificaciones restantes
@@ -152,7 +379,8 @@ func SendWhatsAppService(apiKey string, req SendWhatsAppRequest) (*SendWhatsAppR
 	return &SendWhatsAppResponse{
 		Success:           true,
 		NotificationID:    notificationID,
-		TemplateUsed:      template.Name,
+		MessageType:       messageType,
+		TemplateUsed:      templateName,
 		NotificationCount: newCount,
 		NotificationLeft:  notificationLeft,
 	}, nil
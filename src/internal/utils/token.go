@@ -0,0 +1,87 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SignToken serializa el payload y lo firma con HMAC-SHA256 (SIGNING_SECRET).
+// Formato: base64url(payload).base64url(firma). Se usa en URLs públicas (tracking, unsubscribe),
+// donde el contenido no es secreto pero no debe poder alterarse.
+func SignToken(payload interface{}) (string, error) {
+	secret := os.Getenv("SIGNING_SECRET")
+	if secret == "" {
+		return "", fmt.Errorf("SIGNING_SECRET is not configured")
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(data)
+	return encoded + "." + tokenSignature(secret, encoded), nil
+}
+
+// VerifyToken valida la firma y decodifica el payload en v
+func VerifyToken(token string, v interface{}) error {
+	secret := os.Getenv("SIGNING_SECRET")
+	if secret == "" {
+		return fmt.Errorf("SIGNING_SECRET is not configured")
+	}
+
+	dot := strings.LastIndex(token, ".")
+	if dot == -1 {
+		return fmt.Errorf("invalid token")
+	}
+
+	encoded, signature := token[:dot], token[dot+1:]
+	if !hmac.Equal([]byte(signature), []byte(tokenSignature(secret, encoded))) {
+		return fmt.Errorf("invalid token")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("invalid token")
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("invalid token")
+	}
+
+	return nil
+}
+
+// HashSecret retorna el HMAC-SHA256 (SIGNING_SECRET) de value en hex. Se usa para guardar secretos cortos
+// (códigos de verificación): sin la llave, el hash guardado en la tabla no se puede atacar por fuerza bruta.
+func HashSecret(value string) (string, error) {
+	secret := os.Getenv("SIGNING_SECRET")
+	if secret == "" {
+		return "", fmt.Errorf("SIGNING_SECRET is not configured")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(value))
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+func tokenSignature(secret, encoded string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// PublicURL construye una URL pública de la API a partir de PUBLIC_BASE_URL (ej. https://api.miempresa.com)
+func PublicURL(path string) (string, error) {
+	base := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/")
+	if base == "" {
+		return "", fmt.Errorf("PUBLIC_BASE_URL is not configured")
+	}
+	return base + path, nil
+}